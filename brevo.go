@@ -15,8 +15,10 @@ type BrevoClient interface {
 
 // BrevoService implements the SenderService interface using Brevo
 type BrevoService struct {
-	client BrevoClient
-	from   *brevo.SendSmtpEmailSender
+	client  BrevoClient
+	from    *brevo.SendSmtpEmailSender
+	limits  SizeLimits
+	headers HeaderPolicy
 }
 
 // NewBrevoService returns a new instance of BrevoService
@@ -30,6 +32,8 @@ func NewBrevoService(apiKey, senderName, senderEmail string) SenderService {
 			Name:  senderName,
 			Email: senderEmail,
 		},
+		limits:  BrevoSizeLimits,
+		headers: BrevoHeaderPolicy,
 	}
 	var service SenderService = &s
 	return service
@@ -51,8 +55,27 @@ func (s *BrevoService) Send(message *EmailMessage) error {
 // field, kept verbatim including the surrounding chevrons (e.g.
 // "<xxx@smtp-relay.mailin.fr>"). Brevo webhooks echo this same value in their
 // message-id field, so it is the join key used to track delivery status.
+//
+// Messages are validated (see EmailMessage.Validate) and those exceeding BrevoSizeLimits
+// are rejected with ErrMessageTooLarge before calling the API. Custom headers must be allowed
+// by BrevoHeaderPolicy; the threading fields are sent as headers and the tags as tags.
+// The From address of the message, when set, overrides the configured sender.
+// Messages with transformers are rejected with ErrTransformersUnsupported.
 func (s *BrevoService) SendWithResult(message *EmailMessage) (SendResult, error) {
-	brevoMsg := s.buildMessage(message)
+	if len(message.Transformers) > 0 {
+		return SendResult{}, ErrTransformersUnsupported
+	}
+	if err := message.Validate(); err != nil {
+		return SendResult{}, err
+	}
+	if err := checkMessageSize(message, s.limits); err != nil {
+		return SendResult{}, err
+	}
+
+	brevoMsg, err := s.buildMessage(message)
+	if err != nil {
+		return SendResult{}, err
+	}
 
 	res, _, err := s.client.SendTransacEmail(context.Background(), brevoMsg)
 	if err != nil {
@@ -63,15 +86,19 @@ func (s *BrevoService) SendWithResult(message *EmailMessage) (SendResult, error)
 }
 
 // buildMessage maps an EmailMessage onto the Brevo request payload.
-func (s *BrevoService) buildMessage(message *EmailMessage) brevo.SendSmtpEmail {
+func (s *BrevoService) buildMessage(message *EmailMessage) (brevo.SendSmtpEmail, error) {
 	brevoMsg := brevo.SendSmtpEmail{
 		Sender:      s.from,
 		To:          []brevo.SendSmtpEmailTo{{Email: message.To, Name: message.To}},
 		Subject:     message.Subject,
 		TextContent: message.PlainTextContent,
 		HtmlContent: message.HTMLContent,
+		Tags:        message.Tags,
 	}
 
+	if message.From != nil {
+		brevoMsg.Sender = &brevo.SendSmtpEmailSender{Name: message.From.Name, Email: message.From.Address}
+	}
 	if message.ReplyTo != nil && message.ReplyTo.Address != "" {
 		brevoMsg.ReplyTo = &brevo.SendSmtpEmailReplyTo{
 			Name:  message.ReplyTo.Name,
@@ -79,9 +106,14 @@ func (s *BrevoService) buildMessage(message *EmailMessage) brevo.SendSmtpEmail {
 		}
 	}
 
-	if len(message.Headers) > 0 {
-		h := make(map[string]interface{}, len(message.Headers))
-		for k, v := range message.Headers {
+	headers, err := s.headers.Sanitize(message.Headers)
+	if err != nil {
+		return brevo.SendSmtpEmail{}, err
+	}
+	headers = message.withThreadingHeaders(headers)
+	if len(headers) > 0 {
+		h := make(map[string]interface{}, len(headers))
+		for k, v := range headers {
 			h[k] = v
 		}
 		brevoMsg.Headers = h
@@ -98,5 +130,5 @@ func (s *BrevoService) buildMessage(message *EmailMessage) brevo.SendSmtpEmail {
 		brevoMsg.Attachment = atts
 	}
 
-	return brevoMsg
+	return brevoMsg, nil
 }
@@ -0,0 +1,224 @@
+package goat
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/mail"
+	"net/textproto"
+	"net/url"
+	"strings"
+	"time"
+
+	brevo "github.com/getbrevo/brevo-go/lib"
+)
+
+// brevoInboundAddress is an address of a Brevo inbound parsing webhook item.
+type brevoInboundAddress struct {
+	Name    string `json:"Name"`
+	Address string `json:"Address"`
+}
+
+// brevoInboundItem is an email of a Brevo inbound parsing webhook request.
+type brevoInboundItem struct {
+	MessageID   string                     `json:"MessageId"`
+	InReplyTo   string                     `json:"InReplyTo"`
+	From        *brevoInboundAddress       `json:"From"`
+	To          []brevoInboundAddress      `json:"To"`
+	Cc          []brevoInboundAddress      `json:"Cc"`
+	ReplyTo     *brevoInboundAddress       `json:"ReplyTo"`
+	Recipients  []string                   `json:"Recipients"`
+	Subject     string                     `json:"Subject"`
+	RawTextBody string                     `json:"RawTextBody"`
+	RawHTMLBody string                     `json:"RawHtmlBody"`
+	SpamScore   float64                    `json:"SpamScore"`
+	Headers     map[string]json.RawMessage `json:"Headers"` // string or array of strings
+	Attachments []struct {
+		Name          string `json:"Name"`
+		ContentType   string `json:"ContentType"`
+		ContentID     string `json:"ContentID"`
+		DownloadToken string `json:"DownloadToken"`
+	} `json:"Attachments"`
+}
+
+// DefaultBrevoAttachmentTimeout is the timeout of the attachment downloads of a BrevoInboundHandler.
+const DefaultBrevoAttachmentTimeout = 30 * time.Second
+
+// BrevoInboundOptions configures a BrevoInboundHandler.
+type BrevoInboundOptions struct {
+	BrevoWebhookOptions
+	// APIKey is used to download the attachments. When empty, attachments are passed without content.
+	APIKey string
+	// HTTPClient downloads the attachments, a client with DefaultBrevoAttachmentTimeout when nil.
+	HTTPClient *http.Client
+}
+
+// BrevoInboundHandler is an http.Handler receiving emails from the Brevo inbound parsing webhook.
+type BrevoInboundHandler struct {
+	onEmail func(ctx context.Context, email *InboundEmail) error
+	opts    BrevoInboundOptions
+	client  *http.Client
+	baseURL string
+}
+
+// NewBrevoInboundHandler returns a handler for the Brevo inbound parsing webhook, calling onEmail with each
+// email of a request. The basic auth and IP checks of opts apply as for a BrevoWebhookHandler. Brevo only sends
+// attachment download tokens: with an APIKey, the attachments are downloaded from the Brevo API before calling
+// onEmail, and a failed or timed out download answers the request with a 500 status, as does an error of onEmail,
+// so that Brevo retries it later. Brevo then resends the whole request, emails processed before included:
+// onEmail should skip the emails whose MessageID was already processed.
+func NewBrevoInboundHandler(onEmail func(ctx context.Context, email *InboundEmail) error, opts BrevoInboundOptions) *BrevoInboundHandler {
+	client := opts.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: DefaultBrevoAttachmentTimeout}
+	}
+	return &BrevoInboundHandler{onEmail: onEmail, opts: opts, client: client, baseURL: brevo.NewConfiguration().BasePath}
+}
+
+// ServeHTTP implements the http.Handler interface.
+func (h *BrevoInboundHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !basicAuthorized(r, h.opts.Username, h.opts.Password) {
+		w.Header().Set("WWW-Authenticate", `Basic realm="inbound"`)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if !clientAllowed(r, h.opts.AllowedIPs, h.opts.TrustForwardedFor, h.opts.TrustedProxies) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	items, err := decodeBrevoInbound(http.MaxBytesReader(w, r.Body, maxInboundBodySize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	for _, item := range items {
+		email := item.inboundEmail()
+		if h.opts.APIKey != "" {
+			for i, attachment := range item.Attachments {
+				content, err := h.downloadAttachment(r.Context(), attachment.DownloadToken)
+				if err != nil {
+					http.Error(w, "attachment not downloaded", http.StatusInternalServerError)
+					return
+				}
+				email.Attachments[i].Content = content
+			}
+		}
+		if err := h.onEmail(r.Context(), email); err != nil {
+			http.Error(w, "email not processed", http.StatusInternalServerError)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// downloadAttachment downloads the content of an inbound email attachment from the Brevo API.
+func (h *BrevoInboundHandler) downloadAttachment(ctx context.Context, downloadToken string) ([]byte, error) {
+	endpoint := h.baseURL + "/inbound/attachments/" + url.PathEscape(downloadToken)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("api-key", h.opts.APIKey)
+
+	res, err := h.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= http.StatusMultipleChoices {
+		return nil, fmt.Errorf("downloading Brevo inbound attachment: status %d", res.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(res.Body, maxInboundBodySize))
+}
+
+// ParseBrevoInbound parses a Brevo inbound parsing webhook payload into InboundEmails.
+// Attachments are listed without their content, which must be downloaded from the Brevo API.
+func ParseBrevoInbound(r io.Reader) ([]*InboundEmail, error) {
+	items, err := decodeBrevoInbound(r)
+	if err != nil {
+		return nil, err
+	}
+	emails := make([]*InboundEmail, 0, len(items))
+	for _, item := range items {
+		emails = append(emails, item.inboundEmail())
+	}
+	return emails, nil
+}
+
+// decodeBrevoInbound decodes the items of a Brevo inbound parsing webhook payload.
+func decodeBrevoInbound(r io.Reader) ([]brevoInboundItem, error) {
+	var payload struct {
+		Items []brevoInboundItem `json:"items"`
+	}
+	if err := json.NewDecoder(r).Decode(&payload); err != nil {
+		return nil, fmt.Errorf("invalid Brevo inbound payload: %w", err)
+	}
+	return payload.Items, nil
+}
+
+// inboundEmail converts the item to an InboundEmail.
+func (item brevoInboundItem) inboundEmail() *InboundEmail {
+	email := &InboundEmail{
+		Provider:   ProviderBrevo,
+		To:         brevoInboundAddresses(item.To),
+		Cc:         brevoInboundAddresses(item.Cc),
+		Recipients: item.Recipients,
+		Subject:    item.Subject,
+		Text:       item.RawTextBody,
+		HTML:       item.RawHTMLBody,
+		Headers:    mail.Header{},
+		MessageID:  item.MessageID,
+		InReplyTo:  item.InReplyTo,
+		SpamScore:  item.SpamScore,
+	}
+	if item.From != nil {
+		email.From = Address{Name: item.From.Name, Address: item.From.Address}
+	}
+	if item.ReplyTo != nil {
+		email.ReplyTo = &Address{Name: item.ReplyTo.Name, Address: item.ReplyTo.Address}
+	}
+
+	for key, raw := range item.Headers {
+		var values []string
+		if err := json.Unmarshal(raw, &values); err != nil {
+			var value string
+			if json.Unmarshal(raw, &value) != nil {
+				continue
+			}
+			values = []string{value}
+		}
+		key = textproto.CanonicalMIMEHeaderKey(key)
+		email.Headers[key] = append(email.Headers[key], values...)
+	}
+	email.References = strings.Fields(email.Headers.Get("References"))
+
+	for _, a := range item.Attachments {
+		email.Attachments = append(email.Attachments, Attachment{
+			Filename:    a.Name,
+			ContentType: a.ContentType,
+			ContentID:   a.ContentID,
+		})
+	}
+	return email
+}
+
+// brevoInboundAddresses converts inbound addresses, returning nil when there is none.
+func brevoInboundAddresses(list []brevoInboundAddress) []Address {
+	if len(list) == 0 {
+		return nil
+	}
+	addresses := make([]Address, 0, len(list))
+	for _, a := range list {
+		addresses = append(addresses, Address{Name: a.Name, Address: a.Address})
+	}
+	return addresses
+}
@@ -0,0 +1,212 @@
+package goat
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const brevoInboundPayload = `{
+	"items": [{
+		"Uuid": ["8a3f4d1e-0f5b-4a3e-9c1d-1f2e3d4c5b6a"],
+		"MessageId": "<reply-1@mail.example.com>",
+		"InReplyTo": "<order-42@example.com>",
+		"From": {"Name": "Renee", "Address": "renee@example.com"},
+		"To": [{"Name": null, "Address": "support@example.com"}],
+		"Cc": [],
+		"ReplyTo": null,
+		"SentAtDate": "Sat, 14 Mar 2026 15:09:26 +0000",
+		"Subject": "Re: Your order",
+		"Attachments": [
+			{"Name": "invoice.pdf", "ContentType": "application/pdf", "ContentLength": 5, "ContentID": "", "DownloadToken": "token-1"}
+		],
+		"Headers": {
+			"Received": ["from mx1.example.com", "from mx2.example.com"],
+			"references": "<welcome-1@example.com> <order-42@example.com>",
+			"X-Mailer": "Mail"
+		},
+		"SpamScore": 0.7,
+		"ExtractedMarkdownMessage": "Where is my parcel?",
+		"RawHtmlBody": "<p>Where is my parcel?</p>",
+		"RawTextBody": "Where is my parcel?",
+		"Recipients": ["support@example.com"]
+	}]
+}`
+
+// TestParseBrevoInbound tests the ParseBrevoInbound function
+func TestParseBrevoInbound(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		emails, err := ParseBrevoInbound(strings.NewReader(brevoInboundPayload))
+		assert.NoError(t, err)
+		assert.Len(t, emails, 1)
+
+		email := emails[0]
+		assert.Equal(t, ProviderBrevo, email.Provider)
+		assert.Equal(t, Address{Name: "Renee", Address: "renee@example.com"}, email.From)
+		assert.Equal(t, []Address{{Address: "support@example.com"}}, email.To)
+		assert.Nil(t, email.Cc)
+		assert.Nil(t, email.ReplyTo)
+		assert.Equal(t, []string{"support@example.com"}, email.Recipients)
+		assert.Equal(t, "Re: Your order", email.Subject)
+		assert.Equal(t, "Where is my parcel?", email.Text)
+		assert.Equal(t, "<p>Where is my parcel?</p>", email.HTML)
+		assert.Equal(t, "<reply-1@mail.example.com>", email.MessageID)
+		assert.Equal(t, "<order-42@example.com>", email.InReplyTo)
+		assert.Equal(t, []string{"<welcome-1@example.com>", "<order-42@example.com>"}, email.References)
+		assert.Equal(t, 0.7, email.SpamScore)
+		assert.Equal(t, []string{"from mx1.example.com", "from mx2.example.com"}, email.Headers["Received"])
+		assert.Equal(t, "Mail", email.Headers.Get("X-Mailer"))
+		assert.Equal(t, []Attachment{{Filename: "invoice.pdf", ContentType: "application/pdf"}}, email.Attachments)
+	})
+
+	t.Run("Failure - invalid payload", func(t *testing.T) {
+		_, err := ParseBrevoInbound(strings.NewReader(`{"items": {}}`))
+		assert.ErrorContains(t, err, "invalid Brevo inbound payload")
+	})
+}
+
+// TestNewBrevoInboundHandler tests the default attachment download client of NewBrevoInboundHandler
+func TestNewBrevoInboundHandler(t *testing.T) {
+	handler := NewBrevoInboundHandler(nil, BrevoInboundOptions{})
+	assert.Equal(t, DefaultBrevoAttachmentTimeout, handler.client.Timeout)
+
+	client := &http.Client{Timeout: time.Second}
+	handler = NewBrevoInboundHandler(nil, BrevoInboundOptions{HTTPClient: client})
+	assert.Same(t, client, handler.client)
+}
+
+// TestBrevoInboundHandler tests the ServeHTTP method of BrevoInboundHandler
+func TestBrevoInboundHandler(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("api-key") != "api-key" || r.URL.Path != "/inbound/attachments/token-1" {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte("%PDF-"))
+	}))
+	defer api.Close()
+
+	var received []*InboundEmail
+	onEmail := func(ctx context.Context, email *InboundEmail) error {
+		received = append(received, email)
+		if email.Subject == "fail" {
+			return errors.New("ticketing unavailable")
+		}
+		return nil
+	}
+
+	newHandler := func(opts BrevoInboundOptions) *BrevoInboundHandler {
+		handler := NewBrevoInboundHandler(onEmail, opts)
+		handler.baseURL = api.URL
+		return handler
+	}
+	newRequest := func(body string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/inbound/brevo", strings.NewReader(body))
+		req.RemoteAddr = "1.179.112.10:43210"
+		return req
+	}
+
+	t.Run("Success - attachments downloaded", func(t *testing.T) {
+		received = nil
+		handler := newHandler(BrevoInboundOptions{APIKey: "api-key"})
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, newRequest(brevoInboundPayload))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Len(t, received, 1)
+		assert.Equal(t, []byte("%PDF-"), received[0].Attachments[0].Content)
+	})
+
+	t.Run("Failure - attachment download timeout", func(t *testing.T) {
+		stalled := make(chan struct{})
+		slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-stalled:
+			case <-r.Context().Done():
+			}
+		}))
+		defer slow.Close()
+		defer close(stalled)
+
+		received = nil
+		handler := NewBrevoInboundHandler(onEmail, BrevoInboundOptions{APIKey: "api-key", HTTPClient: &http.Client{Timeout: 50 * time.Millisecond}})
+		handler.baseURL = slow.URL
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, newRequest(brevoInboundPayload))
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		assert.Empty(t, received)
+	})
+
+	t.Run("Success - attachments not downloaded", func(t *testing.T) {
+		received = nil
+		handler := newHandler(BrevoInboundOptions{})
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, newRequest(brevoInboundPayload))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Len(t, received, 1)
+		assert.Nil(t, received[0].Attachments[0].Content)
+	})
+
+	handler := newHandler(BrevoInboundOptions{
+		BrevoWebhookOptions: BrevoWebhookOptions{Username: "brevo", Password: "s3cret", AllowedIPs: BrevoWebhookIPRanges},
+		APIKey:              "wrong-key",
+	})
+
+	tests := []struct {
+		name     string
+		request  func() *http.Request
+		status   int
+		received int
+	}{
+		{"method not allowed", func() *http.Request {
+			return httptest.NewRequest(http.MethodGet, "/inbound/brevo", nil)
+		}, http.StatusMethodNotAllowed, 0},
+		{"missing credentials", func() *http.Request { return newRequest(brevoInboundPayload) }, http.StatusUnauthorized, 0},
+		{"address not allowed", func() *http.Request {
+			req := newRequest(brevoInboundPayload)
+			req.SetBasicAuth("brevo", "s3cret")
+			req.RemoteAddr = "203.0.113.7:43210"
+			return req
+		}, http.StatusForbidden, 0},
+		{"spoofed X-Forwarded-For", func() *http.Request {
+			req := newRequest(brevoInboundPayload)
+			req.SetBasicAuth("brevo", "s3cret")
+			req.RemoteAddr = "203.0.113.7:43210"
+			req.Header.Set("X-Forwarded-For", "1.179.112.10")
+			return req
+		}, http.StatusForbidden, 0},
+		{"invalid payload", func() *http.Request {
+			req := newRequest("not json")
+			req.SetBasicAuth("brevo", "s3cret")
+			return req
+		}, http.StatusBadRequest, 0},
+		{"attachment download error", func() *http.Request {
+			req := newRequest(brevoInboundPayload)
+			req.SetBasicAuth("brevo", "s3cret")
+			return req
+		}, http.StatusInternalServerError, 0},
+		{"callback error", func() *http.Request {
+			req := newRequest(`{"items": [{"Subject": "fail"}]}`)
+			req.SetBasicAuth("brevo", "s3cret")
+			return req
+		}, http.StatusInternalServerError, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run("Failure - "+tt.name, func(t *testing.T) {
+			received = nil
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, tt.request())
+			assert.Equal(t, tt.status, rec.Code)
+			assert.Len(t, received, tt.received)
+		})
+	}
+}
@@ -42,6 +42,24 @@ func TestBrevoService_Send(t *testing.T) {
 		assert.NoError(t, err)
 	})
 
+	t.Run("Success - configured sender", func(t *testing.T) {
+		mock := &MockBrevoClient{SendError: nil}
+		service.(*BrevoService).client = mock
+
+		assert.NoError(t, service.Send(NewEmailMessage("test@example.com", "Test Subject", "Test Plain Text", "")))
+		assert.Equal(t, &brevo.SendSmtpEmailSender{Name: "test_sender_name", Email: "test_sender_email"}, mock.LastEmail.Sender)
+	})
+
+	t.Run("Success - message from address", func(t *testing.T) {
+		mock := &MockBrevoClient{SendError: nil}
+		service.(*BrevoService).client = mock
+
+		msg := NewEmailMessage("test@example.com", "Test Subject", "Test Plain Text", "").
+			WithFrom("Billing", "billing@example.com")
+		assert.NoError(t, service.Send(msg))
+		assert.Equal(t, &brevo.SendSmtpEmailSender{Name: "Billing", Email: "billing@example.com"}, mock.LastEmail.Sender)
+	})
+
 	t.Run("Success - with reply-to", func(t *testing.T) {
 		service.(*BrevoService).client = &MockBrevoClient{SendError: nil}
 
@@ -86,6 +104,18 @@ func TestBrevoService_Send(t *testing.T) {
 		assert.Equal(t, base64.StdEncoding.EncodeToString(content), att.Content)
 	})
 
+	t.Run("Success - attachment without content type", func(t *testing.T) {
+		mock := &MockBrevoClient{SendError: nil}
+		service.(*BrevoService).client = mock
+
+		msg := NewEmailMessage("test@example.com", "Test Subject", "Test Plain Text", "Test HTML Content").
+			WithAttachment("invoice.pdf", "", []byte("PDF bytes"))
+		assert.NoError(t, service.Send(msg))
+
+		assert.Len(t, mock.LastEmail.Attachment, 1)
+		assert.Equal(t, "invoice.pdf", mock.LastEmail.Attachment[0].Name)
+	})
+
 	t.Run("Success - inline attachment degrades to regular attachment", func(t *testing.T) {
 		mock := &MockBrevoClient{SendError: nil}
 		service.(*BrevoService).client = mock
@@ -113,6 +143,89 @@ func TestBrevoService_Send(t *testing.T) {
 		assert.Len(t, mock.LastEmail.Attachment, 1)
 	})
 
+	t.Run("Success - non-ASCII header encoded", func(t *testing.T) {
+		mock := &MockBrevoClient{}
+		service.(*BrevoService).client = mock
+
+		msg := NewEmailMessage("test@example.com", "Test Subject", "Test Plain Text", "Test HTML Content").
+			WithHeader("X-Greeting", "Café")
+		err := service.Send(msg)
+		assert.NoError(t, err)
+		assert.Equal(t, "=?utf-8?q?Caf=C3=A9?=", mock.LastEmail.Headers["X-Greeting"])
+	})
+
+	t.Run("Success - with threading", func(t *testing.T) {
+		mock := &MockBrevoClient{}
+		service.(*BrevoService).client = mock
+
+		msg := NewEmailMessage("test@example.com", "Re: Test Subject", "Test Plain Text", "").
+			WithHeader("In-Reply-To", "<stale@example.com>").
+			WithInReplyTo("<order-42@example.com>")
+		err := service.Send(msg)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{
+			"In-Reply-To": "<order-42@example.com>",
+			"References":  "<order-42@example.com>",
+		}, mock.LastEmail.Headers)
+	})
+
+	t.Run("Success - with tags", func(t *testing.T) {
+		mock := &MockBrevoClient{}
+		service.(*BrevoService).client = mock
+
+		msg := NewEmailMessage("test@example.com", "Test Subject", "Test Plain Text", "").WithTags("newsletter", "march")
+		err := service.Send(msg)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"newsletter", "march"}, mock.LastEmail.Tags)
+	})
+
+	t.Run("Failure - invalid message", func(t *testing.T) {
+		mock := &MockBrevoClient{}
+		service.(*BrevoService).client = mock
+
+		err := service.Send(NewEmailMessage("invalid", "", "Test Plain Text", "Test HTML Content"))
+		assert.Error(t, err)
+		assert.Nil(t, mock.LastEmail.Sender)
+	})
+
+	t.Run("Failure - reserved header", func(t *testing.T) {
+		mock := &MockBrevoClient{}
+		service.(*BrevoService).client = mock
+
+		msg := NewEmailMessage("test@example.com", "Test Subject", "Test Plain Text", "Test HTML Content").
+			WithHeader("Return-Path", "value")
+		err := service.Send(msg)
+
+		var headerErr *HeaderError
+		assert.ErrorAs(t, err, &headerErr)
+		assert.Equal(t, "Return-Path", headerErr.Header)
+		assert.Nil(t, mock.LastEmail.Sender)
+	})
+
+	t.Run("Failure - transformers", func(t *testing.T) {
+		mock := &MockBrevoClient{}
+		service.(*BrevoService).client = mock
+
+		msg := NewEmailMessage("test@example.com", "Test Subject", "Test Plain Text", "Test HTML Content").
+			WithTransformer(upperTransformer{})
+		err := service.Send(msg)
+		assert.ErrorIs(t, err, ErrTransformersUnsupported)
+		assert.Nil(t, mock.LastEmail.Sender)
+	})
+
+	t.Run("Failure - message too large", func(t *testing.T) {
+		mock := &MockBrevoClient{}
+		service.(*BrevoService).client = mock
+		service.(*BrevoService).limits = SizeLimits{MaxAttachments: 100}
+		defer func() { service.(*BrevoService).limits = BrevoSizeLimits }()
+
+		msg := NewEmailMessage("test@example.com", "Test Subject", "Test Plain Text", "Test HTML Content").
+			WithAttachment("large.bin", "application/octet-stream", make([]byte, 200))
+		err := service.Send(msg)
+		assert.ErrorIs(t, err, ErrMessageTooLarge)
+		assert.Nil(t, mock.LastEmail.Sender)
+	})
+
 	t.Run("Failure", func(t *testing.T) {
 		service.(*BrevoService).client = &MockBrevoClient{SendError: fmt.Errorf("failed to send email")}
 
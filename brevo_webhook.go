@@ -0,0 +1,245 @@
+package goat
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"time"
+)
+
+// maxWebhookBodySize is the maximum size of a webhook request body.
+const maxWebhookBodySize = 1 << 20
+
+// BrevoEventType is the type of a Brevo transactional webhook event.
+type BrevoEventType string
+
+const (
+	BrevoEventRequest         BrevoEventType = "request" // Message accepted by Brevo
+	BrevoEventDelivered       BrevoEventType = "delivered"
+	BrevoEventDeferred        BrevoEventType = "deferred"
+	BrevoEventSoftBounce      BrevoEventType = "soft_bounce"
+	BrevoEventHardBounce      BrevoEventType = "hard_bounce"
+	BrevoEventInvalidEmail    BrevoEventType = "invalid_email"
+	BrevoEventBlocked         BrevoEventType = "blocked"
+	BrevoEventError           BrevoEventType = "error"
+	BrevoEventOpened          BrevoEventType = "opened"
+	BrevoEventUniqueOpened    BrevoEventType = "unique_opened"
+	BrevoEventProxyOpen       BrevoEventType = "proxy_open" // Open through a privacy proxy such as Apple Mail Privacy Protection
+	BrevoEventUniqueProxyOpen BrevoEventType = "unique_proxy_open"
+	BrevoEventClick           BrevoEventType = "click"
+	BrevoEventSpam            BrevoEventType = "spam"
+	BrevoEventUnsubscribed    BrevoEventType = "unsubscribed"
+)
+
+// BrevoWebhookIPRanges are the ranges Brevo sends webhooks from, to use as BrevoWebhookOptions.AllowedIPs.
+var BrevoWebhookIPRanges = []netip.Prefix{
+	netip.MustParsePrefix("1.179.112.0/20"),
+	netip.MustParsePrefix("172.246.240.0/20"),
+}
+
+// BrevoEvent is an event of the Brevo transactional webhook.
+type BrevoEvent struct {
+	Type       BrevoEventType
+	Email      string    // Recipient address
+	MessageID  string    // Chevron-wrapped, as returned in SendResult.MessageID
+	Time       time.Time // Time of the event
+	Subject    string
+	Tags       []string
+	Reason     string // Bounce, deferral, block or error reason
+	Link       string // Clicked link
+	TemplateID int64
+	SendingIP  string
+	WebhookID  int64
+	Raw        json.RawMessage // Original payload, for fields not mapped above
+}
+
+// brevoPayload is the JSON payload of a Brevo transactional webhook event.
+type brevoPayload struct {
+	Event      BrevoEventType `json:"event"`
+	Email      string         `json:"email"`
+	MessageID  string         `json:"message-id"`
+	Subject    string         `json:"subject"`
+	Tags       []string       `json:"tags"`
+	Tag        string         `json:"tag"`
+	Reason     string         `json:"reason"`
+	Link       string         `json:"link"`
+	TemplateID int64          `json:"template_id"`
+	SendingIP  string         `json:"sending_ip"`
+	ID         int64          `json:"id"`
+	TS         int64          `json:"ts"`
+	TSEvent    int64          `json:"ts_event"`
+	TSEpoch    int64          `json:"ts_epoch"` // milliseconds
+}
+
+// BrevoWebhookOptions configures the validation of Brevo webhook requests.
+type BrevoWebhookOptions struct {
+	Username string // Basic auth credentials expected when Username is set, as configured in the webhook URL
+	Password string
+	// AllowedIPs restricts the client addresses when not empty, see BrevoWebhookIPRanges.
+	AllowedIPs []netip.Prefix
+	// TrustForwardedFor takes the client address from the X-Forwarded-For header, for handlers behind
+	// reverse proxies. Clients can send the header themselves, so only the entries appended by the proxies
+	// are trusted: the client address is the TrustedProxies-th entry from the right.
+	TrustForwardedFor bool
+	// TrustedProxies is the number of reverse proxies appending to X-Forwarded-For in front of the handler,
+	// 1 when zero. Requests with fewer entries are rejected.
+	TrustedProxies int
+}
+
+// BrevoWebhookHandler is an http.Handler receiving Brevo transactional webhook events.
+type BrevoWebhookHandler struct {
+	onEvent func(ctx context.Context, event BrevoEvent) error
+	opts    BrevoWebhookOptions
+}
+
+// NewBrevoWebhookHandler returns a handler for the Brevo transactional webhook, calling onEvent with the event
+// of a request, or each event in order when batching is enabled in the webhook settings. Requests failing
+// the basic auth or IP checks of opts get a 401 or 403 status. When onEvent fails, the handler answers 500
+// and Brevo resends the whole request, events processed before included: see BrevoEvent.DeliveryEvent
+// for a key to deduplicate them.
+func NewBrevoWebhookHandler(onEvent func(ctx context.Context, event BrevoEvent) error, opts BrevoWebhookOptions) *BrevoWebhookHandler {
+	return &BrevoWebhookHandler{onEvent: onEvent, opts: opts}
+}
+
+// ServeHTTP implements the http.Handler interface.
+func (h *BrevoWebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !basicAuthorized(r, h.opts.Username, h.opts.Password) {
+		w.Header().Set("WWW-Authenticate", `Basic realm="webhook"`)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if !clientAllowed(r, h.opts.AllowedIPs, h.opts.TrustForwardedFor, h.opts.TrustedProxies) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	events, err := ParseBrevoEvents(http.MaxBytesReader(w, r.Body, maxWebhookBodySize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	for _, event := range events {
+		if err := h.onEvent(r.Context(), event); err != nil {
+			http.Error(w, "event not processed", http.StatusInternalServerError)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// basicAuthorized reports whether the request carries the expected basic auth credentials.
+// Every request is authorized when username is empty.
+func basicAuthorized(r *http.Request, username, password string) bool {
+	if username == "" {
+		return true
+	}
+	u, p, ok := r.BasicAuth()
+	return ok &&
+		subtle.ConstantTimeCompare([]byte(u), []byte(username)) == 1 &&
+		subtle.ConstantTimeCompare([]byte(p), []byte(password)) == 1
+}
+
+// clientAllowed reports whether the client address of the request is in one of the allowed prefixes.
+// Every client is allowed when there is no prefix. With trustForwardedFor, the client address is
+// the X-Forwarded-For entry appended by the outermost of the trusted proxies, counted from the right.
+func clientAllowed(r *http.Request, allowed []netip.Prefix, trustForwardedFor bool, trustedProxies int) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+
+	host := r.RemoteAddr
+	if h, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		host = h
+	}
+	if trustForwardedFor {
+		if trustedProxies < 1 {
+			trustedProxies = 1
+		}
+		forwarded := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+		if len(forwarded) < trustedProxies {
+			return false
+		}
+		host = forwarded[len(forwarded)-trustedProxies]
+	}
+
+	addr, err := netip.ParseAddr(strings.TrimSpace(host))
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range allowed {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseBrevoEvents parses a Brevo transactional webhook payload, a single event or an array of events.
+func ParseBrevoEvents(r io.Reader) ([]BrevoEvent, error) {
+	var raw json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("invalid Brevo webhook payload: %w", err)
+	}
+
+	var items []json.RawMessage
+	if trimmed := bytes.TrimSpace(raw); len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(raw, &items); err != nil {
+			return nil, fmt.Errorf("invalid Brevo webhook payload: %w", err)
+		}
+	} else {
+		items = []json.RawMessage{raw}
+	}
+
+	events := make([]BrevoEvent, 0, len(items))
+	for _, item := range items {
+		var p brevoPayload
+		if err := json.Unmarshal(item, &p); err != nil {
+			return nil, fmt.Errorf("invalid Brevo webhook event: %w", err)
+		}
+		if p.Event == "" {
+			return nil, errors.New("invalid Brevo webhook event: missing event type")
+		}
+
+		event := BrevoEvent{
+			Type:       p.Event,
+			Email:      p.Email,
+			MessageID:  p.MessageID,
+			Subject:    p.Subject,
+			Tags:       p.Tags,
+			Reason:     p.Reason,
+			Link:       p.Link,
+			TemplateID: p.TemplateID,
+			SendingIP:  p.SendingIP,
+			WebhookID:  p.ID,
+			Raw:        item,
+		}
+		if len(event.Tags) == 0 && p.Tag != "" {
+			event.Tags = []string{p.Tag}
+		}
+		switch {
+		case p.TSEpoch > 0:
+			event.Time = time.UnixMilli(p.TSEpoch)
+		case p.TSEvent > 0:
+			event.Time = time.Unix(p.TSEvent, 0)
+		case p.TS > 0:
+			event.Time = time.Unix(p.TS, 0)
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
@@ -0,0 +1,206 @@
+package goat
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const brevoDeliveredPayload = `{
+	"event": "delivered",
+	"email": "user@example.com",
+	"id": 26224,
+	"date": "2026-03-14 15:09:26",
+	"ts": 1773500966,
+	"message-id": "<202603141509.12345@smtp-relay.mailin.fr>",
+	"ts_event": 1773500966,
+	"subject": "Welcome",
+	"sending_ip": "185.41.28.109",
+	"template_id": 22,
+	"tags": ["welcome", "onboarding"],
+	"ts_epoch": 1773500966123
+}`
+
+// TestParseBrevoEvents tests the ParseBrevoEvents function
+func TestParseBrevoEvents(t *testing.T) {
+	t.Run("Success - single event", func(t *testing.T) {
+		events, err := ParseBrevoEvents(strings.NewReader(brevoDeliveredPayload))
+		assert.NoError(t, err)
+		assert.Len(t, events, 1)
+
+		event := events[0]
+		assert.Equal(t, BrevoEventDelivered, event.Type)
+		assert.Equal(t, "user@example.com", event.Email)
+		assert.Equal(t, "<202603141509.12345@smtp-relay.mailin.fr>", event.MessageID)
+		assert.Equal(t, time.UnixMilli(1773500966123), event.Time)
+		assert.Equal(t, "Welcome", event.Subject)
+		assert.Equal(t, []string{"welcome", "onboarding"}, event.Tags)
+		assert.Equal(t, int64(22), event.TemplateID)
+		assert.Equal(t, "185.41.28.109", event.SendingIP)
+		assert.Equal(t, int64(26224), event.WebhookID)
+		assert.JSONEq(t, brevoDeliveredPayload, string(event.Raw))
+	})
+
+	t.Run("Success - batch", func(t *testing.T) {
+		payload := `[
+			{"event": "hard_bounce", "email": "gone@example.com", "message-id": "<a@relay>", "reason": "550 mailbox unavailable", "ts_event": 1773500966},
+			{"event": "click", "email": "user@example.com", "message-id": "<b@relay>", "link": "https://example.com/offer", "tag": "promo", "ts": 1773500900}
+		]`
+
+		events, err := ParseBrevoEvents(strings.NewReader(payload))
+		assert.NoError(t, err)
+		assert.Len(t, events, 2)
+		assert.Equal(t, BrevoEventHardBounce, events[0].Type)
+		assert.Equal(t, "550 mailbox unavailable", events[0].Reason)
+		assert.Equal(t, time.Unix(1773500966, 0), events[0].Time)
+		assert.Equal(t, BrevoEventClick, events[1].Type)
+		assert.Equal(t, "https://example.com/offer", events[1].Link)
+		assert.Equal(t, []string{"promo"}, events[1].Tags)
+		assert.Equal(t, time.Unix(1773500900, 0), events[1].Time)
+	})
+
+	tests := []struct {
+		name     string
+		payload  string
+		expected string
+	}{
+		{"invalid JSON", `{"event":`, "invalid Brevo webhook payload"},
+		{"invalid event", `[{"event": 1}]`, "invalid Brevo webhook event"},
+		{"missing event type", `{"email": "user@example.com"}`, "invalid Brevo webhook event: missing event type"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseBrevoEvents(strings.NewReader(tt.payload))
+			assert.ErrorContains(t, err, tt.expected)
+		})
+	}
+}
+
+// TestBrevoWebhookHandler tests the ServeHTTP method of BrevoWebhookHandler
+func TestBrevoWebhookHandler(t *testing.T) {
+	var received []BrevoEvent
+	onEvent := func(ctx context.Context, event BrevoEvent) error {
+		received = append(received, event)
+		if event.Email == "fail@example.com" {
+			return errors.New("database unavailable")
+		}
+		return nil
+	}
+
+	handler := NewBrevoWebhookHandler(onEvent, BrevoWebhookOptions{
+		Username:   "brevo",
+		Password:   "s3cret",
+		AllowedIPs: BrevoWebhookIPRanges,
+	})
+
+	newRequest := func(method, body string) *http.Request {
+		req := httptest.NewRequest(method, "/webhooks/brevo", strings.NewReader(body))
+		req.RemoteAddr = "1.179.112.10:43210"
+		req.SetBasicAuth("brevo", "s3cret")
+		return req
+	}
+
+	tests := []struct {
+		name     string
+		request  func() *http.Request
+		status   int
+		received int
+	}{
+		{"Success", func() *http.Request { return newRequest(http.MethodPost, brevoDeliveredPayload) }, http.StatusOK, 1},
+		{"method not allowed", func() *http.Request { return newRequest(http.MethodGet, "") }, http.StatusMethodNotAllowed, 0},
+		{"missing credentials", func() *http.Request {
+			req := newRequest(http.MethodPost, brevoDeliveredPayload)
+			req.Header.Del("Authorization")
+			return req
+		}, http.StatusUnauthorized, 0},
+		{"wrong password", func() *http.Request {
+			req := newRequest(http.MethodPost, brevoDeliveredPayload)
+			req.SetBasicAuth("brevo", "guess")
+			return req
+		}, http.StatusUnauthorized, 0},
+		{"address not allowed", func() *http.Request {
+			req := newRequest(http.MethodPost, brevoDeliveredPayload)
+			req.RemoteAddr = "203.0.113.7:43210"
+			return req
+		}, http.StatusForbidden, 0},
+		{"forwarded address ignored by default", func() *http.Request {
+			req := newRequest(http.MethodPost, brevoDeliveredPayload)
+			req.RemoteAddr = "10.0.0.1:43210"
+			req.Header.Set("X-Forwarded-For", "1.179.112.10")
+			return req
+		}, http.StatusForbidden, 0},
+		{"invalid payload", func() *http.Request { return newRequest(http.MethodPost, "not json") }, http.StatusBadRequest, 0},
+		{"callback error", func() *http.Request {
+			return newRequest(http.MethodPost, `{"event": "delivered", "email": "fail@example.com"}`)
+		}, http.StatusInternalServerError, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			received = nil
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, tt.request())
+			assert.Equal(t, tt.status, rec.Code)
+			assert.Len(t, received, tt.received)
+		})
+	}
+
+	t.Run("Success - behind a proxy", func(t *testing.T) {
+		received = nil
+		proxied := NewBrevoWebhookHandler(onEvent, BrevoWebhookOptions{
+			AllowedIPs:        []netip.Prefix{netip.MustParsePrefix("172.246.240.0/20")},
+			TrustForwardedFor: true,
+			TrustedProxies:    2,
+		})
+
+		req := httptest.NewRequest(http.MethodPost, "/webhooks/brevo", strings.NewReader(brevoDeliveredPayload))
+		req.RemoteAddr = "10.0.0.1:43210"
+		req.Header.Set("X-Forwarded-For", "172.246.241.5, 10.0.0.2")
+		rec := httptest.NewRecorder()
+		proxied.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Len(t, received, 1)
+	})
+
+	t.Run("Failure - spoofed X-Forwarded-For", func(t *testing.T) {
+		received = nil
+		proxied := NewBrevoWebhookHandler(onEvent, BrevoWebhookOptions{
+			AllowedIPs:        BrevoWebhookIPRanges,
+			TrustForwardedFor: true,
+		})
+
+		// the client sends an allowed address in front of its own, appended by the proxy
+		req := httptest.NewRequest(http.MethodPost, "/webhooks/brevo", strings.NewReader(brevoDeliveredPayload))
+		req.RemoteAddr = "10.0.0.1:43210"
+		req.Header.Set("X-Forwarded-For", "172.246.241.5, 203.0.113.7")
+		rec := httptest.NewRecorder()
+		proxied.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		assert.Empty(t, received)
+	})
+
+	t.Run("Failure - fewer X-Forwarded-For entries than trusted proxies", func(t *testing.T) {
+		received = nil
+		proxied := NewBrevoWebhookHandler(onEvent, BrevoWebhookOptions{
+			AllowedIPs:        BrevoWebhookIPRanges,
+			TrustForwardedFor: true,
+			TrustedProxies:    2,
+		})
+
+		req := httptest.NewRequest(http.MethodPost, "/webhooks/brevo", strings.NewReader(brevoDeliveredPayload))
+		req.RemoteAddr = "10.0.0.1:43210"
+		req.Header.Set("X-Forwarded-For", "172.246.241.5")
+		rec := httptest.NewRecorder()
+		proxied.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		assert.Empty(t, received)
+	})
+}
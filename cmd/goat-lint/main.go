@@ -0,0 +1,56 @@
+// Command goat-lint reports problems found in a directory of email templates.
+//
+// Usage:
+//
+//	goat-lint [-json] [dir]
+//
+// It reports syntax errors, calls to undefined functions, unclosed HTML tags, images without alt
+// text, links with an empty href and HTML templates without plain text counterpart, one per line
+// as "file:line: severity: message (rule)", or as a JSON array with -json.
+// It exits with status 1 when a problem is found. Field references cannot be checked from the
+// command line; use goat.LintTemplateFS with LintOptions.DataTypes in a test for that.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/Zapharaos/go-at"
+)
+
+func main() {
+	asJSON := flag.Bool("json", false, "print the diagnostics as JSON")
+	flag.Parse()
+
+	dir := "templates"
+	if flag.NArg() > 0 {
+		dir = flag.Arg(0)
+	}
+
+	diags, err := goat.LintTemplateFS(os.DirFS(dir), goat.LintOptions{})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *asJSON {
+		if diags == nil {
+			diags = []goat.Diagnostic{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(diags); err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		for _, d := range diags {
+			fmt.Println(d)
+		}
+	}
+
+	if len(diags) > 0 {
+		os.Exit(1)
+	}
+}
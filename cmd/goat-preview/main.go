@@ -0,0 +1,30 @@
+// Command goat-preview serves a live-reloading preview of a directory of email templates.
+//
+// Usage:
+//
+//	goat-preview -dir ./templates -addr localhost:8025
+//
+// See the goatpreview package for the expected layout of the directory.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/Zapharaos/go-at/goatpreview"
+)
+
+func main() {
+	dir := flag.String("dir", "templates", "directory of the email templates")
+	addr := flag.String("addr", "localhost:8025", "address to listen on")
+	flag.Parse()
+
+	if _, err := os.Stat(*dir); err != nil {
+		log.Fatal(err)
+	}
+
+	log.Printf("previewing templates of %s on http://%s", *dir, *addr)
+	log.Fatal(http.ListenAndServe(*addr, goatpreview.NewHandler(os.DirFS(*dir))))
+}
@@ -0,0 +1,261 @@
+package goat
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Provider names, as set in DeliveryEvent.Provider.
+const (
+	ProviderSendgrid = "sendgrid"
+	ProviderBrevo    = "brevo"
+	ProviderTracking = "tracking" // Opens and clicks recorded by an EngagementTracker
+)
+
+// ErrNoDeliveryEvents is returned when no delivery event was recorded for a message.
+var ErrNoDeliveryEvents = errors.New("no delivery events for message")
+
+// DeliveryStatus is the provider-agnostic status of a message.
+type DeliveryStatus string
+
+const (
+	DeliveryAccepted     DeliveryStatus = "accepted" // Accepted by the provider, not yet delivered
+	DeliveryDeferred     DeliveryStatus = "deferred" // Temporarily rejected by the receiving server, retried by the provider
+	DeliveryDelivered    DeliveryStatus = "delivered"
+	DeliverySoftBounced  DeliveryStatus = "soft_bounced" // Rejected for a temporary reason, e.g. a full mailbox or a block
+	DeliveryBounced      DeliveryStatus = "bounced"      // Permanently rejected by the receiving server
+	DeliveryDropped      DeliveryStatus = "dropped"      // Not sent by the provider, e.g. invalid or suppressed address
+	DeliveryOpened       DeliveryStatus = "opened"
+	DeliveryClicked      DeliveryStatus = "clicked"
+	DeliveryComplained   DeliveryStatus = "complained" // Reported as spam
+	DeliveryUnsubscribed DeliveryStatus = "unsubscribed"
+	// DeliveryGroupUnsubscribed and DeliveryGroupResubscribed only concern the messages of DeliveryEvent.Group.
+	DeliveryGroupUnsubscribed DeliveryStatus = "group_unsubscribed"
+	DeliveryGroupResubscribed DeliveryStatus = "group_resubscribed"
+)
+
+// Failed reports whether the status means the message did not reach the recipient.
+func (s DeliveryStatus) Failed() bool {
+	return s == DeliverySoftBounced || s == DeliveryBounced || s == DeliveryDropped
+}
+
+// DeliveryEvent is a provider-agnostic delivery event, normalized from a provider webhook event.
+type DeliveryEvent struct {
+	MessageID string // As returned in SendResult.MessageID
+	Recipient string
+	Status    DeliveryStatus
+	Reason    string // Bounce, drop or deferral reason
+	URL       string // Clicked link
+	Group     string // Unsubscribe group of group unsubscriptions and resubscriptions, e.g. a SendGrid ASM group ID
+	Provider  string // ProviderSendgrid, ProviderBrevo or ProviderTracking
+	EventID   string // Provider event identifier when available, to deduplicate retried webhooks
+	Time      time.Time
+	Raw       json.RawMessage // Original provider payload
+}
+
+// brevoStatuses maps Brevo event types to delivery statuses.
+var brevoStatuses = map[BrevoEventType]DeliveryStatus{
+	BrevoEventRequest:         DeliveryAccepted,
+	BrevoEventDelivered:       DeliveryDelivered,
+	BrevoEventDeferred:        DeliveryDeferred,
+	BrevoEventSoftBounce:      DeliverySoftBounced,
+	BrevoEventHardBounce:      DeliveryBounced,
+	BrevoEventInvalidEmail:    DeliveryDropped,
+	BrevoEventBlocked:         DeliveryDropped,
+	BrevoEventError:           DeliveryDropped,
+	BrevoEventOpened:          DeliveryOpened,
+	BrevoEventUniqueOpened:    DeliveryOpened,
+	BrevoEventProxyOpen:       DeliveryOpened,
+	BrevoEventUniqueProxyOpen: DeliveryOpened,
+	BrevoEventClick:           DeliveryClicked,
+	BrevoEventSpam:            DeliveryComplained,
+	BrevoEventUnsubscribed:    DeliveryUnsubscribed,
+}
+
+// sendgridStatuses maps SendGrid event types to delivery statuses.
+var sendgridStatuses = map[SendgridEventType]DeliveryStatus{
+	SendgridEventProcessed:        DeliveryAccepted,
+	SendgridEventDelivered:        DeliveryDelivered,
+	SendgridEventDeferred:         DeliveryDeferred,
+	SendgridEventBounce:           DeliveryBounced,
+	SendgridEventDropped:          DeliveryDropped,
+	SendgridEventOpen:             DeliveryOpened,
+	SendgridEventClick:            DeliveryClicked,
+	SendgridEventSpamReport:       DeliveryComplained,
+	SendgridEventUnsubscribe:      DeliveryUnsubscribed,
+	SendgridEventGroupUnsubscribe: DeliveryGroupUnsubscribed,
+	SendgridEventGroupResubscribe: DeliveryGroupResubscribed,
+}
+
+// DeliveryEvent normalizes the Brevo event, and reports false for unknown event types.
+// Brevo events have no identifier, so the EventID is derived from the message ID, the event type
+// and the time in milliseconds, which Brevo keeps when retrying a request.
+func (e BrevoEvent) DeliveryEvent() (DeliveryEvent, bool) {
+	status, ok := brevoStatuses[e.Type]
+	if !ok {
+		return DeliveryEvent{}, false
+	}
+	var eventID string
+	if e.MessageID != "" && !e.Time.IsZero() {
+		eventID = e.MessageID + "|" + string(e.Type) + "|" + strconv.FormatInt(e.Time.UnixMilli(), 10)
+	}
+	return DeliveryEvent{
+		MessageID: e.MessageID,
+		Recipient: e.Email,
+		Status:    status,
+		Reason:    e.Reason,
+		URL:       e.Link,
+		Provider:  ProviderBrevo,
+		EventID:   eventID,
+		Time:      e.Time,
+		Raw:       e.Raw,
+	}, true
+}
+
+// DeliveryEvent normalizes the SendGrid event, and reports false for unknown event types.
+// Bounces of the "blocked" type, usually temporary, are soft bounces. Group unsubscriptions and
+// resubscriptions carry their ASM group ID in Group.
+func (e SendgridEvent) DeliveryEvent() (DeliveryEvent, bool) {
+	status, ok := sendgridStatuses[e.Type]
+	if !ok {
+		return DeliveryEvent{}, false
+	}
+	if e.Type == SendgridEventBounce && e.BounceType == "blocked" {
+		status = DeliverySoftBounced
+	}
+	reason := e.Reason
+	if reason == "" && e.Type == SendgridEventDeferred {
+		reason = e.Response
+	}
+	var group string
+	if (status == DeliveryGroupUnsubscribed || status == DeliveryGroupResubscribed) && e.ASMGroupID != 0 {
+		group = strconv.FormatInt(e.ASMGroupID, 10)
+	}
+	return DeliveryEvent{
+		MessageID: e.MessageID,
+		Recipient: e.Email,
+		Status:    status,
+		Reason:    reason,
+		URL:       e.URL,
+		Group:     group,
+		Provider:  ProviderSendgrid,
+		EventID:   e.EventID,
+		Time:      e.Time,
+		Raw:       e.Raw,
+	}, true
+}
+
+// DeliveryStore stores delivery events by message ID.
+// Implementations should ignore events whose non-empty EventID is already stored.
+type DeliveryStore interface {
+	AddEvent(ctx context.Context, event DeliveryEvent) error
+	// Events returns the events of a message, sorted by time.
+	Events(ctx context.Context, messageID string) ([]DeliveryEvent, error)
+}
+
+// MemoryDeliveryStore is an in-memory DeliveryStore, for tests and single-instance services.
+type MemoryDeliveryStore struct {
+	mu       sync.RWMutex
+	events   map[string][]DeliveryEvent
+	eventIDs map[string]bool
+}
+
+// NewMemoryDeliveryStore returns an empty MemoryDeliveryStore.
+func NewMemoryDeliveryStore() *MemoryDeliveryStore {
+	return &MemoryDeliveryStore{
+		events:   make(map[string][]DeliveryEvent),
+		eventIDs: make(map[string]bool),
+	}
+}
+
+// AddEvent implements the DeliveryStore interface.
+func (s *MemoryDeliveryStore) AddEvent(ctx context.Context, event DeliveryEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if event.EventID != "" {
+		if s.eventIDs[event.EventID] {
+			return nil
+		}
+		s.eventIDs[event.EventID] = true
+	}
+
+	// keep the timeline sorted, events of the same time in arrival order
+	events := s.events[event.MessageID]
+	i := sort.Search(len(events), func(i int) bool { return events[i].Time.After(event.Time) })
+	events = append(events, DeliveryEvent{})
+	copy(events[i+1:], events[i:])
+	events[i] = event
+	s.events[event.MessageID] = events
+	return nil
+}
+
+// Events implements the DeliveryStore interface.
+func (s *MemoryDeliveryStore) Events(ctx context.Context, messageID string) ([]DeliveryEvent, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	events := s.events[messageID]
+	return append([]DeliveryEvent(nil), events...), nil
+}
+
+// DeliveryTracker records delivery events into a DeliveryStore, and tells what happened to a message.
+type DeliveryTracker struct {
+	store DeliveryStore
+}
+
+// NewDeliveryTracker returns a tracker recording events into store, a MemoryDeliveryStore when nil.
+func NewDeliveryTracker(store DeliveryStore) *DeliveryTracker {
+	if store == nil {
+		store = NewMemoryDeliveryStore()
+	}
+	return &DeliveryTracker{store: store}
+}
+
+// Record stores a delivery event. Events without a message ID, which cannot be looked up, are ignored.
+func (t *DeliveryTracker) Record(ctx context.Context, event DeliveryEvent) error {
+	if event.MessageID == "" {
+		return nil
+	}
+	return t.store.AddEvent(ctx, event)
+}
+
+// HandleBrevoEvent records a Brevo event, ignoring unknown event types.
+// It can be used as the callback of NewBrevoWebhookHandler.
+func (t *DeliveryTracker) HandleBrevoEvent(ctx context.Context, event BrevoEvent) error {
+	if e, ok := event.DeliveryEvent(); ok {
+		return t.Record(ctx, e)
+	}
+	return nil
+}
+
+// HandleSendgridEvent records a SendGrid event, ignoring unknown event types.
+// It can be used as the callback of NewSendgridWebhookHandler.
+func (t *DeliveryTracker) HandleSendgridEvent(ctx context.Context, event SendgridEvent) error {
+	if e, ok := event.DeliveryEvent(); ok {
+		return t.Record(ctx, e)
+	}
+	return nil
+}
+
+// Timeline returns the delivery events of a message, sorted by time.
+func (t *DeliveryTracker) Timeline(ctx context.Context, messageID string) ([]DeliveryEvent, error) {
+	return t.store.Events(ctx, messageID)
+}
+
+// Status returns the status of the latest delivery event of a message, or ErrNoDeliveryEvents.
+func (t *DeliveryTracker) Status(ctx context.Context, messageID string) (DeliveryStatus, error) {
+	events, err := t.store.Events(ctx, messageID)
+	if err != nil {
+		return "", err
+	}
+	if len(events) == 0 {
+		return "", ErrNoDeliveryEvents
+	}
+	return events[len(events)-1].Status, nil
+}
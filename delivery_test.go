@@ -0,0 +1,261 @@
+package goat
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// MockDeliveryStore is a mock implementation of the DeliveryStore interface
+type MockDeliveryStore struct {
+	err error
+}
+
+func (m *MockDeliveryStore) AddEvent(ctx context.Context, event DeliveryEvent) error {
+	return m.err
+}
+
+func (m *MockDeliveryStore) Events(ctx context.Context, messageID string) ([]DeliveryEvent, error) {
+	return nil, m.err
+}
+
+// TestDeliveryStatusFailed tests the Failed method of DeliveryStatus
+func TestDeliveryStatusFailed(t *testing.T) {
+	for _, status := range []DeliveryStatus{DeliverySoftBounced, DeliveryBounced, DeliveryDropped} {
+		assert.True(t, status.Failed(), status)
+	}
+	for _, status := range []DeliveryStatus{DeliveryAccepted, DeliveryDeferred, DeliveryDelivered, DeliveryOpened, DeliveryComplained} {
+		assert.False(t, status.Failed(), status)
+	}
+}
+
+// TestBrevoEventDeliveryEvent tests the DeliveryEvent method of BrevoEvent
+func TestBrevoEventDeliveryEvent(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		event := BrevoEvent{
+			Type:      BrevoEventHardBounce,
+			Email:     "gone@example.com",
+			MessageID: "<a@relay>",
+			Time:      time.Unix(1773500966, 0),
+			Reason:    "550 mailbox unavailable",
+			Raw:       []byte(`{"event": "hard_bounce"}`),
+		}
+
+		normalized, ok := event.DeliveryEvent()
+		assert.True(t, ok)
+		assert.Equal(t, DeliveryEvent{
+			MessageID: "<a@relay>",
+			Recipient: "gone@example.com",
+			Status:    DeliveryBounced,
+			Reason:    "550 mailbox unavailable",
+			Provider:  ProviderBrevo,
+			EventID:   "<a@relay>|hard_bounce|1773500966000",
+			Time:      time.Unix(1773500966, 0),
+			Raw:       []byte(`{"event": "hard_bounce"}`),
+		}, normalized)
+	})
+
+	t.Run("Success - opens and clicks", func(t *testing.T) {
+		normalized, ok := BrevoEvent{Type: BrevoEventUniqueProxyOpen}.DeliveryEvent()
+		assert.True(t, ok)
+		assert.Equal(t, DeliveryOpened, normalized.Status)
+
+		normalized, ok = BrevoEvent{Type: BrevoEventClick, Link: "https://example.com"}.DeliveryEvent()
+		assert.True(t, ok)
+		assert.Equal(t, DeliveryClicked, normalized.Status)
+		assert.Equal(t, "https://example.com", normalized.URL)
+	})
+
+	t.Run("Failure - unknown event type", func(t *testing.T) {
+		_, ok := BrevoEvent{Type: "list_addition"}.DeliveryEvent()
+		assert.False(t, ok)
+	})
+}
+
+// TestSendgridEventDeliveryEvent tests the DeliveryEvent method of SendgridEvent
+func TestSendgridEventDeliveryEvent(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		event := SendgridEvent{
+			Type:        SendgridEventBounce,
+			MessageID:   "14c5d75ce93",
+			SGMessageID: "14c5d75ce93.dfd.64b469.filter0001.16648.5515E0B88.0",
+			EventID:     "6g4ZI7SA-xmRDv57GoPIPw==",
+			Email:       "gone@example.com",
+			Time:        time.Unix(1773500970, 0),
+			Reason:      "500 unknown recipient",
+			BounceType:  "bounce",
+		}
+
+		normalized, ok := event.DeliveryEvent()
+		assert.True(t, ok)
+		assert.Equal(t, DeliveryEvent{
+			MessageID: "14c5d75ce93",
+			Recipient: "gone@example.com",
+			Status:    DeliveryBounced,
+			Reason:    "500 unknown recipient",
+			Provider:  ProviderSendgrid,
+			EventID:   "6g4ZI7SA-xmRDv57GoPIPw==",
+			Time:      time.Unix(1773500970, 0),
+		}, normalized)
+	})
+
+	t.Run("Success - blocked bounce", func(t *testing.T) {
+		normalized, ok := SendgridEvent{Type: SendgridEventBounce, BounceType: "blocked"}.DeliveryEvent()
+		assert.True(t, ok)
+		assert.Equal(t, DeliverySoftBounced, normalized.Status)
+	})
+
+	t.Run("Success - deferral response as reason", func(t *testing.T) {
+		normalized, ok := SendgridEvent{Type: SendgridEventDeferred, Response: "400 try again later"}.DeliveryEvent()
+		assert.True(t, ok)
+		assert.Equal(t, DeliveryDeferred, normalized.Status)
+		assert.Equal(t, "400 try again later", normalized.Reason)
+	})
+
+	t.Run("Success - group events", func(t *testing.T) {
+		normalized, ok := SendgridEvent{Type: SendgridEventGroupUnsubscribe, Email: "user@example.com", ASMGroupID: 42}.DeliveryEvent()
+		assert.True(t, ok)
+		assert.Equal(t, DeliveryGroupUnsubscribed, normalized.Status)
+		assert.Equal(t, "42", normalized.Group)
+
+		normalized, ok = SendgridEvent{Type: SendgridEventGroupResubscribe, Email: "user@example.com", ASMGroupID: 42}.DeliveryEvent()
+		assert.True(t, ok)
+		assert.Equal(t, DeliveryGroupResubscribed, normalized.Status)
+		assert.Equal(t, "42", normalized.Group)
+
+		normalized, ok = SendgridEvent{Type: SendgridEventUnsubscribe, Email: "user@example.com", ASMGroupID: 42}.DeliveryEvent()
+		assert.True(t, ok)
+		assert.Equal(t, DeliveryUnsubscribed, normalized.Status)
+		assert.Empty(t, normalized.Group)
+	})
+
+	t.Run("Failure - unknown event type", func(t *testing.T) {
+		_, ok := SendgridEvent{Type: "machine_opened"}.DeliveryEvent()
+		assert.False(t, ok)
+	})
+}
+
+// TestMemoryDeliveryStore tests the MemoryDeliveryStore methods
+func TestMemoryDeliveryStore(t *testing.T) {
+	ctx := context.Background()
+	at := time.Unix(1773500966, 0)
+
+	t.Run("Success - sorted by time", func(t *testing.T) {
+		store := NewMemoryDeliveryStore()
+		assert.NoError(t, store.AddEvent(ctx, DeliveryEvent{MessageID: "m1", Status: DeliveryOpened, Time: at.Add(2 * time.Minute)}))
+		assert.NoError(t, store.AddEvent(ctx, DeliveryEvent{MessageID: "m1", Status: DeliveryAccepted, Time: at}))
+		assert.NoError(t, store.AddEvent(ctx, DeliveryEvent{MessageID: "m1", Status: DeliveryDelivered, Time: at.Add(time.Minute)}))
+		assert.NoError(t, store.AddEvent(ctx, DeliveryEvent{MessageID: "m1", Status: DeliveryClicked, Time: at.Add(2 * time.Minute)}))
+		assert.NoError(t, store.AddEvent(ctx, DeliveryEvent{MessageID: "m2", Status: DeliveryAccepted, Time: at}))
+
+		events, err := store.Events(ctx, "m1")
+		assert.NoError(t, err)
+		var statuses []DeliveryStatus
+		for _, event := range events {
+			statuses = append(statuses, event.Status)
+		}
+		assert.Equal(t, []DeliveryStatus{DeliveryAccepted, DeliveryDelivered, DeliveryOpened, DeliveryClicked}, statuses)
+	})
+
+	t.Run("Success - duplicate event ignored", func(t *testing.T) {
+		store := NewMemoryDeliveryStore()
+		event := DeliveryEvent{MessageID: "m1", EventID: "e1", Status: DeliveryDelivered, Time: at}
+		assert.NoError(t, store.AddEvent(ctx, event))
+		assert.NoError(t, store.AddEvent(ctx, event))
+
+		events, err := store.Events(ctx, "m1")
+		assert.NoError(t, err)
+		assert.Len(t, events, 1)
+	})
+
+	t.Run("Success - unknown message", func(t *testing.T) {
+		events, err := NewMemoryDeliveryStore().Events(ctx, "unknown")
+		assert.NoError(t, err)
+		assert.Empty(t, events)
+	})
+}
+
+// TestDeliveryTracker tests the DeliveryTracker methods
+func TestDeliveryTracker(t *testing.T) {
+	ctx := context.Background()
+	at := time.Unix(1773500966, 0)
+
+	t.Run("Success", func(t *testing.T) {
+		tracker := NewDeliveryTracker(nil)
+		assert.NoError(t, tracker.HandleSendgridEvent(ctx, SendgridEvent{Type: SendgridEventProcessed, MessageID: "sg1", Time: at}))
+		assert.NoError(t, tracker.HandleSendgridEvent(ctx, SendgridEvent{Type: SendgridEventDelivered, MessageID: "sg1", Time: at.Add(time.Second)}))
+		assert.NoError(t, tracker.HandleBrevoEvent(ctx, BrevoEvent{Type: BrevoEventSoftBounce, MessageID: "<b1@relay>", Reason: "mailbox full", Time: at}))
+
+		status, err := tracker.Status(ctx, "sg1")
+		assert.NoError(t, err)
+		assert.Equal(t, DeliveryDelivered, status)
+
+		timeline, err := tracker.Timeline(ctx, "<b1@relay>")
+		assert.NoError(t, err)
+		assert.Len(t, timeline, 1)
+		assert.Equal(t, DeliverySoftBounced, timeline[0].Status)
+		assert.Equal(t, "mailbox full", timeline[0].Reason)
+		assert.Equal(t, ProviderBrevo, timeline[0].Provider)
+	})
+
+	t.Run("Success - ignored events", func(t *testing.T) {
+		tracker := NewDeliveryTracker(nil)
+		assert.NoError(t, tracker.HandleBrevoEvent(ctx, BrevoEvent{Type: "list_addition", MessageID: "<b1@relay>"}))
+		assert.NoError(t, tracker.HandleSendgridEvent(ctx, SendgridEvent{Type: SendgridEventDelivered}))
+
+		_, err := tracker.Status(ctx, "<b1@relay>")
+		assert.ErrorIs(t, err, ErrNoDeliveryEvents)
+		_, err = tracker.Status(ctx, "")
+		assert.ErrorIs(t, err, ErrNoDeliveryEvents)
+	})
+
+	t.Run("Success - from a webhook", func(t *testing.T) {
+		tracker := NewDeliveryTracker(nil)
+		handler := NewBrevoWebhookHandler(tracker.HandleBrevoEvent, BrevoWebhookOptions{})
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/webhooks/brevo", strings.NewReader(brevoDeliveredPayload)))
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		status, err := tracker.Status(ctx, "<202603141509.12345@smtp-relay.mailin.fr>")
+		assert.NoError(t, err)
+		assert.Equal(t, DeliveryDelivered, status)
+	})
+
+	t.Run("Success - retried Brevo batch", func(t *testing.T) {
+		tracker := NewDeliveryTracker(nil)
+		handler := NewBrevoWebhookHandler(tracker.HandleBrevoEvent, BrevoWebhookOptions{})
+		payload := `[
+			{"event": "request", "email": "user@example.com", "message-id": "<b1@relay>", "ts_epoch": 1773500966000},
+			{"event": "delivered", "email": "user@example.com", "message-id": "<b1@relay>", "ts_epoch": 1773500967000},
+			{"event": "click", "email": "user@example.com", "message-id": "<b1@relay>", "link": "https://example.com", "ts_epoch": 1773500968000}
+		]`
+
+		for range 2 {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/webhooks/brevo", strings.NewReader(payload)))
+			assert.Equal(t, http.StatusOK, rec.Code)
+		}
+
+		timeline, err := tracker.Timeline(ctx, "<b1@relay>")
+		assert.NoError(t, err)
+		assert.Len(t, timeline, 3)
+	})
+
+	t.Run("Failure - store error", func(t *testing.T) {
+		tracker := NewDeliveryTracker(&MockDeliveryStore{err: errors.New("database unavailable")})
+
+		err := tracker.Record(ctx, DeliveryEvent{MessageID: "m1", Status: DeliveryDelivered})
+		assert.EqualError(t, err, "database unavailable")
+		_, err = tracker.Timeline(ctx, "m1")
+		assert.EqualError(t, err, "database unavailable")
+		_, err = tracker.Status(ctx, "m1")
+		assert.EqualError(t, err, "database unavailable")
+	})
+}
@@ -0,0 +1,326 @@
+package goat
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Canonicalization is a DKIM canonicalization algorithm (RFC 6376 section 3.4).
+type Canonicalization string
+
+const (
+	CanonicalizationSimple  Canonicalization = "simple"  // Headers and body kept as is, trailing empty lines removed
+	CanonicalizationRelaxed Canonicalization = "relaxed" // Whitespace and header name case normalized
+)
+
+// DefaultDKIMHeaders are the headers signed when DKIMOptions.Headers is empty, when present in the message.
+var DefaultDKIMHeaders = []string{
+	"From", "Reply-To", "Subject", "Date", "To", "Cc", "Message-ID", "In-Reply-To", "References",
+	"MIME-Version", "Content-Type", "Content-Transfer-Encoding", "List-Unsubscribe", "List-Unsubscribe-Post",
+}
+
+// ErrDKIMInvalid is returned when a DKIM signature does not verify.
+var ErrDKIMInvalid = errors.New("invalid DKIM signature")
+
+var (
+	// dkimWhitespace matches sequences of whitespace within a line.
+	dkimWhitespace = regexp.MustCompile(`[ \t]+`)
+	// dkimSignatureValue matches the b= tag of a DKIM-Signature header and its value.
+	dkimSignatureValue = regexp.MustCompile(`(^|[;\s])b=[^;]*`)
+)
+
+// DKIMOptions configures a DKIMSigner.
+type DKIMOptions struct {
+	Domain                 string           // Signing domain (d= tag)
+	Selector               string           // Selector of the public key record, published at <selector>._domainkey.<domain>
+	Key                    crypto.Signer    // *rsa.PrivateKey (rsa-sha256) or ed25519.PrivateKey (ed25519-sha256)
+	HeaderCanonicalization Canonicalization // Defaults to CanonicalizationRelaxed
+	BodyCanonicalization   Canonicalization // Defaults to CanonicalizationRelaxed
+	Headers                []string         // Headers to sign, defaults to DefaultDKIMHeaders; From is always signed
+}
+
+// DKIMSigner signs serialized messages with a DKIM-Signature header (RFC 6376).
+// It implements MIMETransformer, so it can be passed to NewSMTPService.
+type DKIMSigner struct {
+	opts      DKIMOptions
+	algorithm string
+}
+
+// NewDKIMSigner returns a DKIMSigner for the given options.
+func NewDKIMSigner(opts DKIMOptions) (*DKIMSigner, error) {
+	if opts.Domain == "" || opts.Selector == "" {
+		return nil, errors.New("DKIM domain and selector are required")
+	}
+
+	s := &DKIMSigner{opts: opts}
+	switch opts.Key.(type) {
+	case *rsa.PrivateKey:
+		s.algorithm = "rsa-sha256"
+	case ed25519.PrivateKey:
+		s.algorithm = "ed25519-sha256"
+	default:
+		return nil, fmt.Errorf("unsupported DKIM key type %T", opts.Key)
+	}
+
+	for _, c := range []*Canonicalization{&s.opts.HeaderCanonicalization, &s.opts.BodyCanonicalization} {
+		switch *c {
+		case "":
+			*c = CanonicalizationRelaxed
+		case CanonicalizationSimple, CanonicalizationRelaxed:
+		default:
+			return nil, fmt.Errorf("unsupported DKIM canonicalization %q", *c)
+		}
+	}
+
+	if len(s.opts.Headers) == 0 {
+		s.opts.Headers = DefaultDKIMHeaders
+	}
+	hasFrom := false
+	for _, h := range s.opts.Headers {
+		hasFrom = hasFrom || strings.EqualFold(h, "From")
+	}
+	if !hasFrom {
+		s.opts.Headers = append([]string{"From"}, s.opts.Headers...)
+	}
+	return s, nil
+}
+
+// TransformMIME implements the MIMETransformer interface, see Sign.
+func (s *DKIMSigner) TransformMIME(raw []byte) ([]byte, error) {
+	return s.Sign(raw)
+}
+
+// Sign returns the serialized message raw, e.g. from EmailMessage.MarshalMIME, with a DKIM-Signature header prepended.
+func (s *DKIMSigner) Sign(raw []byte) ([]byte, error) {
+	fields, body, err := splitMessage(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	bodyHash := sha256.Sum256(canonicalBody(body, s.opts.BodyCanonicalization))
+
+	// every instance of a configured header is signed
+	var names []string
+	for _, name := range s.opts.Headers {
+		for _, f := range fields {
+			if strings.EqualFold(fieldName(f), name) {
+				names = append(names, strings.ToLower(name))
+			}
+		}
+	}
+	signed := selectHeaders(fields, names)
+
+	tags := fmt.Sprintf("v=1; a=%s; c=%s/%s; d=%s; s=%s; t=%d; h=%s; bh=%s; b=",
+		s.algorithm, s.opts.HeaderCanonicalization, s.opts.BodyCanonicalization, s.opts.Domain, s.opts.Selector,
+		timeNow().Unix(), strings.Join(names, ":"), base64.StdEncoding.EncodeToString(bodyHash[:]))
+
+	var header bytes.Buffer
+	if err := writeHeader(&header, "DKIM-Signature", tags); err != nil {
+		return nil, err
+	}
+	unsigned := strings.TrimSuffix(header.String(), "\r\n")
+
+	hash := headerHash(signed, unsigned, s.opts.HeaderCanonicalization)
+	var signature []byte
+	if s.algorithm == "rsa-sha256" {
+		signature, err = s.opts.Key.Sign(rand.Reader, hash, crypto.SHA256)
+	} else {
+		signature, err = s.opts.Key.Sign(rand.Reader, hash, crypto.Hash(0))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("signing DKIM header: %w", err)
+	}
+
+	var out bytes.Buffer
+	out.WriteString(unsigned)
+	out.WriteString(base64.StdEncoding.EncodeToString(signature))
+	out.WriteString("\r\n")
+	out.Write(normalizeCRLF(raw))
+	return out.Bytes(), nil
+}
+
+// VerifyDKIM verifies the first DKIM-Signature header of the serialized message raw against the given public key,
+// an *rsa.PublicKey or an ed25519.PublicKey. It does not look up DNS, and is meant for tests.
+func VerifyDKIM(raw []byte, publicKey crypto.PublicKey) error {
+	fields, body, err := splitMessage(raw)
+	if err != nil {
+		return err
+	}
+
+	var signature string
+	for _, f := range fields {
+		if strings.EqualFold(fieldName(f), "DKIM-Signature") {
+			signature = f
+			break
+		}
+	}
+	if signature == "" {
+		return fmt.Errorf("%w: no DKIM-Signature header", ErrDKIMInvalid)
+	}
+	tags := parseDKIMTags(signature[strings.Index(signature, ":")+1:])
+
+	headerCanon, bodyCanon, _ := strings.Cut(tags["c"], "/")
+	if headerCanon == "" {
+		headerCanon = string(CanonicalizationSimple)
+	}
+	if bodyCanon == "" {
+		bodyCanon = string(CanonicalizationSimple)
+	}
+
+	bodyHash := sha256.Sum256(canonicalBody(body, Canonicalization(bodyCanon)))
+	if base64.StdEncoding.EncodeToString(bodyHash[:]) != tags["bh"] {
+		return fmt.Errorf("%w: body hash mismatch", ErrDKIMInvalid)
+	}
+
+	var others []string
+	for _, f := range fields {
+		if f != signature {
+			others = append(others, f)
+		}
+	}
+	signed := selectHeaders(others, strings.Split(tags["h"], ":"))
+
+	// the signature is computed over the DKIM-Signature header with an empty b= tag, without trailing CRLF
+	unsigned := dkimSignatureValue.ReplaceAllString(strings.TrimSuffix(signature, "\r\n"), "${1}b=")
+	hash := headerHash(signed, unsigned, Canonicalization(headerCanon))
+
+	sig, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(tags["b"]), ""))
+	if err != nil {
+		return fmt.Errorf("%w: malformed b= tag", ErrDKIMInvalid)
+	}
+
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		if tags["a"] != "rsa-sha256" {
+			return fmt.Errorf("%w: algorithm %q does not match an RSA key", ErrDKIMInvalid, tags["a"])
+		}
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, hash, sig); err != nil {
+			return fmt.Errorf("%w: %v", ErrDKIMInvalid, err)
+		}
+	case ed25519.PublicKey:
+		if tags["a"] != "ed25519-sha256" {
+			return fmt.Errorf("%w: algorithm %q does not match an Ed25519 key", ErrDKIMInvalid, tags["a"])
+		}
+		if !ed25519.Verify(key, hash, sig) {
+			return fmt.Errorf("%w: signature mismatch", ErrDKIMInvalid)
+		}
+	default:
+		return fmt.Errorf("unsupported DKIM public key type %T", publicKey)
+	}
+	return nil
+}
+
+// splitMessage returns the header fields, each with its continuation lines and trailing CRLF, and the body of raw.
+func splitMessage(raw []byte) ([]string, []byte, error) {
+	raw = normalizeCRLF(raw)
+	header, body, found := bytes.Cut(raw, []byte("\r\n\r\n"))
+	if !found {
+		return nil, nil, errors.New("message has no header/body separator")
+	}
+
+	var fields []string
+	for _, line := range strings.SplitAfter(string(header)+"\r\n", "\r\n") {
+		switch {
+		case line == "":
+		case (line[0] == ' ' || line[0] == '\t') && len(fields) > 0:
+			fields[len(fields)-1] += line
+		default:
+			fields = append(fields, line)
+		}
+	}
+	return fields, body, nil
+}
+
+// normalizeCRLF converts bare LF line endings to CRLF.
+func normalizeCRLF(raw []byte) []byte {
+	if !bytes.Contains(bytes.ReplaceAll(raw, []byte("\r\n"), nil), []byte("\n")) {
+		return raw
+	}
+	return bytes.ReplaceAll(bytes.ReplaceAll(raw, []byte("\r\n"), []byte("\n")), []byte("\n"), []byte("\r\n"))
+}
+
+// fieldName returns the name of a header field.
+func fieldName(field string) string {
+	name, _, _ := strings.Cut(field, ":")
+	return strings.TrimSpace(name)
+}
+
+// selectHeaders returns the signed fields for the names of an h= tag, each name selecting
+// the next unused instance of the header starting from the bottom. Missing headers are skipped.
+func selectHeaders(fields []string, names []string) []string {
+	used := make([]bool, len(fields))
+	var selected []string
+	for _, name := range names {
+		for i := len(fields) - 1; i >= 0; i-- {
+			if !used[i] && strings.EqualFold(fieldName(fields[i]), strings.TrimSpace(name)) {
+				used[i] = true
+				selected = append(selected, fields[i])
+				break
+			}
+		}
+	}
+	return selected
+}
+
+// headerHash returns the SHA-256 hash of the canonicalized signed fields followed by the DKIM-Signature header.
+func headerHash(signed []string, signature string, c Canonicalization) []byte {
+	h := sha256.New()
+	for _, f := range signed {
+		h.Write([]byte(canonicalHeader(f, c)))
+	}
+	h.Write([]byte(strings.TrimSuffix(canonicalHeader(signature+"\r\n", c), "\r\n")))
+	return h.Sum(nil)
+}
+
+// canonicalHeader canonicalizes a header field, including its trailing CRLF.
+func canonicalHeader(field string, c Canonicalization) string {
+	if c == CanonicalizationSimple {
+		return field
+	}
+	name, value, _ := strings.Cut(field, ":")
+	value = strings.NewReplacer("\r\n", "").Replace(value)
+	value = strings.TrimSpace(dkimWhitespace.ReplaceAllString(value, " "))
+	return strings.ToLower(strings.TrimSpace(name)) + ":" + value + "\r\n"
+}
+
+// canonicalBody canonicalizes a message body.
+func canonicalBody(body []byte, c Canonicalization) []byte {
+	lines := strings.Split(string(body), "\r\n")
+	if c == CanonicalizationRelaxed {
+		for i, line := range lines {
+			lines[i] = strings.TrimRight(dkimWhitespace.ReplaceAllString(line, " "), " ")
+		}
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) == 0 {
+		if c == CanonicalizationRelaxed {
+			return nil
+		}
+		return []byte("\r\n")
+	}
+	return []byte(strings.Join(lines, "\r\n") + "\r\n")
+}
+
+// parseDKIMTags parses the tag=value list of a DKIM-Signature header value.
+func parseDKIMTags(value string) map[string]string {
+	tags := make(map[string]string)
+	for _, tag := range strings.Split(value, ";") {
+		k, v, found := strings.Cut(tag, "=")
+		if !found {
+			continue
+		}
+		tags[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return tags
+}
@@ -0,0 +1,206 @@
+package goat
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// dkimTestMessage returns a serialized message to sign.
+func dkimTestMessage(t *testing.T) []byte {
+	t.Helper()
+
+	raw, err := NewEmailMessage("to@example.com", "Votre reçu", "Bonjour,\n\nvoici votre reçu.", "<p>Bonjour</p>").
+		WithFrom("Shop", "shop@example.com").
+		WithHeader("X-Campaign", "receipts").
+		WithAttachment("receipt.pdf", "application/pdf", []byte("%PDF-1.7")).
+		MarshalMIME()
+	assert.NoError(t, err)
+	return raw
+}
+
+// TestDKIMSigner tests the Sign method of DKIMSigner and the VerifyDKIM function
+func TestDKIMSigner(t *testing.T) {
+	defer func(prev func() time.Time) { timeNow = prev }(timeNow)
+	timeNow = func() time.Time { return time.Unix(1700000000, 0) }
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	edPublic, edKey, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+
+	keys := []struct {
+		name      string
+		signer    *rsa.PrivateKey
+		edSigner  ed25519.PrivateKey
+		public    interface{}
+		algorithm string
+	}{
+		{"RSA", rsaKey, nil, &rsaKey.PublicKey, "rsa-sha256"},
+		{"Ed25519", nil, edKey, edPublic, "ed25519-sha256"},
+	}
+
+	for _, key := range keys {
+		for _, canon := range []Canonicalization{CanonicalizationSimple, CanonicalizationRelaxed} {
+			t.Run(key.name+" "+string(canon), func(t *testing.T) {
+				opts := DKIMOptions{Domain: "example.com", Selector: "mail", HeaderCanonicalization: canon, BodyCanonicalization: canon}
+				if key.signer != nil {
+					opts.Key = key.signer
+				} else {
+					opts.Key = key.edSigner
+				}
+				signer, err := NewDKIMSigner(opts)
+				assert.NoError(t, err)
+
+				raw := dkimTestMessage(t)
+				signed, err := signer.Sign(raw)
+				assert.NoError(t, err)
+				unfolded := strings.ReplaceAll(string(signed), "\r\n ", " ")
+				assert.True(t, strings.HasPrefix(unfolded, "DKIM-Signature: v=1; a="+key.algorithm+"; c="+string(canon)+"/"+string(canon)+"; d=example.com; s=mail; t=1700000000;"))
+				assert.Contains(t, unfolded, "h=from:subject:date:to:message-id:mime-version:content-type;")
+				assert.True(t, bytes.HasSuffix(signed, raw))
+				assert.NoError(t, VerifyDKIM(signed, key.public))
+
+				tampered := bytes.Replace(signed, []byte("Subject: "), []byte("Subject: Re: "), 1)
+				assert.ErrorIs(t, VerifyDKIM(tampered, key.public), ErrDKIMInvalid)
+
+				tampered = bytes.Replace(signed, []byte("JVBERi0xLjc="), []byte("JVBERi0xLjg="), 1)
+				err = VerifyDKIM(tampered, key.public)
+				assert.ErrorIs(t, err, ErrDKIMInvalid)
+				assert.Contains(t, err.Error(), "body hash mismatch")
+
+				// unsigned headers can be added in transit
+				assert.NoError(t, VerifyDKIM(append([]byte("Received: from relay\r\n"), signed...), key.public))
+			})
+		}
+	}
+
+	t.Run("relaxed canonicalization tolerates whitespace changes", func(t *testing.T) {
+		signer, err := NewDKIMSigner(DKIMOptions{Domain: "example.com", Selector: "mail", Key: edKey})
+		assert.NoError(t, err)
+
+		signed, err := signer.Sign(dkimTestMessage(t))
+		assert.NoError(t, err)
+
+		rewritten := bytes.Replace(signed, []byte("\r\nSubject: "), []byte("\r\nsubject:   "), 1)
+		rewritten = bytes.Replace(rewritten, []byte("\r\n\r\n"), []byte("  \r\n\r\n"), 1)
+		rewritten = append(rewritten, "\r\n\r\n"...)
+		assert.NoError(t, VerifyDKIM(rewritten, edPublic))
+	})
+
+	t.Run("configured headers, From always signed", func(t *testing.T) {
+		signer, err := NewDKIMSigner(DKIMOptions{Domain: "example.com", Selector: "mail", Key: edKey, Headers: []string{"Subject", "X-Campaign"}})
+		assert.NoError(t, err)
+
+		signed, err := signer.Sign(dkimTestMessage(t))
+		assert.NoError(t, err)
+		assert.Contains(t, strings.ReplaceAll(string(signed), "\r\n ", " "), "h=from:subject:x-campaign;")
+		assert.NoError(t, VerifyDKIM(signed, edPublic))
+		assert.NoError(t, VerifyDKIM(bytes.Replace(signed, []byte("Content-Type: multipart/mixed"), []byte("Content-Type: multipart/mixed "), 1), edPublic))
+	})
+
+	t.Run("wrong key", func(t *testing.T) {
+		signer, err := NewDKIMSigner(DKIMOptions{Domain: "example.com", Selector: "mail", Key: edKey})
+		assert.NoError(t, err)
+		signed, err := signer.Sign(dkimTestMessage(t))
+		assert.NoError(t, err)
+
+		otherPublic, _, _ := ed25519.GenerateKey(rand.Reader)
+		assert.ErrorIs(t, VerifyDKIM(signed, otherPublic), ErrDKIMInvalid)
+		assert.ErrorIs(t, VerifyDKIM(signed, &rsaKey.PublicKey), ErrDKIMInvalid)
+	})
+
+	t.Run("unsigned message", func(t *testing.T) {
+		err := VerifyDKIM(dkimTestMessage(t), edPublic)
+		assert.EqualError(t, err, "invalid DKIM signature: no DKIM-Signature header")
+	})
+}
+
+// rfc8463Message is the signed example message of RFC 8463 appendix A.3, with its Ed25519 signature only.
+const rfc8463Message = "DKIM-Signature: v=1; a=ed25519-sha256; c=relaxed/relaxed;\r\n" +
+	" d=football.example.com; i=@football.example.com;\r\n" +
+	" q=dns/txt; s=brisbane; t=1528637909; h=from : to :\r\n" +
+	" subject : date : message-id : from : subject : date;\r\n" +
+	" bh=2jUSOH9NhtVGCQWNr9BrIAPreKQjO6Sn7XIkfJVOzv8=;\r\n" +
+	" b=/gCrinpcQOoIfuHNQIbq4pgh9kyIK3AQUdt9OdqQehSwhEIug4D11Bus\r\n" +
+	" Fa3bT3FY5OsU7ZbnKELq+eXdp1Q1Dw==\r\n" +
+	"From: Joe SixPack <joe@football.example.com>\r\n" +
+	"To: Suzie Q <suzie@shopping.example.net>\r\n" +
+	"Subject: Is dinner ready?\r\n" +
+	"Date: Fri, 11 Jul 2003 21:00:37 -0700 (PDT)\r\n" +
+	"Message-ID: <20030712040037.46341.5F8J@football.example.com>\r\n" +
+	"\r\n" +
+	"Hi.\r\n" +
+	"\r\n" +
+	"We lost the game.  Are you hungry yet?\r\n" +
+	"\r\n" +
+	"Joe.\r\n"
+
+// TestDKIM_RFC8463 tests signing and verification against the Ed25519 example of RFC 8463 appendix A
+func TestDKIM_RFC8463(t *testing.T) {
+	seed, err := base64.StdEncoding.DecodeString("nWGxne/9WmC6hEr0kuwsxERJxWl7MmkZcDusAxyuf2A=")
+	assert.NoError(t, err)
+	key := ed25519.NewKeyFromSeed(seed)
+	public, err := base64.StdEncoding.DecodeString("11qYAYKxCrfVS/7TyWQHOg7hcvPapiMlrwIaaPcHURo=")
+	assert.NoError(t, err)
+	assert.Equal(t, ed25519.PublicKey(public), key.Public())
+
+	t.Run("Success - verifies the example signature", func(t *testing.T) {
+		assert.NoError(t, VerifyDKIM([]byte(rfc8463Message), ed25519.PublicKey(public)))
+	})
+
+	t.Run("Success - signs with the example body hash", func(t *testing.T) {
+		_, unsigned, _ := strings.Cut(rfc8463Message, "Fa3bT3FY5OsU7ZbnKELq+eXdp1Q1Dw==\r\n")
+		signer, err := NewDKIMSigner(DKIMOptions{Domain: "football.example.com", Selector: "brisbane", Key: key})
+		assert.NoError(t, err)
+
+		signed, err := signer.Sign([]byte(unsigned))
+		assert.NoError(t, err)
+		assert.Contains(t, string(signed), "bh=2jUSOH9NhtVGCQWNr9BrIAPreKQjO6Sn7XIkfJVOzv8=;")
+		assert.NoError(t, VerifyDKIM(signed, ed25519.PublicKey(public)))
+	})
+
+	t.Run("Failure - modified signed header", func(t *testing.T) {
+		modified := strings.Replace(rfc8463Message, "Subject: Is dinner ready?", "Subject: Is lunch ready?", 1)
+		assert.ErrorIs(t, VerifyDKIM([]byte(modified), ed25519.PublicKey(public)), ErrDKIMInvalid)
+	})
+}
+
+// TestNewDKIMSigner tests the NewDKIMSigner function
+func TestNewDKIMSigner(t *testing.T) {
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+
+	tests := []struct {
+		name     string
+		opts     DKIMOptions
+		expected string
+	}{
+		{"missing selector", DKIMOptions{Domain: "example.com", Key: edKey}, "DKIM domain and selector are required"},
+		{"unsupported key", DKIMOptions{Domain: "example.com", Selector: "mail"}, "unsupported DKIM key type <nil>"},
+		{"unsupported canonicalization", DKIMOptions{Domain: "example.com", Selector: "mail", Key: edKey, BodyCanonicalization: "nowsp"}, `unsupported DKIM canonicalization "nowsp"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewDKIMSigner(tt.opts)
+			assert.EqualError(t, err, tt.expected)
+		})
+	}
+
+	t.Run("Success - relaxed by default", func(t *testing.T) {
+		signer, err := NewDKIMSigner(DKIMOptions{Domain: "example.com", Selector: "mail", Key: edKey})
+		assert.NoError(t, err)
+
+		signed, err := signer.TransformMIME([]byte("From: a@example.com\nSubject: Hi\n\nHello\n"))
+		assert.NoError(t, err)
+		assert.Contains(t, string(signed), "c=relaxed/relaxed;")
+		assert.True(t, strings.HasSuffix(string(signed), "From: a@example.com\r\nSubject: Hi\r\n\r\nHello\r\n"))
+	})
+}
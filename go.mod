@@ -3,17 +3,24 @@ module github.com/Zapharaos/go-at
 go 1.25.0
 
 require (
+	github.com/ProtonMail/go-crypto v1.5.2
+	github.com/antihax/optional v1.0.0
 	github.com/getbrevo/brevo-go v1.1.3
 	github.com/sendgrid/rest v2.6.9+incompatible
 	github.com/sendgrid/sendgrid-go v3.16.1+incompatible
+	github.com/smallstep/pkcs7 v0.2.3
 	github.com/stretchr/testify v1.11.1
+	github.com/yuin/goldmark v1.8.6
+	golang.org/x/net v0.43.0
+	golang.org/x/text v0.28.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
-	github.com/antihax/optional v1.0.0 // indirect
+	github.com/cloudflare/circl v1.6.3 // indirect
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	golang.org/x/net v0.43.0 // indirect
+	golang.org/x/crypto v0.41.0 // indirect
 	golang.org/x/oauth2 v0.36.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
+	golang.org/x/sys v0.35.0 // indirect
 )
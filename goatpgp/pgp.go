@@ -0,0 +1,154 @@
+// Package goatpgp encrypts, and optionally signs, serialized goat messages with OpenPGP as PGP/MIME (RFC 3156).
+//
+// Encrypter implements goat.MIMETransformer. As each recipient has their own key, set it on the message:
+//
+//	encrypter, err := goatpgp.NewEncrypter(user.PGPPublicKey)
+//	if err != nil {
+//		return err
+//	}
+//	msg := goat.NewEmailMessage(user.Email, "Reset your password", text, html).
+//		WithTransformer(encrypter.WithSigner(signer))
+//
+// The message must then be sent through a raw MIME transport such as goat.NewSMTPService. The body and the
+// attachments are encrypted; the headers, including the subject, are not.
+package goatpgp
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"strings"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/Zapharaos/go-at"
+)
+
+// Signer holds the private key used to sign encrypted messages.
+type Signer struct {
+	entity *openpgp.Entity
+}
+
+// NewSigner returns a Signer for the armored private key, decrypted with passphrase if it is protected.
+func NewSigner(armoredPrivateKey string, passphrase []byte) (*Signer, error) {
+	entities, err := readKeys(armoredPrivateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	entity := entities[0]
+	if entity.PrivateKey == nil {
+		return nil, errors.New("OpenPGP signing requires a private key")
+	}
+	if entity.PrivateKey.Encrypted {
+		if err := entity.DecryptPrivateKeys(passphrase); err != nil {
+			return nil, fmt.Errorf("decrypting OpenPGP private key: %w", err)
+		}
+	}
+	return &Signer{entity: entity}, nil
+}
+
+// Encrypter encrypts messages for recipient public keys as multipart/encrypted.
+type Encrypter struct {
+	recipients openpgp.EntityList
+	signer     *Signer
+}
+
+// NewEncrypter returns an Encrypter for the armored public keys, which must have a valid encryption key.
+func NewEncrypter(armoredPublicKeys ...string) (*Encrypter, error) {
+	if len(armoredPublicKeys) == 0 {
+		return nil, errors.New("OpenPGP encryption requires at least one recipient key")
+	}
+
+	e := &Encrypter{}
+	for _, armored := range armoredPublicKeys {
+		entities, err := readKeys(armored)
+		if err != nil {
+			return nil, err
+		}
+		for _, entity := range entities {
+			if _, ok := entity.EncryptionKey(time.Now()); !ok {
+				return nil, fmt.Errorf("OpenPGP key %X has no valid encryption key", entity.PrimaryKey.Fingerprint)
+			}
+		}
+		e.recipients = append(e.recipients, entities...)
+	}
+	return e, nil
+}
+
+// WithSigner returns a copy of the Encrypter also signing messages with the signer key.
+func (e *Encrypter) WithSigner(signer *Signer) *Encrypter {
+	c := *e
+	c.signer = signer
+	return &c
+}
+
+// TransformMIME implements the goat.MIMETransformer interface. The headers of the message are kept,
+// and its content is replaced by a multipart/encrypted body holding the armored OpenPGP message.
+func (e *Encrypter) TransformMIME(raw []byte) ([]byte, error) {
+	header, entity, err := goat.SplitMIMEEntity(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	var armored bytes.Buffer
+	aw, err := armor.Encode(&armored, "PGP MESSAGE", nil)
+	if err != nil {
+		return nil, err
+	}
+	var signed *openpgp.Entity
+	if e.signer != nil {
+		signed = e.signer.entity
+	}
+	pw, err := openpgp.Encrypt(aw, e.recipients, signed, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("OpenPGP encryption: %w", err)
+	}
+	if _, err := pw.Write(entity); err != nil {
+		return nil, fmt.Errorf("OpenPGP encryption: %w", err)
+	}
+	if err := pw.Close(); err != nil {
+		return nil, fmt.Errorf("OpenPGP encryption: %w", err)
+	}
+	if err := aw.Close(); err != nil {
+		return nil, err
+	}
+
+	boundary := multipart.NewWriter(io.Discard).Boundary()
+
+	var out bytes.Buffer
+	out.Write(header)
+	out.WriteString("MIME-Version: 1.0\r\n")
+	out.WriteString("Content-Type: " + mime.FormatMediaType("multipart/encrypted", map[string]string{
+		"protocol": "application/pgp-encrypted",
+		"boundary": boundary,
+	}) + "\r\n\r\n")
+	out.WriteString("This is an OpenPGP/MIME encrypted message (RFC 4880 and 3156)\r\n")
+	out.WriteString("--" + boundary + "\r\n")
+	out.WriteString("Content-Type: application/pgp-encrypted\r\n")
+	out.WriteString("Content-Description: PGP/MIME version identification\r\n\r\n")
+	out.WriteString("Version: 1\r\n")
+	out.WriteString("\r\n--" + boundary + "\r\n")
+	out.WriteString("Content-Type: application/octet-stream; name=encrypted.asc\r\n")
+	out.WriteString("Content-Description: OpenPGP encrypted message\r\n")
+	out.WriteString("Content-Disposition: inline; filename=encrypted.asc\r\n\r\n")
+	out.WriteString(strings.ReplaceAll(armored.String(), "\n", "\r\n"))
+	out.WriteString("\r\n--" + boundary + "--\r\n")
+	return out.Bytes(), nil
+}
+
+// readKeys reads the entities of an armored key block.
+func readKeys(armored string) (openpgp.EntityList, error) {
+	entities, err := openpgp.ReadArmoredKeyRing(strings.NewReader(armored))
+	if err != nil {
+		return nil, fmt.Errorf("reading OpenPGP key: %w", err)
+	}
+	if len(entities) == 0 {
+		return nil, errors.New("reading OpenPGP key: no key found")
+	}
+	return entities, nil
+}
@@ -0,0 +1,142 @@
+package goatpgp
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"net/mail"
+	"strings"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/Zapharaos/go-at"
+	"github.com/stretchr/testify/assert"
+)
+
+// testKey returns a new entity with its armored public and private keys, the private one protected by passphrase if set.
+func testKey(t *testing.T, email string, passphrase []byte) (*openpgp.Entity, string, string) {
+	t.Helper()
+
+	entity, err := openpgp.NewEntity(email, "", email, nil)
+	assert.NoError(t, err)
+
+	var public bytes.Buffer
+	w, _ := armor.Encode(&public, openpgp.PublicKeyType, nil)
+	assert.NoError(t, entity.Serialize(w))
+	assert.NoError(t, w.Close())
+
+	if passphrase != nil {
+		assert.NoError(t, entity.EncryptPrivateKeys(passphrase, nil))
+	}
+	var private bytes.Buffer
+	w, _ = armor.Encode(&private, openpgp.PrivateKeyType, nil)
+	assert.NoError(t, entity.SerializePrivateWithoutSigning(w, nil))
+	assert.NoError(t, w.Close())
+
+	if passphrase != nil {
+		assert.NoError(t, entity.DecryptPrivateKeys(passphrase))
+	}
+	return entity, public.String(), private.String()
+}
+
+// decrypt returns the decrypted content of a multipart/encrypted message and its details.
+func decrypt(t *testing.T, encrypted []byte, keyring openpgp.EntityList) (*mail.Message, string, *openpgp.MessageDetails) {
+	t.Helper()
+
+	msg, err := mail.ReadMessage(bytes.NewReader(encrypted))
+	assert.NoError(t, err)
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	assert.NoError(t, err)
+	assert.Equal(t, "multipart/encrypted", mediaType)
+	assert.Equal(t, "application/pgp-encrypted", params["protocol"])
+
+	body, _ := io.ReadAll(msg.Body)
+	sections := strings.Split(string(body), "--"+params["boundary"])
+	assert.Len(t, sections, 4)
+	assert.Contains(t, sections[1], "Content-Type: application/pgp-encrypted\r\n")
+	assert.Contains(t, sections[1], "\r\n\r\nVersion: 1\r\n")
+
+	block, err := armor.Decode(strings.NewReader(sections[2][strings.Index(sections[2], "-----BEGIN"):]))
+	assert.NoError(t, err)
+	md, err := openpgp.ReadMessage(block.Body, keyring, nil, nil)
+	assert.NoError(t, err)
+	content, err := io.ReadAll(md.UnverifiedBody)
+	assert.NoError(t, err)
+	return msg, string(content), md
+}
+
+// TestEncrypter_TransformMIME tests the TransformMIME method of Encrypter
+func TestEncrypter_TransformMIME(t *testing.T) {
+	recipient, recipientPublic, _ := testKey(t, "user@example.com", nil)
+	sender, _, senderPrivate := testKey(t, "security@example.com", []byte("secret"))
+
+	newMessage := func() *goat.EmailMessage {
+		return goat.NewEmailMessage("user@example.com", "Reset your password", "Your reset code is 123456.", "<p>Your reset code is <b>123456</b>.</p>").
+			WithFrom("Security", "security@example.com").
+			WithAttachment("recovery.txt", "text/plain", []byte("recovery codes"))
+	}
+
+	t.Run("Success - encrypt", func(t *testing.T) {
+		encrypter, err := NewEncrypter(recipientPublic)
+		assert.NoError(t, err)
+
+		raw, err := newMessage().WithTransformer(encrypter).MarshalMIME()
+		assert.NoError(t, err)
+		assert.NotContains(t, string(raw), "123456")
+		assert.NotContains(t, string(raw), "recovery")
+
+		msg, content, md := decrypt(t, raw, openpgp.EntityList{recipient})
+		assert.Equal(t, "Reset your password", msg.Header.Get("Subject"))
+		assert.False(t, md.IsSigned)
+
+		decrypted, err := goat.ParseEmailMessage(strings.NewReader(content))
+		assert.NoError(t, err)
+		assert.Equal(t, "Your reset code is 123456.", decrypted.PlainTextContent)
+		assert.Equal(t, "<p>Your reset code is <b>123456</b>.</p>", decrypted.HTMLContent)
+		assert.Equal(t, []goat.Attachment{{Filename: "recovery.txt", ContentType: "text/plain", Content: []byte("recovery codes")}}, decrypted.Attachments)
+	})
+
+	t.Run("Success - encrypt and sign", func(t *testing.T) {
+		signer, err := NewSigner(senderPrivate, []byte("secret"))
+		assert.NoError(t, err)
+		encrypter, err := NewEncrypter(recipientPublic)
+		assert.NoError(t, err)
+
+		raw, err := newMessage().WithTransformer(encrypter.WithSigner(signer)).MarshalMIME()
+		assert.NoError(t, err)
+
+		_, _, md := decrypt(t, raw, openpgp.EntityList{recipient, sender})
+		assert.True(t, md.IsSigned)
+		assert.NotNil(t, md.SignedBy)
+		assert.NoError(t, md.SignatureError)
+	})
+
+	t.Run("Failure - providers building MIME reject transformers", func(t *testing.T) {
+		encrypter, _ := NewEncrypter(recipientPublic)
+		service := goat.NewSendgridService("key", "Security", "security@example.com")
+
+		err := service.Send(newMessage().WithTransformer(encrypter))
+		assert.ErrorIs(t, err, goat.ErrTransformersUnsupported)
+	})
+}
+
+// TestNewEncrypter tests the NewEncrypter function
+func TestNewEncrypter(t *testing.T) {
+	_, err := NewEncrypter()
+	assert.EqualError(t, err, "OpenPGP encryption requires at least one recipient key")
+
+	_, err = NewEncrypter("not a key")
+	assert.ErrorContains(t, err, "reading OpenPGP key")
+}
+
+// TestNewSigner tests the NewSigner function
+func TestNewSigner(t *testing.T) {
+	_, public, private := testKey(t, "security@example.com", []byte("secret"))
+
+	_, err := NewSigner(private, []byte("wrong"))
+	assert.ErrorContains(t, err, "decrypting OpenPGP private key")
+
+	_, err = NewSigner(public, nil)
+	assert.EqualError(t, err, "OpenPGP signing requires a private key")
+}
@@ -0,0 +1,239 @@
+package goatpreview
+
+import (
+	"html/template"
+	"net/http"
+	"strings"
+
+	"github.com/Zapharaos/go-at"
+)
+
+// Preview widths, in pixels, of the rendered HTML body.
+const (
+	MobileWidth  = 375
+	DesktopWidth = 800
+)
+
+// indexEntry describes a template of the index page.
+type indexEntry struct {
+	Name     string
+	Kinds    []string
+	Fixtures []string
+}
+
+// indexPage is the data of the index page.
+type indexPage struct {
+	Root      string
+	Templates []indexEntry
+	Error     string
+}
+
+// Title returns the title of the index page.
+func (indexPage) Title() string {
+	return "Templates"
+}
+
+// source is a raw template source shown on the preview page.
+type source struct {
+	File    string
+	Content string
+}
+
+// previewPage is the data of the preview page.
+type previewPage struct {
+	Root      string
+	Name      string
+	Fixture   string
+	Fixtures  []string
+	Width     int
+	Mobile    bool
+	PlainText string
+	Sources   []source
+	Error     string
+}
+
+// Title returns the title of the preview page.
+func (p previewPage) Title() string {
+	return p.Name
+}
+
+// root returns the relative URL of the handler root from the requested page, so that the links of the pages
+// keep working when the handler is mounted under a prefix, e.g. with http.StripPrefix.
+func root(r *http.Request) string {
+	if depth := strings.Count(r.URL.Path, "/") - 1; depth > 0 {
+		return strings.Repeat("../", depth)
+	}
+	return "./"
+}
+
+// handleIndex lists the templates of the directory.
+func (h *Handler) handleIndex(w http.ResponseWriter, r *http.Request) {
+	page := indexPage{Root: root(r)}
+
+	set, err := h.load()
+	if err != nil {
+		page.Error = err.Error()
+	} else {
+		for _, name := range set.Names() {
+			entry, _ := set.Lookup(name)
+			item := indexEntry{Name: name, Fixtures: entry.FixtureNames()}
+			for _, kind := range []string{goat.TemplateKindHTML, goat.TemplateKindText, goat.TemplateKindMarkdown} {
+				if _, ok := entry.Sources[kind]; ok {
+					item.Kinds = append(item.Kinds, kind)
+				}
+			}
+			page.Templates = append(page.Templates, item)
+		}
+	}
+
+	h.execute(w, "index", page)
+}
+
+// handlePreview shows the HTML body, plain text body and raw sources of a template side by side.
+func (h *Handler) handlePreview(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	page := previewPage{
+		Root:    root(r),
+		Name:    name,
+		Fixture: r.URL.Query().Get("fixture"),
+		Width:   DesktopWidth,
+		Mobile:  r.URL.Query().Get("width") == "mobile",
+	}
+	if page.Mobile {
+		page.Width = MobileWidth
+	}
+
+	set, err := h.load()
+	if err != nil {
+		page.Error = err.Error()
+		h.execute(w, "preview", page)
+		return
+	}
+
+	entry, ok := set.Lookup(name)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	page.Fixtures = entry.FixtureNames()
+	for _, kind := range []string{goat.TemplateKindMarkdown, goat.TemplateKindHTML, goat.TemplateKindText} {
+		if content, ok := entry.Sources[kind]; ok {
+			page.Sources = append(page.Sources, source{File: entry.Files[kind], Content: content})
+		}
+	}
+
+	page.PlainText, _, err = h.render(entry, page.Fixture)
+	if err != nil {
+		page.Error = err.Error()
+	}
+
+	h.execute(w, "preview", page)
+}
+
+// handleRenderHTML serves the rendered HTML body of a template, displayed in the preview page iframe.
+func (h *Handler) handleRenderHTML(w http.ResponseWriter, r *http.Request) {
+	set, err := h.load()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	entry, ok := set.Lookup(r.PathValue("name"))
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	_, html, err := h.render(entry, r.URL.Query().Get("fixture"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(html))
+}
+
+// execute writes the page with the given name.
+func (h *Handler) execute(w http.ResponseWriter, name string, data interface{}) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := pages.ExecuteTemplate(w, name, data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+var pages = template.Must(template.New("pages").Parse(`
+{{define "head"}}<!DOCTYPE html>
+<html>
+<head>
+<meta charset="UTF-8">
+<title>{{.Title}} - goat preview</title>
+<style>
+body { margin: 0; font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Roboto, Arial, sans-serif; color: #222; background: #f3f4f6; }
+header { padding: 12px 20px; background: #1f2937; color: #fff; display: flex; gap: 16px; align-items: center; }
+header a { color: #fff; }
+main { padding: 20px; }
+table { border-collapse: collapse; background: #fff; }
+th, td { border: 1px solid #ddd; padding: 6px 12px; text-align: left; }
+.error { padding: 12px; margin-bottom: 16px; background: #fee2e2; color: #991b1b; white-space: pre-wrap; font-family: monospace; }
+.panes { display: flex; gap: 16px; align-items: flex-start; }
+.pane { background: #fff; padding: 12px; overflow: auto; }
+.pane h2 { margin: 0 0 8px; font-size: 14px; text-transform: uppercase; color: #555; }
+.pane pre { margin: 0; white-space: pre-wrap; font-size: 13px; }
+iframe { border: 1px solid #ddd; height: 80vh; background: #fff; }
+.active { font-weight: bold; text-decoration: none; }
+</style>
+<script>
+new EventSource({{.Root}} + "events").addEventListener("reload", function () { location.reload(); });
+</script>
+</head>
+<body>
+{{end}}
+
+{{define "index"}}{{template "head" .}}{{$root := .Root}}
+<header><strong>goat preview</strong></header>
+<main>
+{{with .Error}}<div class="error">{{.}}</div>{{end}}
+<table>
+<tr><th>Template</th><th>Sources</th><th>Fixtures</th></tr>
+{{range .Templates}}{{$name := .Name}}
+<tr>
+<td><a href="{{$root}}preview/{{.Name}}">{{.Name}}</a></td>
+<td>{{range .Kinds}}{{.}} {{end}}</td>
+<td>{{range .Fixtures}}<a href="{{$root}}preview/{{$name}}?fixture={{.}}">{{if .}}{{.}}{{else}}default{{end}}</a> {{end}}</td>
+</tr>
+{{else}}
+<tr><td colspan="3">No template found.</td></tr>
+{{end}}
+</table>
+</main>
+</body>
+</html>
+{{end}}
+
+{{define "preview"}}{{template "head" .}}{{$page := .}}
+<header>
+<a href="{{.Root}}">&larr; Templates</a>
+<strong>{{.Name}}</strong>
+<span>Fixture:
+{{range .Fixtures}}<a class="{{if eq . $page.Fixture}}active{{end}}" href="?fixture={{.}}{{if $page.Mobile}}&width=mobile{{end}}">{{if .}}{{.}}{{else}}default{{end}}</a> {{else}}none{{end}}
+</span>
+<span>Width:
+<a class="{{if not .Mobile}}active{{end}}" href="?fixture={{.Fixture}}">desktop</a>
+<a class="{{if .Mobile}}active{{end}}" href="?fixture={{.Fixture}}&width=mobile">mobile</a>
+</span>
+</header>
+<main>
+{{with .Error}}<div class="error">{{.}}</div>{{else}}
+<div class="panes">
+<div class="pane"><h2>HTML</h2><iframe style="width: {{.Width}}px" src="{{.Root}}render/html/{{.Name}}?fixture={{.Fixture}}"></iframe></div>
+<div class="pane" style="flex: 1"><h2>Plain text</h2><pre>{{.PlainText}}</pre></div>
+<div class="pane" style="flex: 1"><h2>Source</h2>{{range .Sources}}<h2>{{.File}}</h2><pre>{{.Content}}</pre>{{end}}</div>
+</div>
+{{end}}
+</main>
+</body>
+</html>
+{{end}}
+`))
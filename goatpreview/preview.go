@@ -0,0 +1,115 @@
+// Package goatpreview provides a development HTTP handler previewing the email templates of a
+// goat.TemplateSet directory, so designers can iterate on templates without sending real mail.
+//
+// The handler lists the templates of the directory, renders each of them with its sample data
+// fixtures (JSON or YAML files next to the template, see goat.TemplateSet) and shows the HTML body,
+// the plain text body and the raw sources side by side, at mobile or desktop width.
+// Pages live-reload when a file of the directory changes on disk.
+//
+//	http.ListenAndServe("localhost:8025", goatpreview.NewHandler(os.DirFS("templates")))
+//
+// The handler is meant for local development only and must not be exposed publicly.
+package goatpreview
+
+import (
+	"fmt"
+	"io/fs"
+	"net/http"
+	"time"
+
+	"github.com/Zapharaos/go-at"
+	"github.com/Zapharaos/go-at/internal/fsutil"
+)
+
+// DefaultPollInterval is the interval at which the template directory is checked for changes.
+const DefaultPollInterval = 500 * time.Millisecond
+
+// Handler serves the template previews of a directory.
+type Handler struct {
+	fsys         fs.FS
+	mux          *http.ServeMux
+	pollInterval time.Duration
+}
+
+// NewHandler returns a preview handler for the templates of fsys, e.g. os.DirFS("templates").
+func NewHandler(fsys fs.FS) *Handler {
+	h := &Handler{
+		fsys:         fsys,
+		mux:          http.NewServeMux(),
+		pollInterval: DefaultPollInterval,
+	}
+
+	h.mux.HandleFunc("GET /{$}", h.handleIndex)
+	h.mux.HandleFunc("GET /preview/{name...}", h.handlePreview)
+	h.mux.HandleFunc("GET /render/html/{name...}", h.handleRenderHTML)
+	h.mux.HandleFunc("GET /events", h.handleEvents)
+	return h
+}
+
+// WithPollInterval sets the interval at which the directory is checked for changes and returns the handler for chaining.
+func (h *Handler) WithPollInterval(interval time.Duration) *Handler {
+	h.pollInterval = interval
+	return h
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+// load loads the template directory, templates being reloaded from disk on every request.
+func (h *Handler) load() (*goat.TemplateSet, error) {
+	return goat.LoadTemplateSet(h.fsys)
+}
+
+// render renders a template with the requested fixture.
+func (h *Handler) render(entry *goat.TemplateEntry, fixture string) (plainText, html string, err error) {
+	var data interface{}
+	if _, ok := entry.Fixtures[fixture]; ok {
+		data, err = entry.Fixture(fixture)
+		if err != nil {
+			return "", "", err
+		}
+	}
+	return entry.Render(data)
+}
+
+// version returns a fingerprint of the files of the directory.
+func (h *Handler) version() (uint64, error) {
+	return fsutil.Fingerprint(h.fsys)
+}
+
+// handleEvents streams a "reload" server-sent event whenever the template directory changes.
+func (h *Handler) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	current, _ := h.version()
+	ticker := time.NewTicker(h.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+			next, err := h.version()
+			if err != nil || next == current {
+				continue
+			}
+			current = next
+			if _, err := fmt.Fprint(w, "event: reload\ndata: {}\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
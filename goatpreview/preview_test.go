@@ -0,0 +1,166 @@
+package goatpreview
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testFS returns a template directory used by the preview tests
+func testFS() fstest.MapFS {
+	return fstest.MapFS{
+		"welcome.html":        {Data: []byte("<p>Hello {{.Name}}</p>")},
+		"welcome.txt":         {Data: []byte("Hello {{.Name}}")},
+		"welcome.json":        {Data: []byte(`{"Name": "John"}`)},
+		"welcome.french.yaml": {Data: []byte("Name: Jean\n")},
+		"news.md":             {Data: []byte("# News")},
+	}
+}
+
+// get performs a GET request against the handler
+func get(h http.Handler, target string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+	return rec
+}
+
+// TestHandler_Index tests the template list page
+func TestHandler_Index(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		rec := get(NewHandler(testFS()), "/")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `<a href="./preview/welcome">welcome</a>`)
+		assert.Contains(t, rec.Body.String(), `<a href="./preview/welcome?fixture=french">french</a>`)
+		assert.Contains(t, rec.Body.String(), `<a href="./preview/news">news</a>`)
+	})
+
+	t.Run("Load error", func(t *testing.T) {
+		fsys := testFS()
+		fsys["broken.html"] = &fstest.MapFile{Data: []byte("{{.Name")}
+
+		rec := get(NewHandler(fsys), "/")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `class="error"`)
+	})
+}
+
+// TestHandler_Preview tests the template preview page
+func TestHandler_Preview(t *testing.T) {
+	h := NewHandler(testFS())
+
+	t.Run("Success - default fixture", func(t *testing.T) {
+		rec := get(h, "/preview/welcome")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), "<pre>Hello John</pre>")
+		assert.Contains(t, rec.Body.String(), "&lt;p&gt;Hello {{.Name}}&lt;/p&gt;")
+		assert.Contains(t, rec.Body.String(), `style="width: 800px"`)
+	})
+
+	t.Run("Success - named fixture at mobile width", func(t *testing.T) {
+		rec := get(h, "/preview/welcome?fixture=french&width=mobile")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), "<pre>Hello Jean</pre>")
+		assert.Contains(t, rec.Body.String(), `style="width: 375px"`)
+	})
+
+	t.Run("Render error", func(t *testing.T) {
+		rec := get(h, "/preview/welcome?fixture=unknown")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `class="error"`)
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		rec := get(h, "/preview/unknown")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
+
+// TestHandler_Prefix tests that the links of the pages resolve under the prefix the handler is mounted at
+func TestHandler_Prefix(t *testing.T) {
+	fsys := testFS()
+	fsys["emails/reset.txt"] = &fstest.MapFile{Data: []byte("Reset")}
+	h := http.StripPrefix("/mail", NewHandler(fsys))
+
+	// resolve returns the absolute path of a relative link of the page at the given path
+	resolve := func(page, link string) string {
+		base, err := url.Parse(page)
+		assert.NoError(t, err)
+		ref, err := url.Parse(link)
+		assert.NoError(t, err)
+		return base.ResolveReference(ref).Path
+	}
+
+	t.Run("Success - index", func(t *testing.T) {
+		rec := get(h, "/mail/")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `<a href="./preview/emails/reset">`)
+		assert.Contains(t, rec.Body.String(), `new EventSource("./" + "events")`)
+		assert.Equal(t, "/mail/preview/emails/reset", resolve("/mail/", "./preview/emails/reset"))
+		assert.Equal(t, "/mail/events", resolve("/mail/", "./events"))
+	})
+
+	t.Run("Success - nested preview", func(t *testing.T) {
+		rec := get(h, "/mail/preview/emails/reset")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `<a href="../../">&larr; Templates</a>`)
+		assert.Contains(t, rec.Body.String(), `src="../../render/html/emails/reset?fixture="`)
+		assert.Equal(t, "/mail/", resolve("/mail/preview/emails/reset", "../../"))
+		assert.Equal(t, "/mail/render/html/emails/reset", resolve("/mail/preview/emails/reset", "../../render/html/emails/reset"))
+		assert.Equal(t, http.StatusOK, get(h, "/mail/render/html/emails/reset").Code)
+	})
+}
+
+// TestHandler_RenderHTML tests the rendered HTML body endpoint
+func TestHandler_RenderHTML(t *testing.T) {
+	h := NewHandler(testFS())
+
+	t.Run("Success", func(t *testing.T) {
+		rec := get(h, "/render/html/welcome?fixture=french")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "<p>Hello Jean</p>", rec.Body.String())
+	})
+
+	t.Run("Render error", func(t *testing.T) {
+		rec := get(h, "/render/html/welcome?fixture=unknown")
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		rec := get(h, "/render/html/unknown")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
+
+// TestHandler_Events tests that a reload event is sent when a template changes
+func TestHandler_Events(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "welcome.txt"), []byte("Hello {{.Name}}"), 0o600))
+	server := httptest.NewServer(NewHandler(os.DirFS(dir)).WithPollInterval(10 * time.Millisecond))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/events", nil)
+	assert.NoError(t, err)
+	res, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "welcome.html"), []byte("<p>Hello {{.Name}}</p>"), 0o600))
+
+	line, err := bufio.NewReader(res.Body).ReadString('\n')
+	assert.NoError(t, err)
+	assert.Equal(t, "event: reload", strings.TrimSpace(line))
+}
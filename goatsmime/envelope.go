@@ -0,0 +1,134 @@
+package goatsmime
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+	"math/big"
+)
+
+// contentKeyLength is the length of the AES-256 content encryption key.
+const contentKeyLength = 32
+
+var (
+	oidData          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidEnvelopedData = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 3}
+	oidRSAEncryption = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	oidAES256CBC     = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+)
+
+// contentInfo is the outer CMS structure (RFC 5652 section 3).
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"tag:0"` // [0] EXPLICIT, built as a constructed context-specific value
+}
+
+// envelopedData holds the encrypted content and the content key encrypted for each recipient (RFC 5652 section 6.1).
+type envelopedData struct {
+	Version              int
+	RecipientInfos       []keyTransRecipientInfo `asn1:"set"`
+	EncryptedContentInfo encryptedContentInfo
+}
+
+// keyTransRecipientInfo holds the content key encrypted with the public key of a recipient certificate.
+type keyTransRecipientInfo struct {
+	Version                int
+	IssuerAndSerialNumber  issuerAndSerialNumber
+	KeyEncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedKey           []byte
+}
+
+// issuerAndSerialNumber identifies a recipient certificate.
+type issuerAndSerialNumber struct {
+	Issuer       asn1.RawValue
+	SerialNumber *big.Int
+}
+
+// encryptedContentInfo holds the encrypted content and its encryption algorithm.
+type encryptedContentInfo struct {
+	ContentType                asn1.ObjectIdentifier
+	ContentEncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedContent           asn1.RawValue `asn1:"tag:0,optional"`
+}
+
+// checkRecipient returns an error if the certificate cannot be used to encrypt a content key.
+func checkRecipient(cert *x509.Certificate) error {
+	if cert == nil {
+		return fmt.Errorf("nil recipient certificate")
+	}
+	if _, ok := cert.PublicKey.(*rsa.PublicKey); !ok {
+		return fmt.Errorf("recipient certificate %q: unsupported public key type %T, only RSA is supported", cert.Subject.CommonName, cert.PublicKey)
+	}
+	return nil
+}
+
+// envelope encrypts content with a random AES-256-CBC key, itself encrypted for each recipient with
+// RSA PKCS #1 v1.5, and returns the DER encoded CMS enveloped data.
+//
+// pkcs7.Encrypt is not used because it takes its content encryption algorithm from the package-global
+// pkcs7.ContentEncryptionAlgorithm, which defaults to DES-CBC: setting it to AES would race with, and
+// silently change, every other user of the pkcs7 package in the same program.
+func envelope(content []byte, recipients []*x509.Certificate) ([]byte, error) {
+	key := make([]byte, contentKeyLength)
+	iv := make([]byte, aes.BlockSize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(iv); err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	padding := aes.BlockSize - len(content)%aes.BlockSize
+	plaintext := make([]byte, len(content), len(content)+padding)
+	copy(plaintext, content)
+	for range padding {
+		plaintext = append(plaintext, byte(padding))
+	}
+	ciphertext := make([]byte, len(plaintext))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(ciphertext, plaintext)
+
+	infos := make([]keyTransRecipientInfo, 0, len(recipients))
+	for _, cert := range recipients {
+		if err := checkRecipient(cert); err != nil {
+			return nil, err
+		}
+		encryptedKey, err := rsa.EncryptPKCS1v15(rand.Reader, cert.PublicKey.(*rsa.PublicKey), key)
+		if err != nil {
+			return nil, fmt.Errorf("encrypting content key for %q: %w", cert.Subject.CommonName, err)
+		}
+		infos = append(infos, keyTransRecipientInfo{
+			IssuerAndSerialNumber:  issuerAndSerialNumber{Issuer: asn1.RawValue{FullBytes: cert.RawIssuer}, SerialNumber: cert.SerialNumber},
+			KeyEncryptionAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidRSAEncryption, Parameters: asn1.NullRawValue},
+			EncryptedKey:           encryptedKey,
+		})
+	}
+
+	enveloped, err := asn1.Marshal(envelopedData{
+		RecipientInfos: infos,
+		EncryptedContentInfo: encryptedContentInfo{
+			ContentType: oidData,
+			ContentEncryptionAlgorithm: pkix.AlgorithmIdentifier{
+				Algorithm:  oidAES256CBC,
+				Parameters: asn1.RawValue{Tag: asn1.TagOctetString, Bytes: iv},
+			},
+			EncryptedContent: asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, Bytes: ciphertext},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return asn1.Marshal(contentInfo{
+		ContentType: oidEnvelopedData,
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: enveloped},
+	})
+}
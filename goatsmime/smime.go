@@ -0,0 +1,143 @@
+// Package goatsmime signs and encrypts serialized goat messages with S/MIME (RFC 8551).
+//
+// Signer and Encrypter implement goat.MIMETransformer: pass them to goat.NewSMTPService, or apply them to
+// the output of EmailMessage.MarshalMIME; an Encrypter for a single recipient can also be set on the
+// message with EmailMessage.WithTransformer. To sign and encrypt, sign first:
+//
+//	signer, _ := goatsmime.NewSigner(cert, key)
+//	encrypter, _ := goatsmime.NewEncrypter(recipientCert)
+//	service := goat.NewSMTPService(addr, auth, "Your Company", "no-reply@yourcompany.com", signer, encrypter)
+//
+// A DKIM signer must come after them, as it signs the final headers and body.
+package goatsmime
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+
+	"github.com/Zapharaos/go-at"
+	"github.com/smallstep/pkcs7"
+)
+
+// base64LineLength is the maximum length of a base64 line, excluding CRLF.
+const base64LineLength = 76
+
+// Signer signs messages with a detached application/pkcs7-signature part (multipart/signed).
+type Signer struct {
+	cert  *x509.Certificate
+	key   crypto.PrivateKey
+	chain []*x509.Certificate
+}
+
+// NewSigner returns a Signer using the certificate and its private key. The intermediate certificates
+// of chain are embedded in signatures so recipients can build the path to a trusted root.
+func NewSigner(cert *x509.Certificate, key crypto.PrivateKey, chain ...*x509.Certificate) (*Signer, error) {
+	if cert == nil || key == nil {
+		return nil, errors.New("S/MIME signing requires a certificate and its private key")
+	}
+	return &Signer{cert: cert, key: key, chain: chain}, nil
+}
+
+// TransformMIME implements the goat.MIMETransformer interface. The headers of the message are kept,
+// and its content becomes the first part of a multipart/signed body, followed by the signature.
+func (s *Signer) TransformMIME(raw []byte) ([]byte, error) {
+	header, entity, err := goat.SplitMIMEEntity(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	sd, err := pkcs7.NewSignedData(entity)
+	if err != nil {
+		return nil, fmt.Errorf("S/MIME signing: %w", err)
+	}
+	sd.SetDigestAlgorithm(pkcs7.OIDDigestAlgorithmSHA256)
+	if err := sd.AddSignerChain(s.cert, s.key, s.chain, pkcs7.SignerInfoConfig{}); err != nil {
+		return nil, fmt.Errorf("S/MIME signing: %w", err)
+	}
+	sd.Detach()
+	signature, err := sd.Finish()
+	if err != nil {
+		return nil, fmt.Errorf("S/MIME signing: %w", err)
+	}
+
+	boundary := multipart.NewWriter(io.Discard).Boundary()
+
+	var out bytes.Buffer
+	out.Write(header)
+	out.WriteString("MIME-Version: 1.0\r\n")
+	out.WriteString("Content-Type: " + mime.FormatMediaType("multipart/signed", map[string]string{
+		"protocol": "application/pkcs7-signature",
+		"micalg":   "sha-256",
+		"boundary": boundary,
+	}) + "\r\n\r\n")
+	out.WriteString("--" + boundary + "\r\n")
+	out.Write(entity)
+	out.WriteString("\r\n--" + boundary + "\r\n")
+	out.WriteString("Content-Type: application/pkcs7-signature; name=smime.p7s\r\n")
+	out.WriteString("Content-Transfer-Encoding: base64\r\n")
+	out.WriteString("Content-Disposition: attachment; filename=smime.p7s\r\n\r\n")
+	writeBase64(&out, signature)
+	out.WriteString("\r\n--" + boundary + "--\r\n")
+	return out.Bytes(), nil
+}
+
+// Encrypter encrypts messages for recipient certificates as application/pkcs7-mime enveloped data.
+type Encrypter struct {
+	recipients []*x509.Certificate
+}
+
+// NewEncrypter returns an Encrypter for the recipient certificates, which must hold RSA keys.
+// Include the sender certificate to keep the sent message readable by the sender.
+func NewEncrypter(recipients ...*x509.Certificate) (*Encrypter, error) {
+	if len(recipients) == 0 {
+		return nil, errors.New("S/MIME encryption requires at least one recipient certificate")
+	}
+	for _, cert := range recipients {
+		if err := checkRecipient(cert); err != nil {
+			return nil, err
+		}
+	}
+	return &Encrypter{recipients: recipients}, nil
+}
+
+// TransformMIME implements the goat.MIMETransformer interface. The headers of the message are kept,
+// and its content is replaced by the enveloped data, encrypted with AES-256-CBC.
+func (e *Encrypter) TransformMIME(raw []byte) ([]byte, error) {
+	header, entity, err := goat.SplitMIMEEntity(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	enveloped, err := envelope(entity, e.recipients)
+	if err != nil {
+		return nil, fmt.Errorf("S/MIME encryption: %w", err)
+	}
+
+	var out bytes.Buffer
+	out.Write(header)
+	out.WriteString("MIME-Version: 1.0\r\n")
+	out.WriteString("Content-Type: application/pkcs7-mime; smime-type=enveloped-data; name=smime.p7m\r\n")
+	out.WriteString("Content-Transfer-Encoding: base64\r\n")
+	out.WriteString("Content-Disposition: attachment; filename=smime.p7m\r\n\r\n")
+	writeBase64(&out, enveloped)
+	out.WriteString("\r\n")
+	return out.Bytes(), nil
+}
+
+// writeBase64 writes content base64 encoded in lines of base64LineLength characters, without trailing CRLF.
+func writeBase64(w *bytes.Buffer, content []byte) {
+	encoded := base64.StdEncoding.EncodeToString(content)
+	for i := 0; i < len(encoded); i += base64LineLength {
+		if i > 0 {
+			w.WriteString("\r\n")
+		}
+		w.WriteString(encoded[i:min(i+base64LineLength, len(encoded))])
+	}
+}
@@ -0,0 +1,184 @@
+package goatsmime
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"io"
+	"math/big"
+	"mime"
+	"net/mail"
+	"testing"
+	"time"
+
+	"github.com/Zapharaos/go-at"
+	"github.com/smallstep/pkcs7"
+	"github.com/stretchr/testify/assert"
+)
+
+// testCertificate returns a self-signed S/MIME certificate and its key.
+func testCertificate(t *testing.T, email string) (*x509.Certificate, *rsa.PrivateKey) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: email},
+		EmailAddresses:        []string{email},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageEmailProtection},
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+	return cert, key
+}
+
+// testMessage returns a serialized message.
+func testMessage(t *testing.T) []byte {
+	t.Helper()
+
+	raw, err := goat.NewEmailMessage("customer@example.com", "Your invoice", "Please find your invoice attached.", "<p>Please find your invoice attached.</p>").
+		WithFrom("Shop", "shop@example.com").
+		WithAttachment("invoice.pdf", "application/pdf", []byte("%PDF-1.7")).
+		MarshalMIME()
+	assert.NoError(t, err)
+	return raw
+}
+
+// TestSigner_TransformMIME tests the TransformMIME method of Signer
+func TestSigner_TransformMIME(t *testing.T) {
+	cert, key := testCertificate(t, "shop@example.com")
+	signer, err := NewSigner(cert, key)
+	assert.NoError(t, err)
+
+	raw := testMessage(t)
+	signed, err := signer.TransformMIME(raw)
+	assert.NoError(t, err)
+
+	msg, err := mail.ReadMessage(bytes.NewReader(signed))
+	assert.NoError(t, err)
+	assert.Equal(t, "Your invoice", msg.Header.Get("Subject"))
+	assert.Equal(t, "1.0", msg.Header.Get("Mime-Version"))
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	assert.NoError(t, err)
+	assert.Equal(t, "multipart/signed", mediaType)
+	assert.Equal(t, "application/pkcs7-signature", params["protocol"])
+	assert.Equal(t, "sha-256", params["micalg"])
+
+	// the signed entity is the exact content between the first two boundaries
+	body, _ := io.ReadAll(msg.Body)
+	delimiter := []byte("--" + params["boundary"])
+	sections := bytes.Split(body, delimiter)
+	assert.Len(t, sections, 4)
+	entity := bytes.TrimSuffix(bytes.TrimPrefix(sections[1], []byte("\r\n")), []byte("\r\n"))
+	assert.True(t, bytes.HasPrefix(entity, []byte("Content-Type: multipart/mixed;")))
+
+	signature, err := mail.ReadMessage(bytes.NewReader(bytes.TrimPrefix(sections[2], []byte("\r\n"))))
+	assert.NoError(t, err)
+	assert.Equal(t, "application/pkcs7-signature; name=smime.p7s", signature.Header.Get("Content-Type"))
+	der, err := io.ReadAll(base64.NewDecoder(base64.StdEncoding, signature.Body))
+	assert.NoError(t, err)
+
+	p7, err := pkcs7.Parse(der)
+	assert.NoError(t, err)
+	p7.Content = entity
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+	assert.NoError(t, p7.VerifyWithChain(roots))
+
+	p7.Content = bytes.Replace(entity, []byte("invoice"), []byte("receipt"), 1)
+	assert.Error(t, p7.VerifyWithChain(roots))
+}
+
+// TestEncrypter_TransformMIME tests the TransformMIME method of Encrypter
+func TestEncrypter_TransformMIME(t *testing.T) {
+	recipient, recipientKey := testCertificate(t, "customer@example.com")
+	sender, senderKey := testCertificate(t, "shop@example.com")
+
+	t.Run("Success - encrypt", func(t *testing.T) {
+		encrypter, err := NewEncrypter(recipient, sender)
+		assert.NoError(t, err)
+
+		encrypted, err := encrypter.TransformMIME(testMessage(t))
+		assert.NoError(t, err)
+		assert.NotContains(t, string(encrypted), "invoice attached")
+
+		msg, err := mail.ReadMessage(bytes.NewReader(encrypted))
+		assert.NoError(t, err)
+		assert.Equal(t, "Your invoice", msg.Header.Get("Subject"))
+		assert.Equal(t, "application/pkcs7-mime; smime-type=enveloped-data; name=smime.p7m", msg.Header.Get("Content-Type"))
+
+		der, err := io.ReadAll(base64.NewDecoder(base64.StdEncoding, msg.Body))
+		assert.NoError(t, err)
+		p7, err := pkcs7.Parse(der)
+		assert.NoError(t, err)
+
+		for _, r := range []struct {
+			cert *x509.Certificate
+			key  *rsa.PrivateKey
+		}{{recipient, recipientKey}, {sender, senderKey}} {
+			entity, err := p7.Decrypt(r.cert, r.key)
+			assert.NoError(t, err)
+
+			decrypted, err := goat.ParseEmailMessage(bytes.NewReader(entity))
+			assert.NoError(t, err)
+			assert.Equal(t, "Please find your invoice attached.", decrypted.PlainTextContent)
+			assert.Len(t, decrypted.Attachments, 1)
+		}
+	})
+
+	t.Run("Success - sign then encrypt", func(t *testing.T) {
+		signer, _ := NewSigner(sender, senderKey)
+		encrypter, _ := NewEncrypter(recipient)
+
+		signed, err := signer.TransformMIME(testMessage(t))
+		assert.NoError(t, err)
+		encrypted, err := encrypter.TransformMIME(signed)
+		assert.NoError(t, err)
+
+		msg, err := mail.ReadMessage(bytes.NewReader(encrypted))
+		assert.NoError(t, err)
+		der, _ := io.ReadAll(base64.NewDecoder(base64.StdEncoding, msg.Body))
+		p7, err := pkcs7.Parse(der)
+		assert.NoError(t, err)
+		entity, err := p7.Decrypt(recipient, recipientKey)
+		assert.NoError(t, err)
+		assert.True(t, bytes.HasPrefix(entity, []byte("Content-Type: multipart/signed;")))
+	})
+}
+
+// TestNewSigner tests the NewSigner function
+func TestNewSigner(t *testing.T) {
+	_, err := NewSigner(nil, nil)
+	assert.EqualError(t, err, "S/MIME signing requires a certificate and its private key")
+}
+
+// TestNewEncrypter tests the NewEncrypter function
+func TestNewEncrypter(t *testing.T) {
+	t.Run("no recipient", func(t *testing.T) {
+		_, err := NewEncrypter()
+		assert.EqualError(t, err, "S/MIME encryption requires at least one recipient certificate")
+	})
+
+	t.Run("unsupported key", func(t *testing.T) {
+		key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		template := &x509.Certificate{SerialNumber: big.NewInt(1), Subject: pkix.Name{CommonName: "ec@example.com"}, NotAfter: time.Now().Add(time.Hour)}
+		der, _ := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+		cert, _ := x509.ParseCertificate(der)
+
+		_, err := NewEncrypter(cert)
+		assert.EqualError(t, err, `recipient certificate "ec@example.com": unsupported public key type *ecdsa.PublicKey, only RSA is supported`)
+	})
+}
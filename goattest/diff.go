@@ -0,0 +1,289 @@
+package goattest
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// maxDifferences is the number of differences reported before the diff is truncated.
+const maxDifferences = 10
+
+// TextDiff returns a line diff between want and got, or an empty string when they are equal.
+// Line endings and trailing whitespace are normalized before comparing.
+func TextDiff(want, got string) string {
+	a, b := normalizeLines(want), normalizeLines(got)
+
+	var out []string
+	i, j := 0, 0
+	for _, op := range diffLines(a, b) {
+		switch op {
+		case '=':
+			i++
+			j++
+		case '-':
+			out = append(out, fmt.Sprintf("line %d: - %s", i+1, a[i]))
+			i++
+		case '+':
+			out = append(out, fmt.Sprintf("line %d: + %s", j+1, b[j]))
+			j++
+		}
+	}
+	return truncateDifferences(out)
+}
+
+// normalizeLines splits s into lines without trailing whitespace nor trailing empty lines.
+func normalizeLines(s string) []string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t\r")
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines returns the edit script turning a into b, as a sequence of '=', '-' and '+' operations,
+// computed from the longest common subsequence of lines.
+func diffLines(a, b []string) []byte {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var ops []byte
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, '=')
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, '-')
+			i++
+		default:
+			ops = append(ops, '+')
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, '-')
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, '+')
+	}
+	return ops
+}
+
+// HTMLDiff compares want and got as HTML documents and returns the differences, each prefixed by
+// the path of the element that changed (e.g. "html > body > div.content > p[2]"), or an empty string
+// when they are equivalent. Attribute order and whitespace between or within text nodes are ignored,
+// except inside <pre> and <textarea> elements where whitespace is rendered and compared exactly.
+func HTMLDiff(want, got string) string {
+	a, err := html.Parse(strings.NewReader(want))
+	if err != nil {
+		return fmt.Sprintf("invalid golden HTML: %v", err)
+	}
+	b, err := html.Parse(strings.NewReader(got))
+	if err != nil {
+		return fmt.Sprintf("invalid rendered HTML: %v", err)
+	}
+
+	var out []string
+	compareNodes(&out, "", a, b, false)
+	return truncateDifferences(out)
+}
+
+// preformatted lists the elements whose whitespace is rendered as is.
+var preformatted = map[string]bool{"pre": true, "textarea": true}
+
+// compareNodes appends the differences between the children of a and b, recursively.
+// Whitespace is compared exactly when pre is set, i.e. inside a preformatted element.
+func compareNodes(out *[]string, path string, a, b *html.Node, pre bool) {
+	ac, bc := significantChildren(a, pre), significantChildren(b, pre)
+	labels := siblingLabels(bc)
+
+	for i := 0; i < len(ac) || i < len(bc); i++ {
+		switch {
+		case i >= len(bc):
+			*out = append(*out, fmt.Sprintf("%s: removed %s", location(path), describe(ac[i])))
+		case i >= len(ac):
+			*out = append(*out, fmt.Sprintf("%s: added %s", location(path), describe(bc[i])))
+		default:
+			compareNode(out, path, labels[i], ac[i], bc[i], pre)
+		}
+	}
+}
+
+// compareNode appends the differences between a and b, recursively.
+func compareNode(out *[]string, parent, label string, a, b *html.Node, pre bool) {
+	if a.Type != b.Type || (a.Type == html.ElementNode && a.Data != b.Data) {
+		*out = append(*out, fmt.Sprintf("%s: %s changed to %s", location(parent), describe(a), describe(b)))
+		return
+	}
+
+	switch a.Type {
+	case html.TextNode, html.CommentNode:
+		at, bt := a.Data, b.Data
+		if !pre || a.Type == html.CommentNode {
+			at, bt = collapseSpaces(at), collapseSpaces(bt)
+		}
+		if at != bt {
+			*out = append(*out, fmt.Sprintf("%s: %s changed from %q to %q", location(parent), nodeKind(a), at, bt))
+		}
+	case html.ElementNode:
+		path := label
+		if parent != "" {
+			path = parent + " > " + label
+		}
+		compareAttributes(out, path, a, b)
+		compareNodes(out, path, a, b, pre || preformatted[a.Data])
+	default:
+		compareNodes(out, parent, a, b, pre)
+	}
+}
+
+// compareAttributes appends the attribute differences between the elements a and b.
+func compareAttributes(out *[]string, path string, a, b *html.Node) {
+	as, bs := attributes(a), attributes(b)
+
+	keys := make([]string, 0, len(as)+len(bs))
+	for k := range as {
+		keys = append(keys, k)
+	}
+	for k := range bs {
+		if _, ok := as[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		av, inA := as[k]
+		bv, inB := bs[k]
+		switch {
+		case !inB:
+			*out = append(*out, fmt.Sprintf("%s: removed attribute %s=%q", path, k, av))
+		case !inA:
+			*out = append(*out, fmt.Sprintf("%s: added attribute %s=%q", path, k, bv))
+		case av != bv:
+			*out = append(*out, fmt.Sprintf("%s: attribute %s changed from %q to %q", path, k, av, bv))
+		}
+	}
+}
+
+// significantChildren returns the children of n, whitespace-only text nodes excluded unless pre is set.
+func significantChildren(n *html.Node, pre bool) []*html.Node {
+	var children []*html.Node
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if !pre && c.Type == html.TextNode && strings.TrimSpace(c.Data) == "" {
+			continue
+		}
+		children = append(children, c)
+	}
+	return children
+}
+
+// siblingLabels returns the path label of each element of nodes, e.g. "div#main", "p.intro" or "td[2]",
+// the index being set when several siblings share the same tag.
+func siblingLabels(nodes []*html.Node) []string {
+	counts := make(map[string]int)
+	for _, n := range nodes {
+		if n.Type == html.ElementNode {
+			counts[n.Data]++
+		}
+	}
+
+	labels := make([]string, len(nodes))
+	seen := make(map[string]int)
+	for i, n := range nodes {
+		if n.Type != html.ElementNode {
+			continue
+		}
+		seen[n.Data]++
+		label := n.Data
+		attrs := attributes(n)
+		if id := attrs["id"]; id != "" {
+			label += "#" + id
+		} else if class := strings.Fields(attrs["class"]); len(class) > 0 {
+			label += "." + strings.Join(class, ".")
+		}
+		if counts[n.Data] > 1 {
+			label += fmt.Sprintf("[%d]", seen[n.Data])
+		}
+		labels[i] = label
+	}
+	return labels
+}
+
+// attributes returns the attributes of n indexed by name.
+func attributes(n *html.Node) map[string]string {
+	attrs := make(map[string]string, len(n.Attr))
+	for _, a := range n.Attr {
+		attrs[a.Key] = strings.TrimSpace(a.Val)
+	}
+	return attrs
+}
+
+// describe returns a short description of n used in the differences.
+func describe(n *html.Node) string {
+	switch n.Type {
+	case html.ElementNode:
+		return "<" + n.Data + ">"
+	case html.TextNode, html.CommentNode:
+		return fmt.Sprintf("%s %q", nodeKind(n), collapseSpaces(n.Data))
+	default:
+		return nodeKind(n)
+	}
+}
+
+// nodeKind returns the human-readable kind of n.
+func nodeKind(n *html.Node) string {
+	switch n.Type {
+	case html.TextNode:
+		return "text"
+	case html.CommentNode:
+		return "comment"
+	case html.DoctypeNode:
+		return "doctype"
+	case html.ElementNode:
+		return "element"
+	default:
+		return "node"
+	}
+}
+
+// location returns the path of an element, or "document" for the root.
+func location(path string) string {
+	if path == "" {
+		return "document"
+	}
+	return path
+}
+
+// collapseSpaces trims s and replaces every sequence of whitespace with a single space.
+func collapseSpaces(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// truncateDifferences joins the differences, keeping at most maxDifferences of them.
+func truncateDifferences(diffs []string) string {
+	if len(diffs) > maxDifferences {
+		diffs = append(diffs[:maxDifferences], fmt.Sprintf("... and %d more differences", len(diffs)-maxDifferences))
+	}
+	return strings.Join(diffs, "\n")
+}
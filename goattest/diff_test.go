@@ -0,0 +1,72 @@
+package goattest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestTextDiff tests the TextDiff function
+func TestTextDiff(t *testing.T) {
+	t.Run("equal after normalization", func(t *testing.T) {
+		assert.Equal(t, "", TextDiff("Hello\r\nWorld  \n\n", "Hello\nWorld"))
+	})
+
+	t.Run("changed line", func(t *testing.T) {
+		diff := TextDiff("Hello\nJohn\nBye", "Hello\nJane\nBye")
+		assert.Equal(t, "line 2: - John\nline 2: + Jane", diff)
+	})
+
+	t.Run("added and removed lines", func(t *testing.T) {
+		diff := TextDiff("a\nb\nc", "a\nc\nd")
+		assert.Equal(t, "line 2: - b\nline 3: + d", diff)
+	})
+
+	t.Run("truncated differences", func(t *testing.T) {
+		diff := TextDiff("", "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12")
+		assert.Contains(t, diff, "line 10: + 10")
+		assert.Contains(t, diff, "... and 2 more differences")
+		assert.NotContains(t, diff, "line 11")
+	})
+}
+
+// TestHTMLDiff tests the HTMLDiff function
+func TestHTMLDiff(t *testing.T) {
+	t.Run("equal after normalization", func(t *testing.T) {
+		want := `<div class="a" id="x">
+			<p>Hello   World</p>
+		</div>`
+		got := `<div id="x" class="a"><p>Hello World</p></div>`
+		assert.Equal(t, "", HTMLDiff(want, got))
+	})
+
+	t.Run("changed text", func(t *testing.T) {
+		want := `<div class="content"><p>One</p><p>Two</p></div>`
+		got := `<div class="content"><p>One</p><p>Deux</p></div>`
+		assert.Equal(t, `html > body > div.content > p[2]: text changed from "Two" to "Deux"`, HTMLDiff(want, got))
+	})
+
+	t.Run("changed attributes", func(t *testing.T) {
+		want := `<a id="cta" href="https://a.example" class="btn">Go</a>`
+		got := `<a id="cta" href="https://b.example" target="_blank">Go</a>`
+		assert.Equal(t, "html > body > a#cta: removed attribute class=\"btn\"\n"+
+			"html > body > a#cta: attribute href changed from \"https://a.example\" to \"https://b.example\"\n"+
+			"html > body > a#cta: added attribute target=\"_blank\"", HTMLDiff(want, got))
+	})
+
+	t.Run("whitespace inside pre and textarea", func(t *testing.T) {
+		assert.Equal(t, "", HTMLDiff("<pre>a  b\n c</pre>", "<pre>a  b\n c</pre>"))
+		assert.Equal(t, `html > body > pre: text changed from "a  b\n c" to "a b c"`,
+			HTMLDiff("<pre>a  b\n c</pre>", "<pre>a b c</pre>"))
+		assert.Equal(t, `html > body > pre > code: text changed from "x\n  y" to "x\ny"`,
+			HTMLDiff("<pre><code>x\n  y</code></pre>", "<pre><code>x\ny</code></pre>"))
+		assert.Equal(t, `html > body > textarea: text changed from "  indented" to "indented"`,
+			HTMLDiff("<textarea>  indented</textarea>", "<textarea>indented</textarea>"))
+	})
+
+	t.Run("changed, added and removed elements", func(t *testing.T) {
+		assert.Equal(t, "html > body: <p> changed to <div>", HTMLDiff(`<p>x</p>`, `<div>x</div>`))
+		assert.Equal(t, "html > body > ul: added <li>", HTMLDiff(`<ul><li>a</li></ul>`, `<ul><li>a</li><li>b</li></ul>`))
+		assert.Equal(t, "html > body > ul: removed <li>", HTMLDiff(`<ul><li>a</li><li>b</li></ul>`, `<ul><li>a</li></ul>`))
+	})
+}
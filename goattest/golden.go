@@ -0,0 +1,154 @@
+// Package goattest provides golden-file snapshot testing helpers for goat templates.
+//
+// Rendered bodies are compared against golden files; run the tests with the GOATTEST_UPDATE
+// environment variable set to (re)generate them after an intended change:
+//
+//	func TestWelcomeEmail(t *testing.T) {
+//		tmpl := goat.Template{Name: "welcome", ContentRaw: welcomeHTML, Data: data}
+//		goattest.AssertTemplate(t, tmpl, "testdata/welcome.html")
+//	}
+//
+//	GOATTEST_UPDATE=1 go test ./...
+//
+// Golden files ending in .html are compared structurally, reporting which element changed;
+// other files are compared line by line. Both comparisons ignore insignificant whitespace; the
+// whitespace inside <pre> and <textarea> elements is significant and compared exactly.
+package goattest
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/Zapharaos/go-at"
+)
+
+// UpdateEnv is the environment variable which, set to a true value such as 1, makes the assertions
+// write the golden files instead of comparing against them. An environment variable rather than a flag
+// is used so that it applies to every package of a module, whether it imports goattest or not.
+const UpdateEnv = "GOATTEST_UPDATE"
+
+// updating reports whether the golden files are being updated.
+func updating() bool {
+	update, _ := strconv.ParseBool(os.Getenv(UpdateEnv))
+	return update
+}
+
+// AssertGolden compares got against the content of the golden file and fails the test on mismatch.
+// With GOATTEST_UPDATE set, the golden file is written with got instead.
+func AssertGolden(t testing.TB, golden string, got string) {
+	t.Helper()
+
+	if updating() {
+		if err := os.MkdirAll(filepath.Dir(golden), 0o755); err != nil {
+			t.Fatalf("goattest: %v", err)
+		}
+		if err := os.WriteFile(golden, []byte(got), 0o644); err != nil {
+			t.Fatalf("goattest: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(golden)
+	if errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("goattest: golden file %s does not exist, run the test with GOATTEST_UPDATE=1 to create it", golden)
+	}
+	if err != nil {
+		t.Fatalf("goattest: %v", err)
+	}
+
+	var diff string
+	if filepath.Ext(golden) == ".html" {
+		diff = HTMLDiff(string(want), got)
+	} else {
+		diff = TextDiff(string(want), got)
+	}
+	if diff != "" {
+		t.Errorf("goattest: rendered output does not match %s (run the test with GOATTEST_UPDATE=1 if the change is intended):\n%s", golden, diff)
+	}
+}
+
+// AssertTemplate renders the template and compares the result against the golden file.
+func AssertTemplate(t testing.TB, tmpl goat.Template, golden string) {
+	t.Helper()
+
+	got, err := tmpl.Render()
+	if err != nil {
+		t.Fatalf("goattest: rendering template %q: %v", tmpl.Name, err)
+	}
+	AssertGolden(t, golden, got)
+}
+
+// AssertTemplateSet renders every template of the set with each of its fixtures and compares the
+// bodies against the golden files of dir, named <template>[.<fixture>].html and <template>[.<fixture>].txt.
+// Templates without fixture are rendered with nil data. Each template and fixture runs as a subtest.
+// A body is compared whenever it is not empty or its golden file exists, so a body rendering empty
+// where a golden file expects content fails the test.
+func AssertTemplateSet(t *testing.T, set *goat.TemplateSet, dir string) {
+	t.Helper()
+
+	for _, name := range set.Names() {
+		entry, _ := set.Lookup(name)
+
+		fixtures := entry.FixtureNames()
+		if len(fixtures) == 0 {
+			fixtures = []string{""}
+		}
+
+		for _, fixture := range fixtures {
+			base := name
+			if fixture != "" {
+				base += "." + fixture
+			}
+
+			t.Run(base, func(t *testing.T) {
+				var data interface{}
+				if _, ok := entry.Fixtures[fixture]; ok {
+					var err error
+					if data, err = entry.Fixture(fixture); err != nil {
+						t.Fatalf("goattest: %v", err)
+					}
+				}
+
+				plainText, html, err := entry.Render(data)
+				if err != nil {
+					t.Fatalf("goattest: rendering template %q: %v", name, err)
+				}
+
+				golden := filepath.Join(dir, filepath.FromSlash(base))
+				assertBody(t, golden+".html", html)
+				assertBody(t, golden+".txt", plainText)
+			})
+		}
+	}
+}
+
+// assertBody compares a rendered body against its golden file, unless the body is empty and has no golden file.
+// With GOATTEST_UPDATE set, the golden file of an empty body is removed.
+func assertBody(t testing.TB, golden string, got string) {
+	t.Helper()
+
+	if got != "" {
+		AssertGolden(t, golden, got)
+		return
+	}
+
+	if updating() {
+		if err := os.Remove(golden); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			t.Fatalf("goattest: %v", err)
+		}
+		return
+	}
+
+	_, err := os.Stat(golden)
+	if errors.Is(err, fs.ErrNotExist) {
+		return
+	}
+	if err != nil {
+		t.Fatalf("goattest: %v", err)
+	}
+	t.Errorf("goattest: rendered body is empty but golden file %s exists (run the test with GOATTEST_UPDATE=1 if the change is intended)", golden)
+}
@@ -0,0 +1,161 @@
+package goattest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"testing/fstest"
+
+	"github.com/Zapharaos/go-at"
+	"github.com/stretchr/testify/assert"
+)
+
+// recorder is a testing.TB recording failures instead of failing the test
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Fatalf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+// setUpdate sets the GOATTEST_UPDATE environment variable for the duration of the test
+func setUpdate(t *testing.T, value bool) {
+	t.Setenv(UpdateEnv, strconv.FormatBool(value))
+}
+
+// TestUpdating tests the parsing of the GOATTEST_UPDATE environment variable
+func TestUpdating(t *testing.T) {
+	for value, expected := range map[string]bool{"": false, "0": false, "false": false, "1": true, "true": true, "yes": false} {
+		t.Setenv(UpdateEnv, value)
+		assert.Equal(t, expected, updating(), "GOATTEST_UPDATE=%q", value)
+	}
+}
+
+// TestAssertGolden tests the AssertGolden function
+func TestAssertGolden(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		AssertGolden(t, "testdata/welcome.html", `<p class="greeting">Hello <strong>John</strong></p>`)
+		AssertGolden(t, "testdata/welcome.txt", "Hello John")
+	})
+
+	t.Run("Failure - mismatch", func(t *testing.T) {
+		r := &recorder{TB: t}
+		AssertGolden(r, "testdata/welcome.html", `<p class="greeting">Hello <strong>Jane</strong></p>`)
+		assert.Len(t, r.errors, 1)
+		assert.Contains(t, r.errors[0], `html > body > p.greeting > strong: text changed from "John" to "Jane"`)
+	})
+
+	t.Run("Failure - missing golden file", func(t *testing.T) {
+		r := &recorder{TB: t}
+		AssertGolden(r, filepath.Join(t.TempDir(), "missing.txt"), "Hello")
+		assert.NotEmpty(t, r.errors)
+		assert.Contains(t, r.errors[0], "GOATTEST_UPDATE=1")
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		setUpdate(t, true)
+		golden := filepath.Join(t.TempDir(), "nested", "updated.txt")
+
+		AssertGolden(t, golden, "Updated")
+		content, err := os.ReadFile(golden)
+		assert.NoError(t, err)
+		assert.Equal(t, "Updated", string(content))
+	})
+}
+
+// TestAssertTemplate tests the AssertTemplate function
+func TestAssertTemplate(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		tmpl := goat.Template{
+			Name:       "welcome",
+			ContentRaw: `<p class="greeting">Hello <strong>{{.Name}}</strong></p>`,
+			Data:       map[string]string{"Name": "John"},
+		}
+		AssertTemplate(t, tmpl, "testdata/welcome.html")
+	})
+
+	t.Run("Failure - render error", func(t *testing.T) {
+		r := &recorder{TB: t}
+		AssertTemplate(r, goat.Template{Name: "broken", ContentRaw: "{{.Name"}, "testdata/welcome.html")
+		assert.NotEmpty(t, r.errors)
+	})
+}
+
+// TestAssertTemplateSet tests the AssertTemplateSet function
+func TestAssertTemplateSet(t *testing.T) {
+	set, err := goat.LoadTemplateSet(fstest.MapFS{
+		"welcome.html":        {Data: []byte(`<p class="greeting">Hello <strong>{{.Name}}</strong></p>`)},
+		"welcome.txt":         {Data: []byte("Hello {{.Name}}")},
+		"welcome.json":        {Data: []byte(`{"Name": "John"}`)},
+		"welcome.french.yaml": {Data: []byte("Name: Jean\n")},
+		"static.txt":          {Data: []byte("No data needed")},
+	})
+	assert.NoError(t, err)
+
+	t.Run("Update", func(t *testing.T) {
+		setUpdate(t, true)
+		dir := t.TempDir()
+
+		AssertTemplateSet(t, set, dir)
+		for _, file := range []string{"welcome.html", "welcome.txt", "welcome.french.html", "welcome.french.txt", "static.txt"} {
+			assert.FileExists(t, filepath.Join(dir, file))
+		}
+		assert.NoFileExists(t, filepath.Join(dir, "static.html"))
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		AssertTemplateSet(t, set, "testdata/set")
+	})
+
+	t.Run("Update - removes the golden file of an empty body", func(t *testing.T) {
+		setUpdate(t, true)
+		dir := t.TempDir()
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "static.html"), []byte("<p>Old</p>"), 0o644))
+
+		AssertTemplateSet(t, set, dir)
+		assert.NoFileExists(t, filepath.Join(dir, "static.html"))
+	})
+}
+
+// TestAssertBody tests the comparison of the bodies of a template set
+func TestAssertBody(t *testing.T) {
+	dir := t.TempDir()
+	golden := filepath.Join(dir, "welcome.html")
+	assert.NoError(t, os.WriteFile(golden, []byte("<p>Hello</p>"), 0o644))
+
+	t.Run("Success - matching body", func(t *testing.T) {
+		r := &recorder{TB: t}
+		assertBody(r, golden, "<p>Hello</p>")
+		assert.Empty(t, r.errors)
+	})
+
+	t.Run("Success - empty body without golden file", func(t *testing.T) {
+		r := &recorder{TB: t}
+		assertBody(r, filepath.Join(dir, "welcome.txt"), "")
+		assert.Empty(t, r.errors)
+	})
+
+	t.Run("Failure - empty body with golden file", func(t *testing.T) {
+		r := &recorder{TB: t}
+		assertBody(r, golden, "")
+		assert.Len(t, r.errors, 1)
+		assert.Contains(t, r.errors[0], "rendered body is empty")
+	})
+
+	t.Run("Failure - body without golden file", func(t *testing.T) {
+		r := &recorder{TB: t}
+		assertBody(r, filepath.Join(dir, "welcome.txt"), "Hello")
+		assert.NotEmpty(t, r.errors)
+		assert.Contains(t, r.errors[0], "does not exist")
+	})
+}
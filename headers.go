@@ -0,0 +1,125 @@
+package goat
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/textproto"
+	"sort"
+	"unicode/utf8"
+)
+
+// HeaderError reports a custom header rejected by a HeaderPolicy.
+type HeaderError struct {
+	Header string // Name of the rejected header, as set on the message
+	Reason string
+}
+
+// Error implements the error interface.
+func (e *HeaderError) Error() string {
+	return fmt.Sprintf("header %q: %s", e.Header, e.Reason)
+}
+
+// HeaderPolicy decides which custom headers a message may carry and how their values are encoded.
+//
+// Header names must be printable ASCII without colon, and values must not contain line breaks
+// or control characters, which would allow injecting headers. Non-ASCII values are RFC 2047 encoded.
+type HeaderPolicy struct {
+	Reserved map[string]bool // Canonical names (see textproto.CanonicalMIMEHeaderKey) that cannot be set
+}
+
+var (
+	// DefaultHeaderPolicy reserves the headers set from EmailMessage fields or by the MIME structure.
+	DefaultHeaderPolicy = HeaderPolicy{Reserved: reservedHeaders}
+
+	// SendgridHeaderPolicy also reserves the headers SendGrid rejects in the v3 Mail Send API.
+	SendgridHeaderPolicy = HeaderPolicy{Reserved: withReserved(reservedHeaders,
+		"X-Sg-Id", "X-Sg-Eid", "Received", "Dkim-Signature")}
+
+	// BrevoHeaderPolicy also reserves the headers Brevo sets itself when relaying a message.
+	BrevoHeaderPolicy = HeaderPolicy{Reserved: withReserved(reservedHeaders,
+		"Received", "Dkim-Signature", "Return-Path", "X-Mailin-Eid")}
+)
+
+// withReserved returns a copy of reserved with the given names added.
+func withReserved(reserved map[string]bool, names ...string) map[string]bool {
+	all := make(map[string]bool, len(reserved)+len(names))
+	for name := range reserved {
+		all[name] = true
+	}
+	for _, name := range names {
+		all[textproto.CanonicalMIMEHeaderKey(name)] = true
+	}
+	return all
+}
+
+// Check returns a *HeaderError if the header cannot be set under the policy.
+func (p HeaderPolicy) Check(key, value string) error {
+	if !isHeaderName(key) {
+		return &HeaderError{Header: key, Reason: "invalid name"}
+	}
+	if err := checkHeaderValue(key, value); err != nil {
+		return err
+	}
+	if p.Reserved[textproto.CanonicalMIMEHeaderKey(key)] {
+		return &HeaderError{Header: key, Reason: "reserved header cannot be set"}
+	}
+	return nil
+}
+
+// checkHeaderValue returns a *HeaderError if value contains a line break, a control character
+// or invalid UTF-8, which applies to the headers set from EmailMessage fields as well.
+func checkHeaderValue(key, value string) error {
+	for _, r := range value {
+		if r == '\r' || r == '\n' {
+			return &HeaderError{Header: key, Reason: "value contains a line break"}
+		}
+		if (r < ' ' && r != '\t') || r == 0x7f {
+			return &HeaderError{Header: key, Reason: "value contains a control character"}
+		}
+	}
+	if !utf8.ValidString(value) {
+		return &HeaderError{Header: key, Reason: "value is not valid UTF-8"}
+	}
+	return nil
+}
+
+// Sanitize checks every header against the policy and returns them with non-ASCII values RFC 2047 encoded.
+// Rejected headers are reported as *HeaderError values joined in the returned error.
+func (p HeaderPolicy) Sanitize(headers map[string]string) (map[string]string, error) {
+	if len(headers) == 0 {
+		return nil, nil
+	}
+
+	keys := make([]string, 0, len(headers))
+	for key := range headers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var errs []error
+	sanitized := make(map[string]string, len(headers))
+	for _, key := range keys {
+		value := headers[key]
+		if err := p.Check(key, value); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		sanitized[key] = encodeHeaderValue(value)
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return sanitized, nil
+}
+
+// encodeHeaderValue RFC 2047 encodes value if it contains non-ASCII characters.
+func encodeHeaderValue(value string) string {
+	for i := 0; i < len(value); i++ {
+		if value[i] >= utf8.RuneSelf {
+			return mime.QEncoding.Encode("utf-8", value)
+		}
+	}
+	return value
+}
@@ -0,0 +1,80 @@
+package goat
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestHeaderPolicy_Check tests the Check method of HeaderPolicy
+func TestHeaderPolicy_Check(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy HeaderPolicy
+		key    string
+		value  string
+		reason string
+	}{
+		{"valid header", DefaultHeaderPolicy, "X-Campaign", "spring", ""},
+		{"tab allowed", DefaultHeaderPolicy, "X-Campaign", "a\tb", ""},
+		{"invalid name", DefaultHeaderPolicy, "X Campaign", "spring", "invalid name"},
+		{"CRLF injection", DefaultHeaderPolicy, "X-Campaign", "a\r\nBcc: victim@example.com", "value contains a line break"},
+		{"bare LF", DefaultHeaderPolicy, "X-Campaign", "a\nb", "value contains a line break"},
+		{"control character", DefaultHeaderPolicy, "X-Campaign", "a\x00b", "value contains a control character"},
+		{"invalid UTF-8", DefaultHeaderPolicy, "X-Campaign", "a\xffb", "value is not valid UTF-8"},
+		{"reserved header", DefaultHeaderPolicy, "from", "attacker@example.com", "reserved header cannot be set"},
+		{"SendGrid reserved header", SendgridHeaderPolicy, "X-SG-EID", "id", "reserved header cannot be set"},
+		{"Brevo reserved header", BrevoHeaderPolicy, "Return-Path", "bounce@example.com", "reserved header cannot be set"},
+		{"provider header not reserved by default", DefaultHeaderPolicy, "X-SG-EID", "id", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Check(tt.key, tt.value)
+			if tt.reason == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.Equal(t, &HeaderError{Header: tt.key, Reason: tt.reason}, err)
+		})
+	}
+}
+
+// TestHeaderPolicy_Sanitize tests the Sanitize method of HeaderPolicy
+func TestHeaderPolicy_Sanitize(t *testing.T) {
+	t.Run("Success - encodes non-ASCII values", func(t *testing.T) {
+		headers, err := DefaultHeaderPolicy.Sanitize(map[string]string{
+			"X-Campaign": "spring",
+			"X-Greeting": "Café crème",
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{
+			"X-Campaign": "spring",
+			"X-Greeting": "=?utf-8?q?Caf=C3=A9_cr=C3=A8me?=",
+		}, headers)
+	})
+
+	t.Run("Success - no header", func(t *testing.T) {
+		headers, err := DefaultHeaderPolicy.Sanitize(nil)
+		assert.NoError(t, err)
+		assert.Nil(t, headers)
+	})
+
+	t.Run("Failure - reports every rejected header", func(t *testing.T) {
+		headers, err := SendgridHeaderPolicy.Sanitize(map[string]string{
+			"X-Campaign":     "spring",
+			"X-Sg-Id":        "id",
+			"X-Injected":     "a\r\nBcc: victim@example.com",
+			"Dkim-Signature": "v=1",
+		})
+		assert.Nil(t, headers)
+		assert.Equal(t, `header "Dkim-Signature": reserved header cannot be set
+header "X-Injected": value contains a line break
+header "X-Sg-Id": reserved header cannot be set`, err.Error())
+
+		var headerErr *HeaderError
+		assert.True(t, errors.As(err, &headerErr))
+		assert.Equal(t, "Dkim-Signature", headerErr.Header)
+	})
+}
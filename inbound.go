@@ -0,0 +1,101 @@
+package goat
+
+import (
+	"bytes"
+	"fmt"
+	"net/mail"
+	"strings"
+)
+
+// maxInboundBodySize is the maximum size of an inbound email request body, above the 30MB accepted by the providers.
+const maxInboundBodySize = 32 << 20
+
+// InboundEmail is an email received through a provider inbound parsing webhook.
+type InboundEmail struct {
+	Provider    string // ProviderSendgrid or ProviderBrevo
+	From        Address
+	To          []Address
+	Cc          []Address
+	ReplyTo     *Address
+	Recipients  []string // Envelope recipients, the addresses the email was delivered to
+	Subject     string
+	Text        string
+	HTML        string
+	Attachments []Attachment
+	Headers     mail.Header
+	// Threading headers, message IDs kept with their chevrons
+	MessageID  string
+	InReplyTo  string
+	References []string
+	SpamScore  float64
+}
+
+// setHeader sets the addresses, subject, threading fields and headers of the email from its header.
+// Inbound emails come from anywhere, so malformed addresses are skipped rather than rejected.
+func (e *InboundEmail) setHeader(header mail.Header) {
+	e.Headers = header
+	parser := &mail.AddressParser{WordDecoder: wordDecoder}
+
+	if v := header.Get("From"); v != "" {
+		if from, err := parser.Parse(v); err == nil {
+			e.From = Address{Name: from.Name, Address: from.Address}
+		} else {
+			e.From = Address{Address: strings.TrimSpace(v)}
+		}
+	}
+	if v := header.Get("Reply-To"); v != "" {
+		if replyTo, err := parser.Parse(v); err == nil {
+			e.ReplyTo = &Address{Name: replyTo.Name, Address: replyTo.Address}
+		}
+	}
+	e.To = parseInboundAddresses(parser, header.Get("To"))
+	e.Cc = parseInboundAddresses(parser, header.Get("Cc"))
+
+	if subject, err := wordDecoder.DecodeHeader(header.Get("Subject")); err == nil {
+		e.Subject = subject
+	} else {
+		e.Subject = header.Get("Subject")
+	}
+
+	e.MessageID = strings.TrimSpace(header.Get("Message-Id"))
+	if inReplyTo := strings.Fields(header.Get("In-Reply-To")); len(inReplyTo) > 0 {
+		e.InReplyTo = inReplyTo[0]
+	}
+	e.References = strings.Fields(header.Get("References"))
+}
+
+// parseInboundAddresses parses an address list header, or returns nil when it is empty or malformed.
+func parseInboundAddresses(parser *mail.AddressParser, value string) []Address {
+	if strings.TrimSpace(value) == "" {
+		return nil
+	}
+	list, err := parser.ParseList(value)
+	if err != nil {
+		return nil
+	}
+	addresses := make([]Address, 0, len(list))
+	for _, a := range list {
+		addresses = append(addresses, Address{Name: a.Name, Address: a.Address})
+	}
+	return addresses
+}
+
+// parseInboundRaw reads a raw RFC 5322 email into an InboundEmail, using ParseEmailMessage for its content.
+func parseInboundRaw(raw []byte) (*InboundEmail, error) {
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("reading message: %w", err)
+	}
+	parsed, err := ParseEmailMessage(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+
+	e := &InboundEmail{
+		Text:        parsed.PlainTextContent,
+		HTML:        parsed.HTMLContent,
+		Attachments: parsed.Attachments,
+	}
+	e.setHeader(msg.Header)
+	return e, nil
+}
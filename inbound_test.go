@@ -0,0 +1,87 @@
+package goat
+
+import (
+	"net/mail"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const inboundRawEmail = "From: =?UTF-8?Q?Ren=C3=A9e?= <renee@example.com>\r\n" +
+	"To: Support <support@example.com>, tickets@example.com\r\n" +
+	"Cc: boss@example.com\r\n" +
+	"Reply-To: renee.perso@example.com\r\n" +
+	"Subject: Re: Your order =?UTF-8?Q?n=C2=B042?=\r\n" +
+	"Message-ID: <reply-1@mail.example.com>\r\n" +
+	"In-Reply-To: <order-42@example.com>\r\n" +
+	"References: <welcome-1@example.com>\r\n <order-42@example.com>\r\n" +
+	"MIME-Version: 1.0\r\n" +
+	"Content-Type: multipart/mixed; boundary=b1\r\n" +
+	"\r\n" +
+	"--b1\r\n" +
+	"Content-Type: text/plain; charset=utf-8\r\n" +
+	"\r\n" +
+	"Where is my parcel?\r\n" +
+	"--b1\r\n" +
+	"Content-Type: application/pdf; name=invoice.pdf\r\n" +
+	"Content-Disposition: attachment; filename=invoice.pdf\r\n" +
+	"Content-Transfer-Encoding: base64\r\n" +
+	"\r\n" +
+	"JVBERi0=\r\n" +
+	"--b1--\r\n"
+
+// TestInboundEmailSetHeader tests the setHeader method of InboundEmail
+func TestInboundEmailSetHeader(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		msg, err := mail.ReadMessage(strings.NewReader(inboundRawEmail))
+		assert.NoError(t, err)
+
+		email := &InboundEmail{}
+		email.setHeader(msg.Header)
+		assert.Equal(t, Address{Name: "Renée", Address: "renee@example.com"}, email.From)
+		assert.Equal(t, []Address{{Name: "Support", Address: "support@example.com"}, {Address: "tickets@example.com"}}, email.To)
+		assert.Equal(t, []Address{{Address: "boss@example.com"}}, email.Cc)
+		assert.Equal(t, &Address{Address: "renee.perso@example.com"}, email.ReplyTo)
+		assert.Equal(t, "Re: Your order n°42", email.Subject)
+		assert.Equal(t, "<reply-1@mail.example.com>", email.MessageID)
+		assert.Equal(t, "<order-42@example.com>", email.InReplyTo)
+		assert.Equal(t, []string{"<welcome-1@example.com>", "<order-42@example.com>"}, email.References)
+		assert.Equal(t, "1.0", email.Headers.Get("Mime-Version"))
+	})
+
+	t.Run("Success - malformed addresses", func(t *testing.T) {
+		email := &InboundEmail{}
+		email.setHeader(mail.Header{
+			"From": {"not an address"},
+			"To":   {"<broken"},
+		})
+		assert.Equal(t, Address{Address: "not an address"}, email.From)
+		assert.Nil(t, email.To)
+		assert.Nil(t, email.ReplyTo)
+		assert.Empty(t, email.References)
+	})
+}
+
+// TestParseInboundRaw tests the parseInboundRaw function
+func TestParseInboundRaw(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		email, err := parseInboundRaw([]byte(inboundRawEmail))
+		assert.NoError(t, err)
+		assert.Equal(t, "renee@example.com", email.From.Address)
+		assert.Len(t, email.To, 2)
+		assert.Equal(t, "Where is my parcel?", email.Text)
+		assert.Empty(t, email.HTML)
+		assert.Equal(t, []Attachment{{Filename: "invoice.pdf", ContentType: "application/pdf", Content: []byte("%PDF-")}}, email.Attachments)
+	})
+
+	t.Run("Failure - invalid message", func(t *testing.T) {
+		_, err := parseInboundRaw([]byte("not a message"))
+		assert.ErrorContains(t, err, "reading message")
+	})
+
+	t.Run("Failure - invalid multipart", func(t *testing.T) {
+		_, err := parseInboundRaw([]byte("From: a@example.com\r\nContent-Type: multipart/mixed\r\n\r\nbody"))
+		assert.EqualError(t, err, "multipart/mixed part without boundary")
+	})
+}
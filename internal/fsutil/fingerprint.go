@@ -0,0 +1,26 @@
+// Package fsutil holds the file system helpers shared by goat and its subpackages.
+package fsutil
+
+import (
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+)
+
+// Fingerprint returns a hash of the paths, sizes and modification times of the files of fsys,
+// which changes whenever a file is added, removed or modified.
+func Fingerprint(fsys fs.FS) (uint64, error) {
+	hash := fnv.New64a()
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintf(hash, "%s|%d|%d\n", p, info.Size(), info.ModTime().UnixNano())
+		return nil
+	})
+	return hash.Sum64(), err
+}
@@ -0,0 +1,32 @@
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestFingerprint tests that the fingerprint of a directory changes with its files
+func TestFingerprint(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "welcome.txt"), []byte("Hello"), 0o600))
+	fsys := os.DirFS(dir)
+
+	first, err := Fingerprint(fsys)
+	assert.NoError(t, err)
+	again, err := Fingerprint(fsys)
+	assert.NoError(t, err)
+	assert.Equal(t, first, again)
+
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "welcome.html"), []byte("<p>Hello</p>"), 0o600))
+	added, err := Fingerprint(fsys)
+	assert.NoError(t, err)
+	assert.NotEqual(t, first, added)
+
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "welcome.txt"), []byte("Hello again"), 0o600))
+	modified, err := Fingerprint(fsys)
+	assert.NoError(t, err)
+	assert.NotEqual(t, added, modified)
+}
@@ -0,0 +1,464 @@
+package goat
+
+import (
+	"fmt"
+	"io/fs"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"golang.org/x/net/html"
+)
+
+// Severity is the severity of a Diagnostic.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Lint rules reported in Diagnostic.Rule.
+const (
+	RuleSyntax            = "syntax"
+	RuleUndefinedFunction = "undefined-function"
+	RuleUnknownField      = "unknown-field"
+	RuleUnclosedTag       = "unclosed-tag"
+	RuleImageAlt          = "image-alt"
+	RuleEmptyHref         = "empty-href"
+	RuleMissingText       = "missing-text"
+)
+
+// builtinFuncs lists the functions predefined by text/template.
+var builtinFuncs = map[string]bool{
+	"and": true, "call": true, "html": true, "index": true, "slice": true, "js": true, "len": true,
+	"not": true, "or": true, "print": true, "printf": true, "println": true, "urlquery": true,
+	"eq": true, "ge": true, "gt": true, "le": true, "lt": true, "ne": true,
+}
+
+// voidElements lists the HTML elements without closing tag.
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true, "img": true,
+	"input": true, "link": true, "meta": true, "source": true, "track": true, "wbr": true,
+}
+
+// optionalEndElements lists the HTML elements whose closing tag may be omitted.
+var optionalEndElements = map[string]bool{
+	"html": true, "head": true, "body": true, "p": true, "li": true, "dt": true, "dd": true,
+	"option": true, "thead": true, "tbody": true, "tfoot": true, "tr": true, "td": true, "th": true,
+	"colgroup": true, "caption": true,
+}
+
+// Diagnostic is a problem found by the template linter.
+type Diagnostic struct {
+	File     string   `json:"file"`
+	Line     int      `json:"line"` // 1-based, 0 when the problem concerns the whole file
+	Severity Severity `json:"severity"`
+	Rule     string   `json:"rule"`
+	Message  string   `json:"message"`
+}
+
+// String formats the diagnostic as "file:line: severity: message (rule)".
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s:%d: %s: %s (%s)", d.File, d.Line, d.Severity, d.Message, d.Rule)
+}
+
+// LintOptions configures the template linter.
+type LintOptions struct {
+	Funcs     template.FuncMap        // Functions available besides the built-in and globally registered ones
+	DataType  reflect.Type            // Type of the data of LintTemplate, nil to skip field checks
+	DataTypes map[string]reflect.Type // Types of the data of LintTemplateFS templates, by template name
+}
+
+// LintTemplate parses a template source and reports syntax errors, calls to undefined functions and,
+// when opts.DataType is set, references to fields that do not exist on it.
+// HTML checks (unclosed tags, images without alt text, links with an empty href) run when
+// the file name ends in .html.
+func LintTemplate(file, source string, opts LintOptions) []Diagnostic {
+	diags := checkTemplate(file, source, templateFuncs(opts.Funcs), opts.DataType)
+	if strings.HasSuffix(file, TemplateKindHTML) {
+		diags = append(diags, lintHTML(file, source)...)
+	}
+	sortDiagnostics(diags)
+	return diags
+}
+
+// LintTemplateFS lints every template of a TemplateSet directory (see LintTemplate), and reports
+// HTML templates without plain text counterpart. Unlike LoadTemplateSet, syntax errors are
+// reported as diagnostics; the returned error is only set when the directory cannot be read.
+func LintTemplateFS(fsys fs.FS, opts LintOptions) ([]Diagnostic, error) {
+	set, err := scanTemplateSet(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	var diags []Diagnostic
+	for _, name := range set.Names() {
+		entry, _ := set.Lookup(name)
+		entryOpts := LintOptions{Funcs: opts.Funcs, DataType: opts.DataTypes[name]}
+		for kind, source := range entry.Sources {
+			diags = append(diags, LintTemplate(entry.Files[kind], source, entryOpts)...)
+		}
+
+		_, hasHTML := entry.Sources[TemplateKindHTML]
+		_, hasText := entry.Sources[TemplateKindText]
+		if hasHTML && !hasText {
+			diags = append(diags, Diagnostic{
+				File:     entry.Files[TemplateKindHTML],
+				Severity: SeverityWarning,
+				Rule:     RuleMissingText,
+				Message:  fmt.Sprintf("template %q has no plain text counterpart (%s)", name, name+TemplateKindText),
+			})
+		}
+	}
+
+	sortDiagnostics(diags)
+	return diags, nil
+}
+
+// sortDiagnostics sorts diagnostics by file then line.
+func sortDiagnostics(diags []Diagnostic) {
+	sort.SliceStable(diags, func(i, j int) bool {
+		if diags[i].File != diags[j].File {
+			return diags[i].File < diags[j].File
+		}
+		return diags[i].Line < diags[j].Line
+	})
+}
+
+// parseErrorLine extracts the line and message of a text/template parse error.
+var parseErrorLine = regexp.MustCompile(`^template: .*?:(\d+): (.*)$`)
+
+// checkTemplate parses the source and walks its trees, checking function calls and field references.
+func checkTemplate(file, source string, funcs template.FuncMap, dataType reflect.Type) []Diagnostic {
+	trees := make(map[string]*parse.Tree)
+	tree := parse.New(file)
+	tree.Mode = parse.SkipFuncCheck
+	if _, err := tree.Parse(source, "", "", trees); err != nil {
+		diag := Diagnostic{File: file, Severity: SeverityError, Rule: RuleSyntax, Message: err.Error()}
+		if m := parseErrorLine.FindStringSubmatch(err.Error()); m != nil {
+			diag.Line, _ = strconv.Atoi(m[1])
+			diag.Message = m[2]
+		}
+		return []Diagnostic{diag}
+	}
+
+	var diags []Diagnostic
+	names := make([]string, 0, len(trees))
+	for name := range trees {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		t := trees[name]
+		if t.Root == nil {
+			continue
+		}
+		c := &templateChecker{file: file, tree: t, funcs: funcs}
+		// Only the main tree is known to be executed with the template data.
+		var dot reflect.Type
+		if name == file {
+			dot = dataType
+		}
+		c.walk(t.Root, dot, map[string]reflect.Type{"$": dot})
+		diags = append(diags, c.diags...)
+	}
+	return diags
+}
+
+// templateChecker walks a template parse tree, reporting undefined functions and, while the type
+// of dot is known, unknown fields. A nil reflect.Type stands for an unknown type.
+type templateChecker struct {
+	file  string
+	tree  *parse.Tree
+	funcs template.FuncMap
+	diags []Diagnostic
+}
+
+// report records a diagnostic located at node.
+func (c *templateChecker) report(node parse.Node, rule, message string) {
+	location, _ := c.tree.ErrorContext(node)
+	line := 0
+	if parts := strings.Split(strings.TrimPrefix(location, c.tree.ParseName+":"), ":"); len(parts) > 0 {
+		line, _ = strconv.Atoi(parts[0])
+	}
+	c.diags = append(c.diags, Diagnostic{File: c.file, Line: line, Severity: SeverityError, Rule: rule, Message: message})
+}
+
+// walk checks node with the given type of dot and variables in scope.
+func (c *templateChecker) walk(node parse.Node, dot reflect.Type, vars map[string]reflect.Type) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			c.walk(child, dot, vars)
+		}
+	case *parse.ActionNode:
+		c.pipe(n.Pipe, dot, vars, false)
+	case *parse.TemplateNode:
+		c.pipe(n.Pipe, dot, vars, false)
+	case *parse.IfNode:
+		scope := copyVars(vars)
+		c.pipe(n.Pipe, dot, scope, false)
+		c.walk(n.List, dot, scope)
+		c.walk(n.ElseList, dot, copyVars(vars))
+	case *parse.WithNode:
+		scope := copyVars(vars)
+		inner := c.pipe(n.Pipe, dot, scope, false)
+		c.walk(n.List, inner, scope)
+		c.walk(n.ElseList, dot, copyVars(vars))
+	case *parse.RangeNode:
+		scope := copyVars(vars)
+		elem := c.pipe(n.Pipe, dot, scope, true)
+		c.walk(n.List, elem, scope)
+		c.walk(n.ElseList, dot, copyVars(vars))
+	}
+}
+
+// pipe checks a pipeline, declares its variables and returns the type it evaluates to.
+// For a range pipeline, the element type is returned and declared instead.
+func (c *templateChecker) pipe(pipe *parse.PipeNode, dot reflect.Type, vars map[string]reflect.Type, isRange bool) reflect.Type {
+	if pipe == nil {
+		return nil
+	}
+
+	var typ reflect.Type
+	for _, cmd := range pipe.Cmds {
+		typ = c.command(cmd, dot, vars)
+	}
+
+	if !isRange {
+		for _, v := range pipe.Decl {
+			vars[v.Ident[0]] = typ
+		}
+		return typ
+	}
+
+	key, elem := rangeTypes(typ)
+	switch len(pipe.Decl) {
+	case 1:
+		vars[pipe.Decl[0].Ident[0]] = elem
+	case 2:
+		vars[pipe.Decl[0].Ident[0]] = key
+		vars[pipe.Decl[1].Ident[0]] = elem
+	}
+	return elem
+}
+
+// command checks every argument of a command and returns the type of its result.
+func (c *templateChecker) command(cmd *parse.CommandNode, dot reflect.Type, vars map[string]reflect.Type) reflect.Type {
+	var typ reflect.Type
+	for i, arg := range cmd.Args {
+		argType := c.arg(arg, dot, vars)
+		if i == 0 {
+			typ = argType
+		}
+	}
+	return typ
+}
+
+// arg checks a command argument and returns its type.
+func (c *templateChecker) arg(arg parse.Node, dot reflect.Type, vars map[string]reflect.Type) reflect.Type {
+	switch n := arg.(type) {
+	case *parse.DotNode:
+		return dot
+	case *parse.FieldNode:
+		return c.fields(n, dot, n.Ident)
+	case *parse.VariableNode:
+		return c.fields(n, vars[n.Ident[0]], n.Ident[1:])
+	case *parse.ChainNode:
+		base := c.arg(n.Node, dot, vars)
+		return c.fields(n, base, n.Field)
+	case *parse.PipeNode:
+		return c.pipe(n, dot, copyVars(vars), false)
+	case *parse.IdentifierNode:
+		fn, ok := c.funcs[n.Ident]
+		if !ok && !builtinFuncs[n.Ident] {
+			c.report(n, RuleUndefinedFunction, fmt.Sprintf("function %q not defined", n.Ident))
+			return nil
+		}
+		if t := reflect.TypeOf(fn); t != nil && t.Kind() == reflect.Func && t.NumOut() > 0 && t.Out(0).Kind() != reflect.Interface {
+			return t.Out(0)
+		}
+	case *parse.StringNode:
+		return reflect.TypeOf("")
+	case *parse.BoolNode:
+		return reflect.TypeOf(true)
+	}
+	return nil
+}
+
+// fields resolves a chain of field names from typ, reporting the first unknown field.
+func (c *templateChecker) fields(node parse.Node, typ reflect.Type, names []string) reflect.Type {
+	for _, name := range names {
+		if typ == nil {
+			return nil
+		}
+		next, ok := fieldType(typ, name)
+		if !ok {
+			for typ.Kind() == reflect.Pointer {
+				typ = typ.Elem()
+			}
+			c.report(node, RuleUnknownField, fmt.Sprintf("field %q does not exist on type %s", name, typ))
+			return nil
+		}
+		typ = next
+	}
+	return typ
+}
+
+// fieldType returns the type of the field, method or map value with the given name on typ.
+// The returned type is nil when unknown, e.g. for interface values.
+func fieldType(typ reflect.Type, name string) (reflect.Type, bool) {
+	methods := typ
+	if methods.Kind() != reflect.Pointer && methods.Kind() != reflect.Interface {
+		methods = reflect.PointerTo(methods)
+	}
+	if m, ok := methods.MethodByName(name); ok && m.IsExported() {
+		if m.Type.NumOut() == 0 {
+			return nil, false
+		}
+		return knownType(m.Type.Out(0)), true
+	}
+
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+
+	switch typ.Kind() {
+	case reflect.Struct:
+		f, ok := typ.FieldByName(name)
+		if !ok || !f.IsExported() {
+			return nil, false
+		}
+		return knownType(f.Type), true
+	case reflect.Map:
+		if typ.Key().Kind() != reflect.String {
+			return nil, false
+		}
+		return knownType(typ.Elem()), true
+	case reflect.Interface:
+		return nil, true
+	default:
+		return nil, false
+	}
+}
+
+// knownType returns typ, or nil when the dynamic type of its values cannot be known statically.
+func knownType(typ reflect.Type) reflect.Type {
+	if typ.Kind() == reflect.Interface {
+		return nil
+	}
+	return typ
+}
+
+// rangeTypes returns the key and element types of a range over typ.
+func rangeTypes(typ reflect.Type) (key, elem reflect.Type) {
+	if typ == nil {
+		return nil, nil
+	}
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	switch typ.Kind() {
+	case reflect.Slice, reflect.Array:
+		return reflect.TypeOf(0), knownType(typ.Elem())
+	case reflect.Map:
+		return knownType(typ.Key()), knownType(typ.Elem())
+	case reflect.Chan:
+		return knownType(typ.Elem()), knownType(typ.Elem())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return typ, typ
+	default:
+		return nil, nil
+	}
+}
+
+// copyVars returns a copy of the variables in scope, for a nested scope.
+func copyVars(vars map[string]reflect.Type) map[string]reflect.Type {
+	scope := make(map[string]reflect.Type, len(vars))
+	for k, v := range vars {
+		scope[k] = v
+	}
+	return scope
+}
+
+// templateAction matches a template action, replaced before HTML checks.
+var templateAction = regexp.MustCompile(`(?s){{.*?}}`)
+
+// openTag is an element left open while tokenizing HTML.
+type openTag struct {
+	name string
+	line int
+}
+
+// lintHTML reports unclosed tags, images without alt text and links with an empty href.
+// Template actions are replaced by placeholder text first, so conditional markup may be misreported.
+func lintHTML(file, source string) []Diagnostic {
+	source = templateAction.ReplaceAllStringFunc(source, func(action string) string {
+		return "x" + strings.Repeat("\n", strings.Count(action, "\n"))
+	})
+
+	var diags []Diagnostic
+	var stack []openTag
+	line := 1
+	z := html.NewTokenizer(strings.NewReader(source))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+		tokenLine := line
+		line += strings.Count(string(z.Raw()), "\n")
+
+		token := z.Token()
+		switch tt {
+		case html.StartTagToken, html.SelfClosingTagToken:
+			attrs := make(map[string]string, len(token.Attr))
+			for _, a := range token.Attr {
+				attrs[a.Key] = a.Val
+			}
+			switch token.Data {
+			case "img":
+				if _, ok := attrs["alt"]; !ok {
+					diags = append(diags, Diagnostic{File: file, Line: tokenLine, Severity: SeverityWarning, Rule: RuleImageAlt, Message: "image without alt text"})
+				}
+			case "a":
+				if href := strings.TrimSpace(attrs["href"]); href == "" || href == "#" {
+					diags = append(diags, Diagnostic{File: file, Line: tokenLine, Severity: SeverityWarning, Rule: RuleEmptyHref, Message: "link with an empty href"})
+				}
+			}
+			if tt == html.StartTagToken && !voidElements[token.Data] && !optionalEndElements[token.Data] {
+				stack = append(stack, openTag{name: token.Data, line: tokenLine})
+			}
+		case html.EndTagToken:
+			if voidElements[token.Data] || optionalEndElements[token.Data] {
+				continue
+			}
+			i := len(stack) - 1
+			for i >= 0 && stack[i].name != token.Data {
+				i--
+			}
+			if i < 0 {
+				diags = append(diags, Diagnostic{File: file, Line: tokenLine, Severity: SeverityError, Rule: RuleUnclosedTag, Message: fmt.Sprintf("closing tag </%s> without matching opening tag", token.Data)})
+				continue
+			}
+			for _, open := range stack[i+1:] {
+				diags = append(diags, Diagnostic{File: file, Line: open.line, Severity: SeverityError, Rule: RuleUnclosedTag, Message: fmt.Sprintf("<%s> is not closed before </%s>", open.name, token.Data)})
+			}
+			stack = stack[:i]
+		}
+	}
+
+	for _, open := range stack {
+		diags = append(diags, Diagnostic{File: file, Line: open.line, Severity: SeverityError, Rule: RuleUnclosedTag, Message: fmt.Sprintf("<%s> is never closed", open.name)})
+	}
+	return diags
+}
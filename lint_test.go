@@ -0,0 +1,120 @@
+package goat
+
+import (
+	"reflect"
+	"testing"
+	"testing/fstest"
+	"text/template"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type lintUser struct {
+	Name    string
+	Email   string
+	private string
+}
+
+func (u lintUser) Initials() string { return u.Name[:1] }
+
+type lintData struct {
+	User    lintUser
+	Items   []*lintUser
+	Labels  map[string]string
+	Extra   interface{}
+	Manager *lintUser
+}
+
+// TestLintTemplate tests the LintTemplate function
+func TestLintTemplate(t *testing.T) {
+	t.Run("no problem", func(t *testing.T) {
+		source := `{{.User.Name | upper}} {{.User.Initials}} {{.Manager.Email}}
+{{range $i, $item := .Items}}{{$i}} {{$item.Email}} {{.Name}}{{end}}
+{{with .User}}{{.Email}}{{end}}{{.Labels.anything}}{{.Extra.Anything}}
+{{$u := .User}}{{$u.Name}}{{len .Items}}{{$.User.Name}}`
+
+		diags := LintTemplate("ok.txt", source, LintOptions{DataType: reflect.TypeOf(lintData{})})
+		assert.Empty(t, diags)
+	})
+
+	t.Run("syntax error", func(t *testing.T) {
+		diags := LintTemplate("broken.txt", "Hello\n{{.Name", LintOptions{})
+		assert.Equal(t, []Diagnostic{{File: "broken.txt", Line: 2, Severity: SeverityError, Rule: RuleSyntax, Message: "unclosed action"}}, diags)
+	})
+
+	t.Run("undefined functions", func(t *testing.T) {
+		source := "{{.Name | upper}}\n{{.Name | shout}}\n{{custom .Name}}"
+
+		diags := LintTemplate("funcs.txt", source, LintOptions{Funcs: template.FuncMap{"custom": func(string) string { return "" }}})
+		assert.Equal(t, []Diagnostic{{File: "funcs.txt", Line: 2, Severity: SeverityError, Rule: RuleUndefinedFunction, Message: `function "shout" not defined`}}, diags)
+	})
+
+	t.Run("unknown fields", func(t *testing.T) {
+		source := "{{.User.Nmae}}\n{{range .Items}}{{.Emial}}{{end}}\n{{with .Manager}}{{.private}}{{end}}\n{{.User.Name.Length}}"
+
+		diags := LintTemplate("fields.txt", source, LintOptions{DataType: reflect.TypeOf(&lintData{})})
+		assert.Len(t, diags, 4)
+		assert.Equal(t, Diagnostic{File: "fields.txt", Line: 1, Severity: SeverityError, Rule: RuleUnknownField, Message: `field "Nmae" does not exist on type goat.lintUser`}, diags[0])
+		assert.Equal(t, 2, diags[1].Line)
+		assert.Contains(t, diags[1].Message, `"Emial"`)
+		assert.Equal(t, 3, diags[2].Line)
+		assert.Contains(t, diags[2].Message, `"private"`)
+		assert.Equal(t, 4, diags[3].Line)
+		assert.Contains(t, diags[3].Message, `"Length" does not exist on type string`)
+	})
+
+	t.Run("HTML checks", func(t *testing.T) {
+		source := `<div class="content">
+<img src="logo.png">
+<img src="spacer.png" alt="">
+<a href="">empty</a> <a href="{{.URL}}">ok</a> <a href="#">anchor</a>
+<p>unclosed paragraph
+<table><tr><td>cell</table>
+<span>never closed
+</div>
+<section>`
+
+		diags := LintTemplate("page.html", source, LintOptions{})
+		assert.Equal(t, []Diagnostic{
+			{File: "page.html", Line: 2, Severity: SeverityWarning, Rule: RuleImageAlt, Message: "image without alt text"},
+			{File: "page.html", Line: 4, Severity: SeverityWarning, Rule: RuleEmptyHref, Message: "link with an empty href"},
+			{File: "page.html", Line: 4, Severity: SeverityWarning, Rule: RuleEmptyHref, Message: "link with an empty href"},
+			{File: "page.html", Line: 7, Severity: SeverityError, Rule: RuleUnclosedTag, Message: "<span> is not closed before </div>"},
+			{File: "page.html", Line: 9, Severity: SeverityError, Rule: RuleUnclosedTag, Message: "<section> is never closed"},
+		}, diags)
+	})
+
+	t.Run("HTML checks skipped for text templates", func(t *testing.T) {
+		assert.Empty(t, LintTemplate("page.txt", "<div><img src=\"x\">", LintOptions{}))
+	})
+
+	t.Run("unmatched closing tag", func(t *testing.T) {
+		diags := LintTemplate("page.html", "<div></div></span>", LintOptions{})
+		assert.Equal(t, []Diagnostic{{File: "page.html", Line: 1, Severity: SeverityError, Rule: RuleUnclosedTag, Message: "closing tag </span> without matching opening tag"}}, diags)
+	})
+}
+
+// TestLintTemplateFS tests the LintTemplateFS function
+func TestLintTemplateFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"welcome.html": {Data: []byte("<p>Hello {{.User.Name}}</p>\n<img src=\"x\">")},
+		"welcome.txt":  {Data: []byte("Hello {{.User.Nmae}}")},
+		"promo.html":   {Data: []byte("<p>Promo</p>")},
+		"broken.md":    {Data: []byte("# Title\n\n{{if .X}}")},
+	}
+
+	diags, err := LintTemplateFS(fsys, LintOptions{DataTypes: map[string]reflect.Type{"welcome": reflect.TypeOf(lintData{})}})
+	assert.NoError(t, err)
+	assert.Equal(t, []Diagnostic{
+		{File: "broken.md", Line: 3, Severity: SeverityError, Rule: RuleSyntax, Message: "unexpected EOF"},
+		{File: "promo.html", Line: 0, Severity: SeverityWarning, Rule: RuleMissingText, Message: `template "promo" has no plain text counterpart (promo.txt)`},
+		{File: "welcome.html", Line: 2, Severity: SeverityWarning, Rule: RuleImageAlt, Message: "image without alt text"},
+		{File: "welcome.txt", Line: 1, Severity: SeverityError, Rule: RuleUnknownField, Message: `field "Nmae" does not exist on type goat.lintUser`},
+	}, diags)
+}
+
+// TestDiagnostic_String tests the String method of Diagnostic
+func TestDiagnostic_String(t *testing.T) {
+	d := Diagnostic{File: "a.html", Line: 3, Severity: SeverityWarning, Rule: RuleImageAlt, Message: "image without alt text"}
+	assert.Equal(t, "a.html:3: warning: image without alt text (image-alt)", d.String())
+}
@@ -0,0 +1,347 @@
+package goat
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	east "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// DefaultMarkdownLayout is the HTML layout wrapping the converted Markdown body when
+// MarkdownTemplate.Layout is empty. Layouts are Go templates executed with MarkdownLayoutData.
+const DefaultMarkdownLayout = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="UTF-8">
+<meta name="viewport" content="width=device-width, initial-scale=1.0">
+<title>{{.Title | html}}</title>
+</head>
+<body style="margin:0;padding:0;background-color:#f8f9fa;">
+<table role="presentation" width="100%" cellpadding="0" cellspacing="0" border="0" style="background-color:#f8f9fa;">
+<tr>
+<td align="center" style="padding:24px 12px;">
+<table role="presentation" width="600" cellpadding="0" cellspacing="0" border="0" style="width:100%;max-width:600px;background-color:#ffffff;">
+<tr>
+<td style="padding:32px;font-family:Arial,Helvetica,sans-serif;font-size:16px;line-height:1.6;color:#333333;">
+{{.Content}}
+</td>
+</tr>
+</table>
+</td>
+</tr>
+</table>
+</body>
+</html>`
+
+// markdownStyles holds the inline styles applied to the converted HTML elements, since
+// most email clients ignore <style> blocks.
+var markdownStyles = map[ast.NodeKind]string{
+	ast.KindParagraph:      "margin:0 0 16px;",
+	ast.KindLink:           "color:#1a73e8;text-decoration:underline;",
+	ast.KindAutoLink:       "color:#1a73e8;text-decoration:underline;",
+	ast.KindList:           "margin:0 0 16px;padding-left:24px;",
+	ast.KindListItem:       "margin:0 0 4px;",
+	ast.KindBlockquote:     "margin:0 0 16px;padding:0 0 0 12px;border-left:4px solid #dddddd;color:#555555;",
+	ast.KindCodeSpan:       "font-family:monospace;background-color:#f4f4f4;padding:2px 4px;",
+	ast.KindImage:          "max-width:100%;height:auto;border:0;",
+	ast.KindThematicBreak:  "border:0;border-top:1px solid #dddddd;margin:24px 0;",
+	east.KindTable:         "border-collapse:collapse;margin:0 0 16px;",
+	east.KindTableCell:     "border:1px solid #dddddd;padding:6px 12px;",
+	east.KindStrikethrough: "text-decoration:line-through;",
+}
+
+// markdownHeadingStyles holds the inline styles of headings, indexed by level.
+var markdownHeadingStyles = [...]string{
+	1: "margin:0 0 16px;font-size:24px;line-height:1.3;",
+	2: "margin:0 0 16px;font-size:20px;line-height:1.3;",
+	3: "margin:0 0 12px;font-size:18px;line-height:1.3;",
+	4: "margin:0 0 12px;font-size:16px;line-height:1.3;",
+	5: "margin:0 0 12px;font-size:14px;line-height:1.3;",
+	6: "margin:0 0 12px;font-size:13px;line-height:1.3;",
+}
+
+// MarkdownTemplate represents an email template authored in Markdown.
+//
+// Rendering first executes ContentRaw as a Go template with Data (see Template), then converts
+// the resulting Markdown into both an email-safe HTML body wrapped in Layout and a readable
+// plain text body, so a single source yields both EmailMessage bodies.
+// Raw HTML found in the Markdown is omitted from the output.
+type MarkdownTemplate struct {
+	Name       string           // Name of the template
+	ContentRaw string           // Raw Markdown content with potential template variables
+	Data       interface{}      // Data containing the template variables values
+	Funcs      template.FuncMap // Optional functions available to this template and its layout
+	Layout     string           // Optional HTML layout, defaults to DefaultMarkdownLayout
+}
+
+// MarkdownLayoutData is the data a MarkdownTemplate layout is executed with.
+type MarkdownLayoutData struct {
+	Title   string      // Text of the first heading of the Markdown, if any
+	Content string      // Converted HTML body
+	Data    interface{} // Data of the MarkdownTemplate
+}
+
+// Render renders the Markdown template with its data and returns the plain text and HTML bodies.
+func (t MarkdownTemplate) Render() (plainText string, htmlContent string, err error) {
+	source, err := Template{Name: t.Name, ContentRaw: t.ContentRaw, Data: t.Data, Funcs: t.Funcs}.Render()
+	if err != nil {
+		return "", "", err
+	}
+
+	src := []byte(source)
+	md := newMarkdown()
+	doc := md.Parser().Parse(text.NewReader(src))
+
+	plainText = markdownToText(doc, src)
+
+	var body bytes.Buffer
+	if err = md.Renderer().Render(&body, src, doc); err != nil {
+		return "", "", fmt.Errorf("markdown: %w", err)
+	}
+
+	layout := t.Layout
+	if layout == "" {
+		layout = DefaultMarkdownLayout
+	}
+	tmpl, err := template.New(t.Name + ".layout").Funcs(templateFuncs(t.Funcs)).Parse(layout)
+	if err != nil {
+		return "", "", err
+	}
+
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, MarkdownLayoutData{
+		Title:   markdownTitle(doc, src),
+		Content: strings.TrimSpace(body.String()),
+		Data:    t.Data,
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	return plainText, buf.String(), nil
+}
+
+// newMarkdown returns the GitHub flavored Markdown converter used by MarkdownTemplate.
+func newMarkdown() goldmark.Markdown {
+	return goldmark.New(
+		goldmark.WithExtensions(
+			extension.NewTable(extension.WithTableCellAlignMethod(extension.TableCellAlignAttribute)),
+			extension.Strikethrough,
+			extension.Linkify,
+			extension.TaskList,
+		),
+		goldmark.WithParserOptions(
+			parser.WithASTTransformers(util.Prioritized(markdownStyler{}, 100)),
+		),
+	)
+}
+
+// markdownStyler is an AST transformer setting the inline style attribute of every styled node.
+type markdownStyler struct{}
+
+// Transform implements parser.ASTTransformer.
+func (markdownStyler) Transform(doc *ast.Document, _ text.Reader, _ parser.Context) {
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		style, ok := markdownStyles[n.Kind()]
+		if h, isHeading := n.(*ast.Heading); isHeading && h.Level < len(markdownHeadingStyles) {
+			style, ok = markdownHeadingStyles[h.Level], true
+		}
+		if ok {
+			n.SetAttributeString("style", []byte(style))
+		}
+		return ast.WalkContinue, nil
+	})
+}
+
+// markdownTitle returns the text of the first heading of the document.
+func markdownTitle(doc ast.Node, source []byte) string {
+	var title string
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if h, ok := n.(*ast.Heading); ok && entering {
+			title = inlineText(h, source)
+			return ast.WalkStop, nil
+		}
+		return ast.WalkContinue, nil
+	})
+	return title
+}
+
+// markdownToText converts a Markdown document into a readable plain text body.
+func markdownToText(doc ast.Node, source []byte) string {
+	blocks := blocksText(doc, source)
+	return strings.Join(blocks, "\n\n") + "\n"
+}
+
+// blocksText returns the plain text of every block child of n.
+func blocksText(n ast.Node, source []byte) []string {
+	var blocks []string
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		if b := blockText(c, source); b != "" {
+			blocks = append(blocks, b)
+		}
+	}
+	return blocks
+}
+
+// blockText returns the plain text of a single block node.
+func blockText(n ast.Node, source []byte) string {
+	switch n := n.(type) {
+	case *ast.Heading:
+		title := inlineText(n, source)
+		switch n.Level {
+		case 1:
+			return title + "\n" + strings.Repeat("=", utf8.RuneCountInString(title))
+		case 2:
+			return title + "\n" + strings.Repeat("-", utf8.RuneCountInString(title))
+		default:
+			return title
+		}
+	case *ast.Paragraph, *ast.TextBlock:
+		return inlineText(n, source)
+	case *ast.ThematicBreak:
+		return "----------"
+	case *ast.CodeBlock, *ast.FencedCodeBlock:
+		var lines []string
+		for i := 0; i < n.Lines().Len(); i++ {
+			line := n.Lines().At(i)
+			lines = append(lines, "    "+strings.TrimRight(string(line.Value(source)), "\n"))
+		}
+		return strings.Join(lines, "\n")
+	case *ast.Blockquote:
+		return prefixLines(strings.Join(blocksText(n, source), "\n\n"), "> ", "> ")
+	case *ast.List:
+		return listText(n, source)
+	case *east.Table:
+		return tableText(n, source)
+	case *ast.HTMLBlock:
+		return ""
+	default:
+		return strings.Join(blocksText(n, source), "\n\n")
+	}
+}
+
+// listText renders a list with "-" bullets or numbers, nested lists being indented.
+func listText(list *ast.List, source []byte) string {
+	var items []string
+	number := list.Start
+	for item := list.FirstChild(); item != nil; item = item.NextSibling() {
+		marker := "- "
+		if list.IsOrdered() {
+			marker = strconv.Itoa(number) + ". "
+			number++
+		}
+		separator := "\n"
+		if !list.IsTight {
+			separator = "\n\n"
+		}
+		content := strings.Join(blocksText(item, source), separator)
+		items = append(items, prefixLines(content, marker, strings.Repeat(" ", len(marker))))
+	}
+	if list.IsTight {
+		return strings.Join(items, "\n")
+	}
+	return strings.Join(items, "\n\n")
+}
+
+// tableText renders a table with padded columns separated by pipes.
+func tableText(table *east.Table, source []byte) string {
+	var rows [][]string
+	var widths []int
+	for row := table.FirstChild(); row != nil; row = row.NextSibling() {
+		var cells []string
+		for cell := row.FirstChild(); cell != nil; cell = cell.NextSibling() {
+			value := inlineText(cell, source)
+			if i := len(cells); i >= len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[len(cells)] = max(widths[len(cells)], utf8.RuneCountInString(value))
+			cells = append(cells, value)
+		}
+		rows = append(rows, cells)
+	}
+
+	var lines []string
+	for i, cells := range rows {
+		padded := make([]string, len(cells))
+		for j, cell := range cells {
+			padded[j] = cell + strings.Repeat(" ", widths[j]-utf8.RuneCountInString(cell))
+		}
+		lines = append(lines, strings.TrimRight(strings.Join(padded, " | "), " "))
+		if i == 0 {
+			dashes := make([]string, len(widths))
+			for j, w := range widths {
+				dashes[j] = strings.Repeat("-", w)
+			}
+			lines = append(lines, strings.Join(dashes, "-|-"))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// inlineText returns the plain text of the inline children of n.
+func inlineText(n ast.Node, source []byte) string {
+	var b strings.Builder
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		switch c := c.(type) {
+		case *ast.Text:
+			b.WriteString(html.UnescapeString(string(c.Value(source))))
+			if c.HardLineBreak() || c.SoftLineBreak() {
+				b.WriteString("\n")
+			}
+		case *ast.String:
+			b.WriteString(html.UnescapeString(string(c.Value)))
+		case *ast.AutoLink:
+			b.WriteString(string(c.URL(source)))
+		case *ast.Link:
+			label := inlineText(c, source)
+			destination := string(c.Destination)
+			if label == "" || label == destination || "mailto:"+label == destination {
+				b.WriteString(destination)
+			} else {
+				b.WriteString(label + " (" + destination + ")")
+			}
+		case *ast.Image:
+			if alt := inlineText(c, source); alt != "" {
+				b.WriteString("[" + alt + "]")
+			}
+		case *ast.RawHTML:
+			// raw HTML is omitted, as in the HTML output
+		case *east.TaskCheckBox:
+			if c.IsChecked {
+				b.WriteString("[x] ")
+			} else {
+				b.WriteString("[ ] ")
+			}
+		default:
+			b.WriteString(inlineText(c, source))
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// prefixLines prefixes the first line of s with first and every following line with rest.
+func prefixLines(s, first, rest string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		prefix := rest
+		if i == 0 {
+			prefix = first
+		}
+		lines[i] = strings.TrimRight(prefix+line, " ")
+	}
+	return strings.Join(lines, "\n")
+}
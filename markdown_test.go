@@ -0,0 +1,83 @@
+package goat
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestMarkdownTemplate_Render tests the Render method of the MarkdownTemplate struct
+func TestMarkdownTemplate_Render(t *testing.T) {
+	t.Run("template with incorrect content", func(t *testing.T) {
+		tmpl := MarkdownTemplate{Name: "test_template", ContentRaw: "{{.Content"}
+
+		plainText, html, err := tmpl.Render()
+		assert.Error(t, err)
+		assert.Equal(t, "", plainText)
+		assert.Equal(t, "", html)
+	})
+
+	t.Run("template with wrong data", func(t *testing.T) {
+		tmpl := MarkdownTemplate{
+			Name:       "test_template",
+			ContentRaw: "{{.Content}}",
+			Data:       map[string]string{"Wrong": "wrong"},
+		}
+
+		_, _, err := tmpl.Render()
+		assert.Error(t, err)
+	})
+
+	t.Run("template with incorrect layout", func(t *testing.T) {
+		tmpl := MarkdownTemplate{Name: "test_template", ContentRaw: "Hello", Layout: "{{.Content"}
+
+		_, _, err := tmpl.Render()
+		assert.Error(t, err)
+	})
+
+	t.Run("successful render", func(t *testing.T) {
+		tmpl := MarkdownTemplate{
+			Name: "test_template",
+			ContentRaw: "# Welcome {{.Name}}\n\n" +
+				"Read the [guide](https://example.com/guide) & enjoy.\n\n" +
+				"- first\n- second\n  1. nested\n\n" +
+				"> quoted\n\n" +
+				"| Plan | Price |\n|------|------:|\n| Pro | {{.Price | money \"USD\"}} |\n\n" +
+				"<script>alert(1)</script>\n",
+			Data: map[string]interface{}{"Name": "John", "Price": 12},
+		}
+
+		plainText, html, err := tmpl.Render()
+		assert.NoError(t, err)
+
+		assert.Equal(t, "Welcome John\n"+
+			"============\n\n"+
+			"Read the guide (https://example.com/guide) & enjoy.\n\n"+
+			"- first\n- second\n  1. nested\n\n"+
+			"> quoted\n\n"+
+			"Plan | Price\n"+
+			"-----|-------\n"+
+			"Pro  | $12.00\n", plainText)
+
+		assert.Contains(t, html, "<title>Welcome John</title>")
+		assert.Contains(t, html, `<h1 style="margin:0 0 16px;font-size:24px;line-height:1.3;">Welcome John</h1>`)
+		assert.Contains(t, html, `<a href="https://example.com/guide" style="color:#1a73e8;text-decoration:underline;">guide</a> &amp; enjoy.`)
+		assert.Contains(t, html, `<ol style="margin:0 0 16px;padding-left:24px;">`)
+		assert.Contains(t, html, `<td align="right" style="border:1px solid #dddddd;padding:6px 12px;">$12.00</td>`)
+		assert.NotContains(t, html, "<script>")
+	})
+
+	t.Run("custom layout", func(t *testing.T) {
+		tmpl := MarkdownTemplate{
+			Name:       "test_template",
+			ContentRaw: "Hello **{{.Name}}**",
+			Data:       map[string]string{"Name": "John", "Footer": "Bye"},
+			Layout:     "<div>{{.Content}}</div><footer>{{.Data.Footer | upper}}</footer>",
+		}
+
+		plainText, html, err := tmpl.Render()
+		assert.NoError(t, err)
+		assert.Equal(t, "Hello John\n", plainText)
+		assert.Equal(t, `<div><p style="margin:0 0 16px;">Hello <strong>John</strong></p></div><footer>BYE</footer>`, html)
+	})
+}
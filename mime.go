@@ -0,0 +1,347 @@
+package goat
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"sort"
+	"strings"
+	"time"
+)
+
+// mimeHeaderLength is the recommended maximum length of a header line, excluding CRLF, at which headers are folded.
+const mimeHeaderLength = 78
+
+// ErrMissingFrom is returned when serializing a message without from address.
+var ErrMissingFrom = errors.New("message has no from address")
+
+// timeNow returns the current time, used for the Date header.
+var timeNow = time.Now
+
+// mimePart is a node of the MIME tree of a message: a multipart container or a leaf holding content.
+type mimePart struct {
+	header   textproto.MIMEHeader
+	content  []byte      // Leaf content, before transfer encoding
+	boundary string      // Multipart boundary
+	children []*mimePart // Multipart children
+}
+
+// MarshalMIME returns the message serialized as an RFC 5322 message, see WriteTo.
+func (m *EmailMessage) MarshalMIME() ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := m.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// WriteTo writes the message to w as an RFC 5322 message and returns the number of bytes written.
+//
+// The message must be valid (see EmailMessage.Validate) and have a from address. Its body is structured as
+// multipart/mixed (regular attachments) → multipart/related (inline attachments) → multipart/alternative
+// (plain text and HTML), each level being omitted when not needed. Text parts are quoted-printable encoded
+// and attachments base64 encoded. Non-ASCII subjects, display names and attachment names are RFC 2047 encoded,
+// and file names RFC 2231 encoded. The threading fields are written as the Message-ID, In-Reply-To and References
+// headers, overriding the same custom headers; a Message-ID and a Date are generated unless set.
+// The transformers of the message are then applied in order.
+func (m *EmailMessage) WriteTo(w io.Writer) (int64, error) {
+	if m.From == nil || m.From.Address == "" {
+		return 0, ErrMissingFrom
+	}
+	if err := m.Validate(); err != nil {
+		return 0, err
+	}
+	custom, err := DefaultHeaderPolicy.Sanitize(m.Headers)
+	if err != nil {
+		return 0, err
+	}
+	custom = m.withThreadingHeaders(custom)
+
+	if len(m.Transformers) > 0 {
+		var buf bytes.Buffer
+		if err := m.writeMIME(&buf, custom); err != nil {
+			return 0, err
+		}
+		raw := buf.Bytes()
+		for _, t := range m.Transformers {
+			if raw, err = t.TransformMIME(raw); err != nil {
+				return 0, fmt.Errorf("transforming message: %w", err)
+			}
+		}
+		n, err := w.Write(raw)
+		return int64(n), err
+	}
+
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	if err := m.writeMIME(bw, custom); err != nil {
+		return cw.n, err
+	}
+	err = bw.Flush()
+	return cw.n, err
+}
+
+// SplitMIMEEntity splits a serialized message into its message headers and its MIME entity: the Content-*
+// headers followed by a blank line and the body. The MIME-Version header is dropped. It lets a MIMETransformer
+// wrap the content of a message, e.g. to sign or encrypt it, while keeping its headers.
+func SplitMIMEEntity(raw []byte) (header, entity []byte, err error) {
+	fields, body, err := splitMessage(raw)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var h, e bytes.Buffer
+	for _, f := range fields {
+		name := strings.ToLower(fieldName(f))
+		switch {
+		case name == "mime-version":
+		case strings.HasPrefix(name, "content-"):
+			e.WriteString(f)
+		default:
+			h.WriteString(f)
+		}
+	}
+	if e.Len() == 0 {
+		e.WriteString("Content-Type: text/plain; charset=us-ascii\r\n")
+	}
+	e.WriteString("\r\n")
+	e.Write(body)
+	return h.Bytes(), e.Bytes(), nil
+}
+
+// writeMIME writes the headers and the body of the message, with the given sanitized custom headers.
+func (m *EmailMessage) writeMIME(w io.Writer, custom map[string]string) error {
+	root := m.mimeTree()
+
+	headers := [][2]string{
+		{"From", formatAddress(m.From)},
+		{"To", formatAddress(&Address{Address: m.To})},
+	}
+	if m.ReplyTo != nil && m.ReplyTo.Address != "" {
+		headers = append(headers, [2]string{"Reply-To", formatAddress(m.ReplyTo)})
+	}
+	headers = append(headers, [2]string{"Subject", encodeHeaderValue(m.Subject)})
+
+	canonical := make(map[string]string, len(custom))
+	keys := make([]string, 0, len(custom))
+	for k, v := range custom {
+		canonical[textproto.CanonicalMIMEHeaderKey(k)] = v
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	if _, ok := canonical["Date"]; !ok {
+		headers = append(headers, [2]string{"Date", timeNow().Format(time.RFC1123Z)})
+	}
+	if _, ok := canonical["Message-Id"]; !ok {
+		id, err := newMessageID(m.From.Address)
+		if err != nil {
+			return err
+		}
+		headers = append(headers, [2]string{"Message-ID", id})
+	}
+	for _, k := range keys {
+		headers = append(headers, [2]string{k, custom[k]})
+	}
+	headers = append(headers,
+		[2]string{"MIME-Version", "1.0"},
+		[2]string{"Content-Type", root.header.Get("Content-Type")},
+	)
+	if encoding := root.header.Get("Content-Transfer-Encoding"); encoding != "" {
+		headers = append(headers, [2]string{"Content-Transfer-Encoding", encoding})
+	}
+
+	for _, h := range headers {
+		if err := writeHeader(w, h[0], h[1]); err != nil {
+			return err
+		}
+	}
+	if _, err := io.WriteString(w, "\r\n"); err != nil {
+		return err
+	}
+	return root.writeBody(w)
+}
+
+// mimeTree returns the MIME tree of the message body.
+func (m *EmailMessage) mimeTree() *mimePart {
+	var body *mimePart
+	switch {
+	case m.PlainTextContent != "" && m.HTMLContent != "":
+		body = multipartPart("alternative",
+			textPart("text/plain", m.PlainTextContent),
+			textPart("text/html", m.HTMLContent))
+	case m.HTMLContent != "":
+		body = textPart("text/html", m.HTMLContent)
+	case m.PlainTextContent != "":
+		body = textPart("text/plain", m.PlainTextContent)
+	}
+
+	var inline, attached []*mimePart
+	for _, a := range m.Attachments {
+		if a.ContentID != "" && m.HTMLContent != "" {
+			inline = append(inline, attachmentPart(a, "inline"))
+		} else {
+			attached = append(attached, attachmentPart(a, "attachment"))
+		}
+	}
+
+	if len(inline) > 0 {
+		body = multipartPart("related", append([]*mimePart{body}, inline...)...)
+	}
+	if len(attached) > 0 {
+		if body != nil {
+			attached = append([]*mimePart{body}, attached...)
+		}
+		body = multipartPart("mixed", attached...)
+	}
+	if body == nil {
+		body = textPart("text/plain", "")
+	}
+	return body
+}
+
+// textPart returns a quoted-printable UTF-8 text part.
+func textPart(mediaType, content string) *mimePart {
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Type", mime.FormatMediaType(mediaType, map[string]string{"charset": "utf-8"}))
+	header.Set("Content-Transfer-Encoding", "quoted-printable")
+	return &mimePart{header: header, content: []byte(content)}
+}
+
+// attachmentPart returns a base64 part for the attachment, with the given disposition.
+func attachmentPart(a Attachment, disposition string) *mimePart {
+	mediaType, params, err := mime.ParseMediaType(a.mediaType())
+	if err != nil {
+		mediaType, params = "application/octet-stream", map[string]string{}
+	}
+	params["name"] = encodeHeaderValue(a.Filename)
+
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Type", mime.FormatMediaType(mediaType, params))
+	header.Set("Content-Transfer-Encoding", "base64")
+	if d := mime.FormatMediaType(disposition, map[string]string{"filename": a.Filename}); d != "" {
+		header.Set("Content-Disposition", d)
+	} else {
+		header.Set("Content-Disposition", disposition)
+	}
+	if disposition == "inline" {
+		header.Set("Content-ID", "<"+a.ContentID+">")
+	}
+	return &mimePart{header: header, content: a.Content}
+}
+
+// multipartPart returns a multipart part of the given subtype holding the children.
+func multipartPart(subtype string, children ...*mimePart) *mimePart {
+	boundary := multipart.NewWriter(io.Discard).Boundary()
+
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Type", mime.FormatMediaType("multipart/"+subtype, map[string]string{"boundary": boundary}))
+	return &mimePart{header: header, boundary: boundary, children: children}
+}
+
+// writeBody writes the encoded content of the part, or its children separated by its boundary.
+func (p *mimePart) writeBody(w io.Writer) error {
+	if p.boundary == "" {
+		switch p.header.Get("Content-Transfer-Encoding") {
+		case "base64":
+			return writeBase64(w, p.content)
+		default:
+			qp := quotedprintable.NewWriter(w)
+			if _, err := qp.Write(p.content); err != nil {
+				return err
+			}
+			return qp.Close()
+		}
+	}
+
+	mw := multipart.NewWriter(w)
+	if err := mw.SetBoundary(p.boundary); err != nil {
+		return err
+	}
+	for _, child := range p.children {
+		pw, err := mw.CreatePart(child.header)
+		if err != nil {
+			return err
+		}
+		if err := child.writeBody(pw); err != nil {
+			return err
+		}
+	}
+	return mw.Close()
+}
+
+// writeBase64 writes content base64 encoded in lines of mimeLineLength characters.
+func writeBase64(w io.Writer, content []byte) error {
+	encoded := base64.StdEncoding.EncodeToString(content)
+	for i := 0; i < len(encoded); i += mimeLineLength {
+		line := encoded[i:min(i+mimeLineLength, len(encoded))]
+		if i > 0 {
+			line = "\r\n" + line
+		}
+		if _, err := io.WriteString(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeHeader writes a header field, folded at spaces to keep lines within mimeHeaderLength when possible.
+// A value containing a line break or a control character is rejected with a *HeaderError, as it could
+// inject headers or end the header section.
+func writeHeader(w io.Writer, key, value string) error {
+	if err := checkHeaderValue(key, value); err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	b.WriteString(key + ":")
+	lineLength, words := len(key)+1, 0
+	for _, word := range strings.Split(value, " ") {
+		if words > 0 && lineLength+1+len(word) > mimeHeaderLength {
+			b.WriteString("\r\n")
+			lineLength, words = 0, 0
+		}
+		b.WriteString(" " + word)
+		lineLength += 1 + len(word)
+		words++
+	}
+	b.WriteString("\r\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// formatAddress returns the address formatted for a header, its display name RFC 2047 encoded if needed.
+func formatAddress(a *Address) string {
+	return (&mail.Address{Name: a.Name, Address: a.Address}).String()
+}
+
+// newMessageID returns a unique Message-ID in the domain of the given address.
+func newMessageID(address string) (string, error) {
+	domain := ""
+	if at := strings.LastIndex(address, "@"); at >= 0 && at < len(address)-1 {
+		domain = address[at+1:]
+	}
+	return NewMessageID(domain)
+}
+
+// countingWriter counts the bytes written to w.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+// Write implements the io.Writer interface.
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
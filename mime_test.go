@@ -0,0 +1,255 @@
+package goat
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// readMIMEPart holds a parsed MIME part: its media type and either its decoded content or its children.
+type readMIMEPart struct {
+	mediaType string
+	header    map[string][]string
+	content   string
+	children  []readMIMEPart
+}
+
+// parseMIMEPart parses a part body with the given Content-Type and Content-Transfer-Encoding.
+func parseMIMEPart(t *testing.T, header map[string][]string, body io.Reader) readMIMEPart {
+	t.Helper()
+
+	mediaType, params, err := mime.ParseMediaType(mail.Header(header).Get("Content-Type"))
+	assert.NoError(t, err)
+	part := readMIMEPart{mediaType: mediaType, header: header}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			p, err := mr.NextRawPart()
+			if err == io.EOF {
+				break
+			}
+			assert.NoError(t, err)
+			part.children = append(part.children, parseMIMEPart(t, p.Header, p))
+		}
+		return part
+	}
+
+	reader := body
+	switch mail.Header(header).Get("Content-Transfer-Encoding") {
+	case "base64":
+		reader = base64.NewDecoder(base64.StdEncoding, body)
+	case "quoted-printable":
+		reader = quotedprintable.NewReader(body)
+	}
+	content, err := io.ReadAll(reader)
+	assert.NoError(t, err)
+	part.content = string(content)
+	return part
+}
+
+type upperTransformer struct{}
+
+func (upperTransformer) TransformMIME(raw []byte) ([]byte, error) {
+	return bytes.ToUpper(raw), nil
+}
+
+// TestEmailMessage_WriteTo tests the WriteTo and MarshalMIME methods of EmailMessage
+func TestEmailMessage_WriteTo(t *testing.T) {
+	defer func(prev func() time.Time) { timeNow = prev }(timeNow)
+	timeNow = func() time.Time { return time.Date(2026, 3, 14, 15, 9, 26, 0, time.UTC) }
+
+	t.Run("Success - full structure", func(t *testing.T) {
+		msg := NewEmailMessage("to@example.com", "Reçu de votre commande n°42", "Bonjour,\nvoici votre reçu.", `<p>Bonjour</p><img src="cid:logo">`).
+			WithFrom("Boutique Zoé", "shop@example.com").
+			WithReplyTo("Support", "support@example.com").
+			WithHeader("X-Campaign", "receipts").
+			WithAttachment("reçu.pdf", "application/pdf", []byte("%PDF-1.7 receipt")).
+			WithInlineAttachment("logo.png", "image/png", bytes.Repeat([]byte{0x89, 'P', 'N', 'G'}, 40), "logo")
+
+		var buf bytes.Buffer
+		n, err := msg.WriteTo(&buf)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(buf.Len()), n)
+
+		for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\r\n"), "\r\n") {
+			assert.LessOrEqual(t, len(line), 998)
+			assert.NotContains(t, line, "\n")
+		}
+
+		parsed, err := mail.ReadMessage(&buf)
+		assert.NoError(t, err)
+
+		dec := new(mime.WordDecoder)
+		subject, err := dec.DecodeHeader(parsed.Header.Get("Subject"))
+		assert.NoError(t, err)
+		assert.Equal(t, "Reçu de votre commande n°42", subject)
+
+		from, err := parsed.Header.AddressList("From")
+		assert.NoError(t, err)
+		assert.Equal(t, []*mail.Address{{Name: "Boutique Zoé", Address: "shop@example.com"}}, from)
+		assert.Equal(t, "<to@example.com>", parsed.Header.Get("To"))
+		assert.Equal(t, `"Support" <support@example.com>`, parsed.Header.Get("Reply-To"))
+		assert.Equal(t, "receipts", parsed.Header.Get("X-Campaign"))
+		assert.Equal(t, "1.0", parsed.Header.Get("Mime-Version"))
+		assert.Equal(t, "Sat, 14 Mar 2026 15:09:26 +0000", parsed.Header.Get("Date"))
+		assert.Regexp(t, `^<\d+\.[0-9a-f]{32}@example\.com>$`, parsed.Header.Get("Message-Id"))
+
+		root := parseMIMEPart(t, parsed.Header, parsed.Body)
+		assert.Equal(t, "multipart/mixed", root.mediaType)
+		assert.Len(t, root.children, 2)
+
+		related := root.children[0]
+		assert.Equal(t, "multipart/related", related.mediaType)
+		assert.Len(t, related.children, 2)
+
+		alternative := related.children[0]
+		assert.Equal(t, "multipart/alternative", alternative.mediaType)
+		assert.Len(t, alternative.children, 2)
+		assert.Equal(t, "text/plain", alternative.children[0].mediaType)
+		assert.Equal(t, "Bonjour,\r\nvoici votre reçu.", alternative.children[0].content)
+		assert.Equal(t, "text/html", alternative.children[1].mediaType)
+		assert.Equal(t, `<p>Bonjour</p><img src="cid:logo">`, alternative.children[1].content)
+
+		logo := related.children[1]
+		assert.Equal(t, "image/png", logo.mediaType)
+		assert.Equal(t, "<logo>", mail.Header(logo.header).Get("Content-Id"))
+		assert.Equal(t, "inline; filename=logo.png", mail.Header(logo.header).Get("Content-Disposition"))
+		assert.Equal(t, string(bytes.Repeat([]byte{0x89, 'P', 'N', 'G'}, 40)), logo.content)
+
+		receipt := root.children[1]
+		assert.Equal(t, "application/pdf", receipt.mediaType)
+		assert.Equal(t, "%PDF-1.7 receipt", receipt.content)
+		assert.Equal(t, `application/pdf; name="=?utf-8?q?re=C3=A7u.pdf?="`, mail.Header(receipt.header).Get("Content-Type"))
+		_, params, err := mime.ParseMediaType(mail.Header(receipt.header).Get("Content-Disposition"))
+		assert.NoError(t, err)
+		assert.Equal(t, "reçu.pdf", params["filename"])
+		assert.Equal(t, "attachment; filename*=utf-8''re%C3%A7u.pdf", mail.Header(receipt.header).Get("Content-Disposition"))
+	})
+
+	t.Run("Success - single part", func(t *testing.T) {
+		msg := NewEmailMessage("to@example.com", "Subject", "Plain text only", "").WithFrom("", "shop@example.com")
+
+		raw, err := msg.MarshalMIME()
+		assert.NoError(t, err)
+
+		parsed, err := mail.ReadMessage(bytes.NewReader(raw))
+		assert.NoError(t, err)
+		assert.Equal(t, "<shop@example.com>", parsed.Header.Get("From"))
+		assert.Equal(t, "text/plain; charset=utf-8", parsed.Header.Get("Content-Type"))
+		assert.Equal(t, "quoted-printable", parsed.Header.Get("Content-Transfer-Encoding"))
+		root := parseMIMEPart(t, parsed.Header, parsed.Body)
+		assert.Equal(t, "Plain text only", root.content)
+	})
+
+	t.Run("Success - custom Message-ID and Date kept", func(t *testing.T) {
+		msg := NewEmailMessage("to@example.com", "Subject", "Text", "").
+			WithFrom("", "shop@example.com").
+			WithHeader("Message-ID", "<custom@example.com>").
+			WithHeader("Date", "Mon, 02 Jan 2006 15:04:05 -0700")
+
+		raw, err := msg.MarshalMIME()
+		assert.NoError(t, err)
+		assert.Equal(t, 1, strings.Count(string(raw), "Message-ID:"))
+		assert.Equal(t, 1, strings.Count(string(raw), "Date:"))
+		assert.Contains(t, string(raw), "Message-ID: <custom@example.com>\r\n")
+	})
+
+	t.Run("Success - long headers are folded", func(t *testing.T) {
+		msg := NewEmailMessage("to@example.com", strings.Repeat("word ", 40), "Text", "").WithFrom("", "shop@example.com")
+
+		raw, err := msg.MarshalMIME()
+		assert.NoError(t, err)
+		parsed, err := mail.ReadMessage(bytes.NewReader(raw))
+		assert.NoError(t, err)
+		assert.Equal(t, strings.TrimSpace(strings.Repeat("word ", 40)), parsed.Header.Get("Subject"))
+		for _, line := range strings.Split(string(raw), "\r\n") {
+			assert.LessOrEqual(t, len(line), mimeHeaderLength)
+		}
+	})
+
+	t.Run("Success - transformers applied", func(t *testing.T) {
+		msg := NewEmailMessage("to@example.com", "Subject", "Text", "").
+			WithFrom("", "shop@example.com").
+			WithTransformer(upperTransformer{})
+
+		var buf bytes.Buffer
+		n, err := msg.WriteTo(&buf)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(buf.Len()), n)
+		assert.Contains(t, buf.String(), "SUBJECT: SUBJECT\r\n")
+	})
+
+	t.Run("Failure - transformer", func(t *testing.T) {
+		_, err := NewEmailMessage("to@example.com", "Subject", "Text", "").
+			WithFrom("", "shop@example.com").
+			WithTransformer(failingTransformer{}).
+			MarshalMIME()
+		assert.EqualError(t, err, "transforming message: no key")
+	})
+
+	t.Run("Failure - missing from", func(t *testing.T) {
+		_, err := NewEmailMessage("to@example.com", "Subject", "Text", "").MarshalMIME()
+		assert.ErrorIs(t, err, ErrMissingFrom)
+	})
+
+	t.Run("Failure - invalid message", func(t *testing.T) {
+		var buf bytes.Buffer
+		n, err := NewEmailMessage("to@example.com", "Subject", "Text", "").
+			WithFrom("", "shop@example.com").
+			WithHeader("X-Injected", "a\r\nBcc: victim@example.com").
+			WriteTo(&buf)
+
+		var headerErr *HeaderError
+		assert.ErrorAs(t, err, &headerErr)
+		assert.Zero(t, n)
+		assert.Zero(t, buf.Len())
+	})
+
+	t.Run("Failure - subject injection", func(t *testing.T) {
+		msg := NewEmailMessage("to@example.com", "Hi\r\nBcc: evil@x.com", "Text", "").WithFrom("", "shop@example.com")
+
+		_, err := msg.MarshalMIME()
+		assert.Error(t, err)
+
+		// The serializer rejects the subject by itself, without relying on Validate
+		var buf bytes.Buffer
+		err = msg.writeMIME(&buf, nil)
+		assert.EqualError(t, err, `header "Subject": value contains a line break`)
+		for _, line := range strings.Split(buf.String(), "\r\n") {
+			assert.False(t, strings.HasPrefix(line, "Bcc:"), "injected header line %q", line)
+		}
+	})
+}
+
+// TestSplitMIMEEntity tests the SplitMIMEEntity function
+func TestSplitMIMEEntity(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		raw := "From: a@example.com\r\nSubject: Hi\r\nMIME-Version: 1.0\r\nContent-Type: multipart/mixed;\r\n boundary=b\r\nX-Campaign: spring\r\n\r\n--b\r\n--b--\r\n"
+
+		header, entity, err := SplitMIMEEntity([]byte(raw))
+		assert.NoError(t, err)
+		assert.Equal(t, "From: a@example.com\r\nSubject: Hi\r\nX-Campaign: spring\r\n", string(header))
+		assert.Equal(t, "Content-Type: multipart/mixed;\r\n boundary=b\r\n\r\n--b\r\n--b--\r\n", string(entity))
+	})
+
+	t.Run("Success - default content type", func(t *testing.T) {
+		_, entity, err := SplitMIMEEntity([]byte("Subject: Hi\n\nHello\n"))
+		assert.NoError(t, err)
+		assert.Equal(t, "Content-Type: text/plain; charset=us-ascii\r\n\r\nHello\r\n", string(entity))
+	})
+
+	t.Run("Failure - no body", func(t *testing.T) {
+		_, _, err := SplitMIMEEntity([]byte("Subject: Hi"))
+		assert.EqualError(t, err, "message has no header/body separator")
+	})
+}
@@ -1,5 +1,10 @@
 package goat
 
+import (
+	"mime"
+	"path/filepath"
+)
+
 // SendResult holds metadata returned by a provider after sending an email.
 type SendResult struct {
 	// MessageID is the provider message identifier, kept verbatim
@@ -7,12 +12,15 @@ type SendResult struct {
 	MessageID string
 }
 
-// ReplyTo holds the reply-to name and address for an email.
-type ReplyTo struct {
+// Address holds a display name and email address.
+type Address struct {
 	Name    string
 	Address string
 }
 
+// ReplyTo holds the reply-to name and address for an email.
+type ReplyTo = Address
+
 // Attachment represents a file attached to an email.
 // Content holds the raw (un-encoded) bytes; the library base64-encodes it per provider.
 // Set ContentID to embed the attachment inline (referenced from HTML as cid:<ContentID>).
@@ -20,21 +28,40 @@ type ReplyTo struct {
 // from Filename and cannot embed inline; such attachments are sent as regular attachments.
 type Attachment struct {
 	Filename    string
-	ContentType string // MIME type, e.g. "application/pdf", "text/calendar"
+	ContentType string // MIME type, e.g. "application/pdf"; optional, detected from the Filename extension
 	Content     []byte // raw bytes (not base64)
 	ContentID   string // optional; when set, the attachment is inline
 }
 
+// mediaType returns the ContentType of the attachment, or when empty the type of its file name extension,
+// defaulting to application/octet-stream.
+func (a Attachment) mediaType() string {
+	if a.ContentType != "" {
+		return a.ContentType
+	}
+	if contentType := mime.TypeByExtension(filepath.Ext(a.Filename)); contentType != "" {
+		return contentType
+	}
+	return "application/octet-stream"
+}
+
 // EmailMessage represents an email to be sent.
 // Build one with NewEmailMessage and chain With* methods for optional fields.
 type EmailMessage struct {
+	From             *Address // optional; overrides the sender configured on the provider
 	To               string
 	Subject          string
 	PlainTextContent string
 	HTMLContent      string
 	ReplyTo          *ReplyTo
 	Headers          map[string]string
+	MessageID        string   // optional; a stable Message-ID, generated by the provider when empty
+	InReplyTo        string   // optional; the Message-ID of the message replied to
+	References       []string // optional; the Message-IDs of the thread, oldest first
+	Tags             []string // optional; categories of the message, sent as SendGrid categories and Brevo tags
 	Attachments      []Attachment
+	Transformers     []MIMETransformer // applied to the serialized message, e.g. to encrypt it for the recipient
+	NoTracking       bool              // optional; disables the open and click tracking of an EngagementTracker
 }
 
 // NewEmailMessage creates a new EmailMessage with the required fields.
@@ -47,6 +74,12 @@ func NewEmailMessage(to, subject, plainTextContent, htmlContent string) *EmailMe
 	}
 }
 
+// WithFrom sets the from address and returns the message for chaining.
+func (m *EmailMessage) WithFrom(name, address string) *EmailMessage {
+	m.From = &Address{Name: name, Address: address}
+	return m
+}
+
 // WithReplyTo sets the reply-to address and returns the message for chaining.
 func (m *EmailMessage) WithReplyTo(name, address string) *EmailMessage {
 	m.ReplyTo = &ReplyTo{Name: name, Address: address}
@@ -68,6 +101,12 @@ func (m *EmailMessage) WithHeaders(headers map[string]string) *EmailMessage {
 	return m
 }
 
+// WithTags adds tags categorizing the message, e.g. "newsletter", and returns the message for chaining.
+func (m *EmailMessage) WithTags(tags ...string) *EmailMessage {
+	m.Tags = append(m.Tags, tags...)
+	return m
+}
+
 // WithAttachment adds a standard file attachment and returns the message for chaining.
 func (m *EmailMessage) WithAttachment(filename, contentType string, content []byte) *EmailMessage {
 	m.Attachments = append(m.Attachments, Attachment{
@@ -95,3 +134,10 @@ func (m *EmailMessage) WithAttachments(attachments ...Attachment) *EmailMessage
 	m.Attachments = append(m.Attachments, attachments...)
 	return m
 }
+
+// WithTransformer adds a transformer applied to the serialized message, such as an encrypter for
+// the recipient key, and returns the message for chaining. Only raw MIME transports apply them.
+func (m *EmailMessage) WithTransformer(transformer MIMETransformer) *EmailMessage {
+	m.Transformers = append(m.Transformers, transformer)
+	return m
+}
@@ -0,0 +1,229 @@
+package goat
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+// traceHeaders are the headers added in transit, dropped when parsing a message so it can be sent again.
+var traceHeaders = map[string]bool{
+	"Received":                   true,
+	"Return-Path":                true,
+	"Delivered-To":               true,
+	"Dkim-Signature":             true,
+	"Authentication-Results":     true,
+	"Arc-Seal":                   true,
+	"Arc-Message-Signature":      true,
+	"Arc-Authentication-Results": true,
+}
+
+// wordDecoder decodes RFC 2047 encoded words, in any charset known to golang.org/x/text.
+var wordDecoder = &mime.WordDecoder{CharsetReader: charsetReader}
+
+// ParseEmailMessage reads an RFC 5322 message, such as a .eml file, into an EmailMessage.
+//
+// Multipart structures are walked depth-first: the first text/plain and text/html parts not marked as
+// attachments become the bodies, and every other part an attachment, inline when it has a Content-ID and
+// no "attachment" disposition. Transfer encodings are decoded, text is converted to UTF-8 with LF line endings,
+// and encoded words and RFC 2231 file names are decoded. Only the first address of the To header is kept.
+// Custom headers are kept (their first value, decoded), except the ones set from EmailMessage fields and
+// trace headers such as Received or DKIM-Signature. It round-trips with EmailMessage.WriteTo.
+func ParseEmailMessage(r io.Reader) (*EmailMessage, error) {
+	msg, err := mail.ReadMessage(r)
+	if err != nil {
+		return nil, fmt.Errorf("reading message: %w", err)
+	}
+
+	m := &EmailMessage{}
+	if err := m.parseHeader(msg.Header); err != nil {
+		return nil, err
+	}
+	if err := m.parsePart(textproto.MIMEHeader(msg.Header), msg.Body); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// parseHeader sets the addresses, subject and custom headers of the message from the top-level header.
+func (m *EmailMessage) parseHeader(header mail.Header) error {
+	parser := &mail.AddressParser{WordDecoder: wordDecoder}
+
+	if v := header.Get("From"); v != "" {
+		from, err := parser.Parse(v)
+		if err != nil {
+			return fmt.Errorf("parsing From header: %w", err)
+		}
+		m.From = &Address{Name: from.Name, Address: from.Address}
+	}
+	if v := header.Get("To"); v != "" {
+		to, err := parser.ParseList(v)
+		if err != nil {
+			return fmt.Errorf("parsing To header: %w", err)
+		}
+		if len(to) > 0 {
+			m.To = to[0].Address
+		}
+	}
+	if v := header.Get("Reply-To"); v != "" {
+		replyTo, err := parser.Parse(v)
+		if err != nil {
+			return fmt.Errorf("parsing Reply-To header: %w", err)
+		}
+		m.ReplyTo = &ReplyTo{Name: replyTo.Name, Address: replyTo.Address}
+	}
+
+	subject, err := wordDecoder.DecodeHeader(header.Get("Subject"))
+	if err != nil {
+		return fmt.Errorf("decoding Subject header: %w", err)
+	}
+	m.Subject = subject
+
+	m.MessageID = strings.TrimSpace(header.Get("Message-Id"))
+	if inReplyTo := strings.Fields(header.Get("In-Reply-To")); len(inReplyTo) > 0 {
+		m.InReplyTo = inReplyTo[0]
+	}
+	if references := strings.Fields(header.Get("References")); len(references) > 0 {
+		m.References = references
+	}
+
+	for key, values := range header {
+		canonical := textproto.CanonicalMIMEHeaderKey(key)
+		if reservedHeaders[canonical] || traceHeaders[canonical] || threadingHeaderNames[canonical] || len(values) == 0 {
+			continue
+		}
+		value, err := wordDecoder.DecodeHeader(values[0])
+		if err != nil {
+			value = values[0]
+		}
+		m.WithHeader(canonical, value)
+	}
+	return nil
+}
+
+// parsePart adds the content of a part to the message, recursively for multipart parts.
+func (m *EmailMessage) parsePart(header textproto.MIMEHeader, body io.Reader) error {
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		mediaType, params = "text/plain", map[string]string{}
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		if params["boundary"] == "" {
+			return fmt.Errorf("%s part without boundary", mediaType)
+		}
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := mr.NextRawPart()
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("reading %s part: %w", mediaType, err)
+			}
+			if err := m.parsePart(part.Header, part); err != nil {
+				return err
+			}
+		}
+	}
+
+	content, err := io.ReadAll(transferDecoder(header.Get("Content-Transfer-Encoding"), body))
+	if err != nil {
+		return fmt.Errorf("decoding %s part: %w", mediaType, err)
+	}
+
+	disposition, dispositionParams, _ := mime.ParseMediaType(header.Get("Content-Disposition"))
+	filename := dispositionParams["filename"]
+	if filename == "" && params["name"] != "" {
+		filename, _ = wordDecoder.DecodeHeader(params["name"])
+	}
+
+	if disposition != "attachment" && filename == "" {
+		switch {
+		case mediaType == "text/plain" && m.PlainTextContent == "":
+			text, err := decodeText(params["charset"], content)
+			m.PlainTextContent = text
+			return err
+		case mediaType == "text/html" && m.HTMLContent == "":
+			text, err := decodeText(params["charset"], content)
+			m.HTMLContent = text
+			return err
+		}
+	}
+
+	attachment := Attachment{
+		Filename:    filename,
+		ContentType: mediaType,
+		Content:     content,
+	}
+	if attachment.Filename == "" {
+		attachment.Filename = fmt.Sprintf("part%d%s", len(m.Attachments)+1, extensionByType(mediaType))
+	}
+	if disposition != "attachment" {
+		attachment.ContentID = strings.Trim(header.Get("Content-Id"), "<> ")
+	}
+	m.Attachments = append(m.Attachments, attachment)
+	return nil
+}
+
+// transferDecoder returns a reader decoding body according to the Content-Transfer-Encoding.
+func transferDecoder(encoding string, body io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, body)
+	case "quoted-printable":
+		return quotedprintable.NewReader(body)
+	default:
+		return body
+	}
+}
+
+// decodeText converts text from charset to UTF-8 with LF line endings.
+func decodeText(charset string, content []byte) (string, error) {
+	r, err := charsetReader(charset, bytes.NewReader(content))
+	if err != nil {
+		return "", err
+	}
+	decoded, err := io.ReadAll(r)
+	if err != nil {
+		return "", fmt.Errorf("decoding %s text: %w", charset, err)
+	}
+	return strings.ReplaceAll(string(decoded), "\r\n", "\n"), nil
+}
+
+// charsetReader returns a reader converting input from charset to UTF-8.
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	switch strings.ToLower(charset) {
+	case "", "utf-8", "utf8", "us-ascii", "ascii":
+		return input, nil
+	}
+	enc, err := htmlindex.Get(charset)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported charset %q", charset)
+	}
+	return enc.NewDecoder().Reader(input), nil
+}
+
+// extensionByType returns the file name extension of a media type, or an empty string if unknown.
+func extensionByType(mediaType string) string {
+	switch mediaType {
+	case "message/rfc822":
+		return ".eml"
+	case "text/plain":
+		return ".txt"
+	}
+	if exts, _ := mime.ExtensionsByType(mediaType); len(exts) > 0 {
+		return exts[0]
+	}
+	return ""
+}
@@ -0,0 +1,121 @@
+package goat
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestParseEmailMessage tests the ParseEmailMessage function
+func TestParseEmailMessage(t *testing.T) {
+	t.Run("Success - round trip", func(t *testing.T) {
+		original := NewEmailMessage("to@example.com", "Reçu de votre commande n°42", "Bonjour,\nvoici votre reçu.", `<p>Bonjour</p><img src="cid:logo">`).
+			WithFrom("Boutique Zoé", "shop@example.com").
+			WithReplyTo("Support", "support@example.com").
+			WithHeader("X-Campaign", "reçus").
+			WithAttachment("reçu.pdf", "application/pdf", []byte("%PDF-1.7 receipt")).
+			WithInlineAttachment("logo.png", "image/png", bytes.Repeat([]byte{0x89, 'P', 'N', 'G'}, 40), "logo")
+
+		raw, err := original.MarshalMIME()
+		assert.NoError(t, err)
+
+		parsed, err := ParseEmailMessage(bytes.NewReader(raw))
+		assert.NoError(t, err)
+		assert.Equal(t, original.From, parsed.From)
+		assert.Equal(t, original.To, parsed.To)
+		assert.Equal(t, original.ReplyTo, parsed.ReplyTo)
+		assert.Equal(t, original.Subject, parsed.Subject)
+		assert.Equal(t, original.PlainTextContent, parsed.PlainTextContent)
+		assert.Equal(t, original.HTMLContent, parsed.HTMLContent)
+		assert.Equal(t, "reçus", parsed.Headers["X-Campaign"])
+		assert.NotEmpty(t, parsed.MessageID)
+		assert.NotEmpty(t, parsed.Headers["Date"])
+		assert.ElementsMatch(t, original.Attachments, parsed.Attachments)
+
+		again, err := parsed.MarshalMIME()
+		assert.NoError(t, err)
+		reparsed, err := ParseEmailMessage(bytes.NewReader(again))
+		assert.NoError(t, err)
+		assert.Equal(t, parsed, reparsed)
+	})
+
+	t.Run("Success - charsets and encodings", func(t *testing.T) {
+		raw := strings.Join([]string{
+			"Received: from relay.example.com by mx.example.com",
+			"DKIM-Signature: v=1; a=rsa-sha256; d=example.com",
+			`From: =?iso-8859-1?q?Andr=E9?= <andre@example.com>`,
+			"To: First <first@example.com>, second@example.com",
+			"Subject: =?windows-1252?b?UHJpeCA6IDEwIIA=?=",
+			"X-Ticket: 1234",
+			"MIME-Version: 1.0",
+			`Content-Type: multipart/mixed; boundary="outer"`,
+			"",
+			"preamble",
+			"--outer",
+			`Content-Type: multipart/alternative; boundary="inner"`,
+			"",
+			"--inner",
+			"Content-Type: text/plain; charset=iso-8859-1",
+			"Content-Transfer-Encoding: quoted-printable",
+			"",
+			"Caf=E9 cr=E8me, ligne tr=E8s longue coup=E9e par un saut de ligne lo=",
+			"gique.",
+			"--inner",
+			"Content-Type: text/html; charset=utf-8",
+			"Content-Transfer-Encoding: base64",
+			"",
+			"PHA+Q2Fmw6k8L3A+",
+			"--inner--",
+			"--outer",
+			`Content-Type: text/plain; name="notes.txt"`,
+			"Content-Disposition: attachment; filename*=utf-8''notes%20d%C3%A9taill%C3%A9es.txt",
+			"",
+			"raw notes",
+			"--outer",
+			"Content-Type: application/pdf",
+			"Content-Transfer-Encoding: base64",
+			"",
+			"AAEC",
+			"--outer--",
+			"",
+		}, "\r\n")
+
+		parsed, err := ParseEmailMessage(strings.NewReader(raw))
+		assert.NoError(t, err)
+		assert.Equal(t, &Address{Name: "André", Address: "andre@example.com"}, parsed.From)
+		assert.Equal(t, "first@example.com", parsed.To)
+		assert.Equal(t, "Prix : 10 €", parsed.Subject)
+		assert.Equal(t, "Café crème, ligne très longue coupée par un saut de ligne logique.", parsed.PlainTextContent)
+		assert.Equal(t, "<p>Café</p>", parsed.HTMLContent)
+		assert.Equal(t, map[string]string{"X-Ticket": "1234"}, parsed.Headers)
+		assert.Equal(t, []Attachment{
+			{Filename: "notes détaillées.txt", ContentType: "text/plain", Content: []byte("raw notes")},
+			{Filename: "part2.pdf", ContentType: "application/pdf", Content: []byte{0, 1, 2}},
+		}, parsed.Attachments)
+	})
+
+	t.Run("Success - single part", func(t *testing.T) {
+		parsed, err := ParseEmailMessage(strings.NewReader("From: a@example.com\r\nTo: b@example.com\r\nSubject: Hi\r\n\r\nHello\r\nWorld\r\n"))
+		assert.NoError(t, err)
+		assert.Equal(t, "Hello\nWorld\n", parsed.PlainTextContent)
+		assert.Empty(t, parsed.HTMLContent)
+		assert.Nil(t, parsed.Headers)
+	})
+
+	t.Run("Failure - unsupported charset", func(t *testing.T) {
+		_, err := ParseEmailMessage(strings.NewReader("Subject: Hi\r\nContent-Type: text/plain; charset=x-unknown\r\n\r\nHello"))
+		assert.EqualError(t, err, `unsupported charset "x-unknown"`)
+	})
+
+	t.Run("Failure - missing boundary", func(t *testing.T) {
+		_, err := ParseEmailMessage(strings.NewReader("Subject: Hi\r\nContent-Type: multipart/mixed\r\n\r\nHello"))
+		assert.EqualError(t, err, "multipart/mixed part without boundary")
+	})
+
+	t.Run("Failure - not a message", func(t *testing.T) {
+		_, err := ParseEmailMessage(strings.NewReader("not a header line"))
+		assert.Error(t, err)
+	})
+}
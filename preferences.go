@@ -0,0 +1,302 @@
+package goat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+)
+
+// ErrInvalidPreferenceToken is returned when a preference token is malformed or its signature does not verify.
+var ErrInvalidPreferenceToken = errors.New("invalid preference token")
+
+// ErrRecipientOptedOut is returned by a PreferenceSender when the recipient opted out of a tag of the message.
+var ErrRecipientOptedOut = errors.New("recipient opted out")
+
+// DefaultPreferencePage is the template of the preference page when PreferenceOptions.Template is nil.
+// It is executed with a PreferencePage.
+var DefaultPreferencePage = template.Must(template.New("preferences").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><meta name="viewport" content="width=device-width, initial-scale=1"><title>Email preferences</title></head>
+<body>
+<form method="post">
+<p>Choose the emails sent to {{.Email}}:</p>
+{{- range .Choices}}
+<p><label><input type="checkbox" name="category" value="{{.Tag}}"{{if .OptedIn}} checked{{end}}> {{.Name}}</label>
+{{- with .Description}}<br><small>{{.}}</small>{{end}}</p>
+{{- end}}
+<button type="submit">Save</button>
+{{- if .Saved}}
+<p>Your preferences have been saved.</p>
+{{- end}}
+</form>
+</body>
+</html>
+`))
+
+// PreferenceCategory is a category of emails recipients can opt out of, matched against EmailMessage.Tags.
+type PreferenceCategory struct {
+	Tag         string // Tag of the messages of the category, e.g. "newsletter"
+	Name        string // Shown on the preference page
+	Description string // Optional, shown on the preference page
+}
+
+// Preference is the choice of a recipient for a category.
+type Preference struct {
+	Email     string // Lower-cased address
+	Tag       string // Tag of the category
+	OptedIn   bool
+	UpdatedAt time.Time
+}
+
+// PreferenceStore stores the preferences of the recipients.
+type PreferenceStore interface {
+	// Preferences returns the preferences of an address, without the categories it made no choice for.
+	Preferences(ctx context.Context, email string) ([]Preference, error)
+	SetPreference(ctx context.Context, preference Preference) error
+}
+
+// MemoryPreferenceStore is an in-memory PreferenceStore, for tests and single-instance services.
+type MemoryPreferenceStore struct {
+	mu          sync.RWMutex
+	preferences map[string]map[string]Preference
+}
+
+// NewMemoryPreferenceStore returns an empty MemoryPreferenceStore.
+func NewMemoryPreferenceStore() *MemoryPreferenceStore {
+	return &MemoryPreferenceStore{preferences: make(map[string]map[string]Preference)}
+}
+
+// Preferences implements the PreferenceStore interface.
+func (s *MemoryPreferenceStore) Preferences(ctx context.Context, email string) ([]Preference, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	preferences := make([]Preference, 0, len(s.preferences[email]))
+	for _, preference := range s.preferences[email] {
+		preferences = append(preferences, preference)
+	}
+	return preferences, nil
+}
+
+// SetPreference implements the PreferenceStore interface.
+func (s *MemoryPreferenceStore) SetPreference(ctx context.Context, preference Preference) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.preferences[preference.Email] == nil {
+		s.preferences[preference.Email] = make(map[string]Preference)
+	}
+	s.preferences[preference.Email][preference.Tag] = preference
+	return nil
+}
+
+// PreferenceOptions configures a PreferenceCenter.
+type PreferenceOptions struct {
+	URL        string               // https URL of the preference page, to which the token query parameter is added
+	Secret     []byte               // HMAC key signing the tokens, at least 16 bytes
+	Categories []PreferenceCategory // Categories shown on the preference page, in order
+	Template   *template.Template   // Preference page executed with a PreferencePage, DefaultPreferencePage when nil
+}
+
+// PreferenceChoice is a category with the choice of a recipient, opted in unless it opted out.
+type PreferenceChoice struct {
+	PreferenceCategory
+	OptedIn bool
+}
+
+// PreferencePage is the data of the preference page template.
+type PreferencePage struct {
+	Email   string
+	Choices []PreferenceChoice
+	Saved   bool // Whether the page is shown after saving the preferences
+}
+
+// PreferenceCenter lets recipients choose the categories of emails they receive, through a preference page
+// reached by signed links generated per recipient. It implements http.Handler to serve the page.
+type PreferenceCenter struct {
+	store      PreferenceStore
+	url        *url.URL
+	secret     []byte
+	categories []PreferenceCategory
+	template   *template.Template
+}
+
+// NewPreferenceCenter returns a preference center stored in store, a MemoryPreferenceStore when nil.
+func NewPreferenceCenter(store PreferenceStore, opts PreferenceOptions) (*PreferenceCenter, error) {
+	u, err := url.Parse(opts.URL)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return nil, fmt.Errorf("invalid preference URL %q: https URL required", opts.URL)
+	}
+	if len(opts.Secret) < 16 {
+		return nil, errors.New("preference secret must be at least 16 bytes")
+	}
+	if len(opts.Categories) == 0 {
+		return nil, errors.New("no preference category")
+	}
+	tags := make(map[string]bool, len(opts.Categories))
+	for _, category := range opts.Categories {
+		if category.Tag == "" || tags[category.Tag] {
+			return nil, fmt.Errorf("invalid or duplicate preference category tag %q", category.Tag)
+		}
+		tags[category.Tag] = true
+	}
+
+	if store == nil {
+		store = NewMemoryPreferenceStore()
+	}
+	if opts.Template == nil {
+		opts.Template = DefaultPreferencePage
+	}
+	return &PreferenceCenter{store: store, url: u, secret: opts.Secret, categories: opts.Categories, template: opts.Template}, nil
+}
+
+// Token returns the preference token of an address.
+func (c *PreferenceCenter) Token(email string) string {
+	return signedToken(c.secret, "preferences", normalizeEmail(email))
+}
+
+// VerifyToken returns the address of a preference token, or ErrInvalidPreferenceToken.
+func (c *PreferenceCenter) VerifyToken(token string) (string, error) {
+	email, ok := verifySignedToken(c.secret, "preferences", token)
+	if !ok {
+		return "", ErrInvalidPreferenceToken
+	}
+	return email, nil
+}
+
+// URL returns the preference page URL of an address.
+func (c *PreferenceCenter) URL(email string) string {
+	return tokenURL(c.url, c.Token(email))
+}
+
+// Choices returns the categories with the choices of an address.
+func (c *PreferenceCenter) Choices(ctx context.Context, email string) ([]PreferenceChoice, error) {
+	preferences, err := c.store.Preferences(ctx, normalizeEmail(email))
+	if err != nil {
+		return nil, err
+	}
+
+	choices := make([]PreferenceChoice, 0, len(c.categories))
+	for _, category := range c.categories {
+		choice := PreferenceChoice{PreferenceCategory: category, OptedIn: true}
+		for _, preference := range preferences {
+			if preference.Tag == category.Tag {
+				choice.OptedIn = preference.OptedIn
+			}
+		}
+		choices = append(choices, choice)
+	}
+	return choices, nil
+}
+
+// Set records the choice of an address for the category of the given tag.
+func (c *PreferenceCenter) Set(ctx context.Context, email, tag string, optedIn bool) error {
+	if !slices.ContainsFunc(c.categories, func(category PreferenceCategory) bool { return category.Tag == tag }) {
+		return fmt.Errorf("unknown preference category %q", tag)
+	}
+	return c.store.SetPreference(ctx, Preference{Email: normalizeEmail(email), Tag: tag, OptedIn: optedIn, UpdatedAt: timeNow()})
+}
+
+// OptedOut returns the first tag of the message whose category its recipient opted out of, or an empty string.
+// Tags without category are ignored.
+func (c *PreferenceCenter) OptedOut(ctx context.Context, message *EmailMessage) (string, error) {
+	if len(message.Tags) == 0 {
+		return "", nil
+	}
+	choices, err := c.Choices(ctx, message.To)
+	if err != nil {
+		return "", err
+	}
+	for _, choice := range choices {
+		if !choice.OptedIn && slices.Contains(message.Tags, choice.Tag) {
+			return choice.Tag, nil
+		}
+	}
+	return "", nil
+}
+
+// ServeHTTP implements the http.Handler interface.
+//
+// GET requests show the preference page of the address of the token. POST requests, sent by its form,
+// opt the address in the checked categories and out of the others. When the token does not verify,
+// it responds with a 403 status, and with a 500 status when the store returns an error.
+func (c *PreferenceCenter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	email, err := c.VerifyToken(r.URL.Query().Get("token"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	if r.Method == http.MethodPost {
+		r.Body = http.MaxBytesReader(w, r.Body, maxWebhookBodySize)
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, "invalid form", http.StatusBadRequest)
+			return
+		}
+		checked := r.PostForm["category"]
+		for _, category := range c.categories {
+			if err := c.Set(r.Context(), email, category.Tag, slices.Contains(checked, category.Tag)); err != nil {
+				http.Error(w, "preferences not saved", http.StatusInternalServerError)
+				return
+			}
+		}
+	}
+
+	choices, err := c.Choices(r.Context(), email)
+	if err != nil {
+		http.Error(w, "preferences not loaded", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_ = c.template.Execute(w, PreferencePage{Email: email, Choices: choices, Saved: r.Method == http.MethodPost})
+}
+
+// PreferenceSender implements the SenderService interface by sending through another service
+// only the messages whose recipient did not opt out of one of their tags.
+type PreferenceSender struct {
+	next   SenderService
+	center *PreferenceCenter
+	mode   SuppressionMode
+}
+
+// NewPreferenceSender returns a service checking the preferences of recipients before sending through next.
+// Messages whose recipient opted out of one of their tags fail with an error wrapping ErrRecipientOptedOut
+// with SuppressionReject, and are skipped, returning an empty SendResult, with SuppressionDrop.
+func NewPreferenceSender(next SenderService, center *PreferenceCenter, mode SuppressionMode) SenderService {
+	s := PreferenceSender{next: next, center: center, mode: mode}
+	var service SenderService = &s
+	return service
+}
+
+// Send sends an email unless its recipient opted out of one of its tags.
+func (s *PreferenceSender) Send(message *EmailMessage) error {
+	_, err := s.SendWithResult(message)
+	return err
+}
+
+// SendWithResult sends an email unless its recipient opted out of one of its tags.
+func (s *PreferenceSender) SendWithResult(message *EmailMessage) (SendResult, error) {
+	tag, err := s.center.OptedOut(context.Background(), message)
+	if err != nil {
+		return SendResult{}, fmt.Errorf("checking preferences: %w", err)
+	}
+	if tag != "" {
+		if s.mode == SuppressionDrop {
+			return SendResult{}, nil
+		}
+		return SendResult{}, fmt.Errorf("%w: %s (%s)", ErrRecipientOptedOut, normalizeEmail(message.To), tag)
+	}
+	return s.next.SendWithResult(message)
+}
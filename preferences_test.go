@@ -0,0 +1,285 @@
+package goat
+
+import (
+	"context"
+	"errors"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// MockPreferenceStore is a mock implementation of the PreferenceStore interface
+type MockPreferenceStore struct {
+	err error
+}
+
+func (m *MockPreferenceStore) Preferences(ctx context.Context, email string) ([]Preference, error) {
+	return nil, m.err
+}
+
+func (m *MockPreferenceStore) SetPreference(ctx context.Context, preference Preference) error {
+	return m.err
+}
+
+// preferenceCategories are the categories of the test PreferenceCenters.
+var preferenceCategories = []PreferenceCategory{
+	{Tag: "newsletter", Name: "Newsletter", Description: "Our monthly news"},
+	{Tag: "offers", Name: "Special offers"},
+}
+
+// newTestPreferenceCenter returns a PreferenceCenter with the test categories stored in store.
+func newTestPreferenceCenter(t *testing.T, store PreferenceStore) *PreferenceCenter {
+	center, err := NewPreferenceCenter(store, PreferenceOptions{
+		URL:        "https://example.com/preferences",
+		Secret:     []byte("0123456789abcdef"),
+		Categories: preferenceCategories,
+	})
+	assert.NoError(t, err)
+	return center
+}
+
+// TestNewPreferenceCenter tests the NewPreferenceCenter function
+func TestNewPreferenceCenter(t *testing.T) {
+	t.Run("Success - defaults", func(t *testing.T) {
+		center := newTestPreferenceCenter(t, nil)
+		assert.IsType(t, &MemoryPreferenceStore{}, center.store)
+		assert.Equal(t, DefaultPreferencePage, center.template)
+	})
+
+	secret := []byte("0123456789abcdef")
+	tests := []struct {
+		name     string
+		opts     PreferenceOptions
+		expected string
+	}{
+		{"http URL", PreferenceOptions{URL: "http://example.com/preferences", Secret: secret, Categories: preferenceCategories}, `invalid preference URL "http://example.com/preferences": https URL required`},
+		{"short secret", PreferenceOptions{URL: "https://example.com/preferences", Secret: []byte("secret"), Categories: preferenceCategories}, "preference secret must be at least 16 bytes"},
+		{"no category", PreferenceOptions{URL: "https://example.com/preferences", Secret: secret}, "no preference category"},
+		{"duplicate category", PreferenceOptions{URL: "https://example.com/preferences", Secret: secret, Categories: []PreferenceCategory{{Tag: "news"}, {Tag: "news"}}}, `invalid or duplicate preference category tag "news"`},
+		{"empty tag", PreferenceOptions{URL: "https://example.com/preferences", Secret: secret, Categories: []PreferenceCategory{{Name: "News"}}}, `invalid or duplicate preference category tag ""`},
+	}
+
+	for _, tt := range tests {
+		t.Run("Failure - "+tt.name, func(t *testing.T) {
+			_, err := NewPreferenceCenter(nil, tt.opts)
+			assert.EqualError(t, err, tt.expected)
+		})
+	}
+}
+
+// TestPreferenceCenter_VerifyToken tests the VerifyToken method of PreferenceCenter
+func TestPreferenceCenter_VerifyToken(t *testing.T) {
+	center := newTestPreferenceCenter(t, nil)
+
+	t.Run("Success", func(t *testing.T) {
+		email, err := center.VerifyToken(center.Token("User@Example.com"))
+		assert.NoError(t, err)
+		assert.Equal(t, "user@example.com", email)
+		assert.Equal(t, "https://example.com/preferences?token="+center.Token("user@example.com"), center.URL("user@example.com"))
+	})
+
+	t.Run("Failure - unsubscribe token", func(t *testing.T) {
+		unsubscriber, _ := NewUnsubscriber(UnsubscribeOptions{URL: "https://example.com/unsubscribe", Secret: []byte("0123456789abcdef")})
+		_, err := center.VerifyToken(unsubscriber.Token("user@example.com"))
+		assert.ErrorIs(t, err, ErrInvalidPreferenceToken)
+	})
+}
+
+// TestPreferenceCenter tests the Set, Choices and OptedOut methods of PreferenceCenter
+func TestPreferenceCenter(t *testing.T) {
+	now := time.Date(2026, 3, 14, 15, 9, 26, 0, time.UTC)
+	withTimeNow(t, now)
+	ctx := context.Background()
+
+	t.Run("Success - opted in by default", func(t *testing.T) {
+		center := newTestPreferenceCenter(t, nil)
+
+		choices, err := center.Choices(ctx, "user@example.com")
+		assert.NoError(t, err)
+		assert.Equal(t, []PreferenceChoice{
+			{PreferenceCategory: preferenceCategories[0], OptedIn: true},
+			{PreferenceCategory: preferenceCategories[1], OptedIn: true},
+		}, choices)
+	})
+
+	t.Run("Success - opted out", func(t *testing.T) {
+		store := NewMemoryPreferenceStore()
+		center := newTestPreferenceCenter(t, store)
+		assert.NoError(t, center.Set(ctx, "User@Example.com", "offers", false))
+
+		preferences, _ := store.Preferences(ctx, "user@example.com")
+		assert.Equal(t, []Preference{{Email: "user@example.com", Tag: "offers", OptedIn: false, UpdatedAt: now}}, preferences)
+
+		choices, err := center.Choices(ctx, "user@example.com")
+		assert.NoError(t, err)
+		assert.True(t, choices[0].OptedIn)
+		assert.False(t, choices[1].OptedIn)
+
+		tag, err := center.OptedOut(ctx, NewEmailMessage("user@example.com", "Sale", "Text", "").WithTags("promo", "offers"))
+		assert.NoError(t, err)
+		assert.Equal(t, "offers", tag)
+
+		tag, err = center.OptedOut(ctx, NewEmailMessage("user@example.com", "News", "Text", "").WithTags("newsletter"))
+		assert.NoError(t, err)
+		assert.Empty(t, tag)
+
+		tag, err = center.OptedOut(ctx, NewEmailMessage("other@example.com", "Sale", "Text", "").WithTags("offers"))
+		assert.NoError(t, err)
+		assert.Empty(t, tag)
+	})
+
+	t.Run("Failure - unknown category", func(t *testing.T) {
+		center := newTestPreferenceCenter(t, nil)
+		assert.EqualError(t, center.Set(ctx, "user@example.com", "promo", false), `unknown preference category "promo"`)
+	})
+
+	t.Run("Failure - opted out", func(t *testing.T) {
+		center := newTestPreferenceCenter(t, nil)
+		assert.NoError(t, center.Set(ctx, "user@example.com", "offers", false))
+		mock := NewMockSenderService()
+		sender := NewPreferenceSender(mock, center, SuppressionReject)
+
+		result, err := sender.SendWithResult(NewEmailMessage("User@Example.com", "Sale", "Text", "").WithTags("offers"))
+		assert.ErrorIs(t, err, ErrRecipientOptedOut)
+		assert.EqualError(t, err, "recipient opted out: user@example.com (offers)")
+		assert.Equal(t, SendResult{}, result)
+		assert.Empty(t, mock.GetSendCalls())
+	})
+
+	t.Run("Failure - store error", func(t *testing.T) {
+		center := newTestPreferenceCenter(t, &MockPreferenceStore{err: errors.New("database unavailable")})
+		_, err := center.OptedOut(ctx, NewEmailMessage("user@example.com", "Sale", "Text", "").WithTags("offers"))
+		assert.EqualError(t, err, "database unavailable")
+	})
+}
+
+// TestPreferenceCenter_ServeHTTP tests the ServeHTTP method of PreferenceCenter
+func TestPreferenceCenter_ServeHTTP(t *testing.T) {
+	newRequest := func(center *PreferenceCenter, method, body string) *http.Request {
+		req := httptest.NewRequest(method, "/preferences?token="+center.Token("user@example.com"), strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return req
+	}
+
+	t.Run("Success - page", func(t *testing.T) {
+		center := newTestPreferenceCenter(t, nil)
+
+		rec := httptest.NewRecorder()
+		center.ServeHTTP(rec, newRequest(center, http.MethodGet, ""))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "text/html; charset=utf-8", rec.Header().Get("Content-Type"))
+		assert.Contains(t, rec.Body.String(), "user@example.com")
+		assert.Contains(t, rec.Body.String(), `value="offers" checked> Special offers`)
+		assert.Contains(t, rec.Body.String(), "<small>Our monthly news</small>")
+		assert.NotContains(t, rec.Body.String(), "saved")
+	})
+
+	t.Run("Success - save", func(t *testing.T) {
+		center := newTestPreferenceCenter(t, nil)
+
+		rec := httptest.NewRecorder()
+		center.ServeHTTP(rec, newRequest(center, http.MethodPost, "category=newsletter"))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `value="newsletter" checked>`)
+		assert.Contains(t, rec.Body.String(), `value="offers"> Special offers`)
+		assert.Contains(t, rec.Body.String(), "Your preferences have been saved.")
+
+		choices, _ := center.Choices(context.Background(), "user@example.com")
+		assert.False(t, choices[1].OptedIn)
+	})
+
+	t.Run("Success - custom template", func(t *testing.T) {
+		center, err := NewPreferenceCenter(nil, PreferenceOptions{
+			URL:        "https://example.com/preferences",
+			Secret:     []byte("0123456789abcdef"),
+			Categories: preferenceCategories,
+			Template:   template.Must(template.New("page").Parse(`{{range .Choices}}{{.Tag}}={{.OptedIn}};{{end}}`)),
+		})
+		assert.NoError(t, err)
+
+		rec := httptest.NewRecorder()
+		center.ServeHTTP(rec, newRequest(center, http.MethodGet, ""))
+		assert.Equal(t, "newsletter=true;offers=true;", rec.Body.String())
+	})
+
+	tests := []struct {
+		name    string
+		store   PreferenceStore
+		request func(center *PreferenceCenter) *http.Request
+		status  int
+	}{
+		{"method not allowed", nil, func(center *PreferenceCenter) *http.Request {
+			return newRequest(center, http.MethodPut, "")
+		}, http.StatusMethodNotAllowed},
+		{"invalid token", nil, func(center *PreferenceCenter) *http.Request {
+			return httptest.NewRequest(http.MethodGet, "/preferences?token=forged", nil)
+		}, http.StatusForbidden},
+		{"invalid form", nil, func(center *PreferenceCenter) *http.Request {
+			return newRequest(center, http.MethodPost, "category=%zz")
+		}, http.StatusBadRequest},
+		{"store error on save", &MockPreferenceStore{err: errors.New("database unavailable")}, func(center *PreferenceCenter) *http.Request {
+			return newRequest(center, http.MethodPost, "category=offers")
+		}, http.StatusInternalServerError},
+		{"store error on load", &MockPreferenceStore{err: errors.New("database unavailable")}, func(center *PreferenceCenter) *http.Request {
+			return newRequest(center, http.MethodGet, "")
+		}, http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run("Failure - "+tt.name, func(t *testing.T) {
+			center := newTestPreferenceCenter(t, tt.store)
+
+			rec := httptest.NewRecorder()
+			center.ServeHTTP(rec, tt.request(center))
+			assert.Equal(t, tt.status, rec.Code)
+		})
+	}
+}
+
+// TestPreferenceSender tests the SendWithResult method of PreferenceSender
+func TestPreferenceSender(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Success - sent", func(t *testing.T) {
+		center := newTestPreferenceCenter(t, nil)
+		assert.NoError(t, center.Set(ctx, "user@example.com", "offers", false))
+		mock := NewMockSenderService()
+		mock.SendWithResultFunc = func(message *EmailMessage) (SendResult, error) {
+			return SendResult{MessageID: "<id@example.com>"}, nil
+		}
+		sender := NewPreferenceSender(mock, center, SuppressionReject)
+
+		result, err := sender.SendWithResult(NewEmailMessage("user@example.com", "News", "Text", "").WithTags("newsletter"))
+		assert.NoError(t, err)
+		assert.Equal(t, "<id@example.com>", result.MessageID)
+		assert.NoError(t, sender.Send(NewEmailMessage("user@example.com", "Receipt", "Text", "")))
+		assert.Len(t, mock.GetSendCalls(), 2)
+	})
+
+	t.Run("Success - dropped", func(t *testing.T) {
+		center := newTestPreferenceCenter(t, nil)
+		assert.NoError(t, center.Set(ctx, "user@example.com", "offers", false))
+		mock := NewMockSenderService()
+		sender := NewPreferenceSender(mock, center, SuppressionDrop)
+
+		result, err := sender.SendWithResult(NewEmailMessage("user@example.com", "Sale", "Text", "").WithTags("offers"))
+		assert.NoError(t, err)
+		assert.Equal(t, SendResult{}, result)
+		assert.Empty(t, mock.GetSendCalls())
+	})
+
+	t.Run("Failure - store error", func(t *testing.T) {
+		center := newTestPreferenceCenter(t, &MockPreferenceStore{err: errors.New("database unavailable")})
+		mock := NewMockSenderService()
+		sender := NewPreferenceSender(mock, center, SuppressionReject)
+
+		err := sender.Send(NewEmailMessage("user@example.com", "Sale", "Text", "").WithTags("offers"))
+		assert.EqualError(t, err, "checking preferences: database unavailable")
+		assert.Empty(t, mock.GetSendCalls())
+	})
+}
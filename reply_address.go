@@ -0,0 +1,131 @@
+package goat
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/mail"
+	"regexp"
+	"strings"
+)
+
+// DefaultReplyAddressPrefix is the local part prefix of reply addresses when ReplyAddressOptions.Prefix is empty.
+const DefaultReplyAddressPrefix = "reply"
+
+// replySignatureLength is the length of the hex encoded signature of reply addresses (64 bits).
+const replySignatureLength = 16
+
+// ErrInvalidReplyAddress is returned when an address is not a reply address or its signature does not verify.
+var ErrInvalidReplyAddress = errors.New("invalid reply address")
+
+var (
+	// replyAddressPrefix matches a valid reply address prefix.
+	replyAddressPrefix = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+	// replyEntityID matches a valid entity ID, which must not contain the "." separating the signature.
+	replyEntityID = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+)
+
+// ReplyAddressOptions configures a ReplyAddresser.
+type ReplyAddressOptions struct {
+	Domain string // Domain receiving the replies, e.g. the inbound parsing domain "in.example.com"
+	Secret []byte // HMAC key signing the entity IDs, at least 16 bytes
+	Prefix string // Local part prefix, defaults to DefaultReplyAddressPrefix
+}
+
+// ReplyAddresser generates and verifies VERP-style reply addresses encoding a signed entity ID,
+// e.g. reply+ticket123.3f2a9c0d1e4b5a67@in.example.com, to route replies without parsing subjects.
+//
+// Since some mail servers change the case of local parts, entity IDs are signed and verified case-insensitively.
+type ReplyAddresser struct {
+	domain string
+	secret []byte
+	prefix string
+}
+
+// NewReplyAddresser returns a ReplyAddresser for the given options.
+func NewReplyAddresser(opts ReplyAddressOptions) (*ReplyAddresser, error) {
+	if opts.Domain == "" || !IsEmailValid("reply@"+opts.Domain) {
+		return nil, fmt.Errorf("invalid reply address domain %q", opts.Domain)
+	}
+	if len(opts.Secret) < 16 {
+		return nil, errors.New("reply address secret must be at least 16 bytes")
+	}
+	if opts.Prefix == "" {
+		opts.Prefix = DefaultReplyAddressPrefix
+	}
+	if !replyAddressPrefix.MatchString(opts.Prefix) {
+		return nil, fmt.Errorf("invalid reply address prefix %q", opts.Prefix)
+	}
+	return &ReplyAddresser{domain: opts.Domain, secret: opts.Secret, prefix: opts.Prefix}, nil
+}
+
+// Address returns the reply address of the entity. Entity IDs are made of letters, digits, "-" and "_",
+// and short enough for the local part to fit in 64 characters.
+func (a *ReplyAddresser) Address(entityID string) (string, error) {
+	if !replyEntityID.MatchString(entityID) {
+		return "", fmt.Errorf("invalid reply entity ID %q", entityID)
+	}
+	local := a.prefix + "+" + entityID + "." + a.sign(entityID)
+	if len(local) > 64 {
+		return "", fmt.Errorf("reply entity ID %q too long", entityID)
+	}
+	return local + "@" + a.domain, nil
+}
+
+// Verify returns the entity ID of a reply address, given as a bare address or with a display name.
+// It returns ErrInvalidReplyAddress when the address is not a reply address of a, or is not signed by it.
+func (a *ReplyAddresser) Verify(address string) (string, error) {
+	parsed, err := mail.ParseAddress(address)
+	if err != nil {
+		return "", ErrInvalidReplyAddress
+	}
+
+	at := strings.LastIndex(parsed.Address, "@")
+	local, domain := parsed.Address[:at], parsed.Address[at+1:]
+	prefix := a.prefix + "+"
+	if !strings.EqualFold(domain, a.domain) || len(local) <= len(prefix) || !strings.EqualFold(local[:len(prefix)], prefix) {
+		return "", ErrInvalidReplyAddress
+	}
+
+	entityID, signature, found := strings.Cut(local[len(prefix):], ".")
+	if !found || !replyEntityID.MatchString(entityID) ||
+		!hmac.Equal([]byte(strings.ToLower(signature)), []byte(a.sign(entityID))) {
+		return "", ErrInvalidReplyAddress
+	}
+	return entityID, nil
+}
+
+// VerifyEmail returns the entity ID of the first reply address among the recipients of the email:
+// its envelope recipients, then its To and Cc addresses. It returns ErrInvalidReplyAddress when there is none.
+func (a *ReplyAddresser) VerifyEmail(email *InboundEmail) (string, error) {
+	addresses := append([]string{}, email.Recipients...)
+	for _, address := range append(append([]Address{}, email.To...), email.Cc...) {
+		addresses = append(addresses, address.Address)
+	}
+
+	for _, address := range addresses {
+		if entityID, err := a.Verify(address); err == nil {
+			return entityID, nil
+		}
+	}
+	return "", ErrInvalidReplyAddress
+}
+
+// sign returns the truncated hex encoded HMAC-SHA256 of the entity ID, bound to the prefix.
+func (a *ReplyAddresser) sign(entityID string) string {
+	mac := hmac.New(sha256.New, a.secret)
+	mac.Write([]byte(strings.ToLower(a.prefix + "+" + entityID)))
+	return hex.EncodeToString(mac.Sum(nil))[:replySignatureLength]
+}
+
+// WithReplyAddress sets the reply-to address to the reply address of the entity, with the given display name,
+// and returns the message for chaining.
+func (m *EmailMessage) WithReplyAddress(addresser *ReplyAddresser, name, entityID string) (*EmailMessage, error) {
+	address, err := addresser.Address(entityID)
+	if err != nil {
+		return m, err
+	}
+	return m.WithReplyTo(name, address), nil
+}
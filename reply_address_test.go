@@ -0,0 +1,172 @@
+package goat
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// replyAddressSecret is the secret of the test ReplyAddressers.
+var replyAddressSecret = []byte("0123456789abcdef")
+
+// TestNewReplyAddresser tests the NewReplyAddresser function
+func TestNewReplyAddresser(t *testing.T) {
+	t.Run("Success - default prefix", func(t *testing.T) {
+		addresser, err := NewReplyAddresser(ReplyAddressOptions{Domain: "in.example.com", Secret: replyAddressSecret})
+		assert.NoError(t, err)
+		assert.Equal(t, DefaultReplyAddressPrefix, addresser.prefix)
+	})
+
+	tests := []struct {
+		name     string
+		opts     ReplyAddressOptions
+		expected string
+	}{
+		{"missing domain", ReplyAddressOptions{Secret: replyAddressSecret}, `invalid reply address domain ""`},
+		{"invalid domain", ReplyAddressOptions{Domain: "in example.com", Secret: replyAddressSecret}, `invalid reply address domain "in example.com"`},
+		{"short secret", ReplyAddressOptions{Domain: "in.example.com", Secret: []byte("secret")}, "reply address secret must be at least 16 bytes"},
+		{"invalid prefix", ReplyAddressOptions{Domain: "in.example.com", Secret: replyAddressSecret, Prefix: "re+ply"}, `invalid reply address prefix "re+ply"`},
+	}
+
+	for _, tt := range tests {
+		t.Run("Failure - "+tt.name, func(t *testing.T) {
+			_, err := NewReplyAddresser(tt.opts)
+			assert.EqualError(t, err, tt.expected)
+		})
+	}
+}
+
+// TestReplyAddresser_Address tests the Address method of ReplyAddresser
+func TestReplyAddresser_Address(t *testing.T) {
+	addresser, _ := NewReplyAddresser(ReplyAddressOptions{Domain: "in.example.com", Secret: replyAddressSecret})
+
+	t.Run("Success", func(t *testing.T) {
+		address, err := addresser.Address("ticket123")
+		assert.NoError(t, err)
+		assert.Regexp(t, `^reply\+ticket123\.[0-9a-f]{16}@in\.example\.com$`, address)
+		assert.True(t, IsEmailValid(address))
+
+		again, _ := addresser.Address("ticket123")
+		assert.Equal(t, address, again)
+		other, _ := addresser.Address("ticket124")
+		assert.NotEqual(t, address, other)
+	})
+
+	tests := []struct {
+		name     string
+		entityID string
+		expected string
+	}{
+		{"empty", "", `invalid reply entity ID ""`},
+		{"separator", "ticket.123", `invalid reply entity ID "ticket.123"`},
+		{"too long", strings.Repeat("a", 50), "too long"},
+	}
+
+	for _, tt := range tests {
+		t.Run("Failure - "+tt.name, func(t *testing.T) {
+			_, err := addresser.Address(tt.entityID)
+			assert.ErrorContains(t, err, tt.expected)
+		})
+	}
+}
+
+// TestReplyAddresser_Verify tests the Verify method of ReplyAddresser
+func TestReplyAddresser_Verify(t *testing.T) {
+	addresser, _ := NewReplyAddresser(ReplyAddressOptions{Domain: "in.example.com", Secret: replyAddressSecret})
+	otherSecret, _ := NewReplyAddresser(ReplyAddressOptions{Domain: "in.example.com", Secret: []byte("fedcba9876543210")})
+	otherPrefix, _ := NewReplyAddresser(ReplyAddressOptions{Domain: "in.example.com", Secret: replyAddressSecret, Prefix: "ticket"})
+	address, _ := addresser.Address("Ticket_123")
+
+	successes := []struct {
+		name    string
+		address string
+	}{
+		{"bare address", address},
+		{"with display name", `"Support" <` + address + ">"},
+		{"case changed", strings.ToUpper(address)},
+	}
+
+	for _, tt := range successes {
+		t.Run("Success - "+tt.name, func(t *testing.T) {
+			entityID, err := addresser.Verify(tt.address)
+			assert.NoError(t, err)
+			assert.True(t, strings.EqualFold("Ticket_123", entityID))
+		})
+	}
+
+	local := address[:strings.Index(address, "@")]
+	failures := []struct {
+		name    string
+		address string
+	}{
+		{"not an address", "not an address"},
+		{"other domain", local + "@example.com"},
+		{"no prefix", "support@in.example.com"},
+		{"no signature", "reply+Ticket_123@in.example.com"},
+		{"tampered entity ID", strings.Replace(address, "Ticket_123", "Ticket_124", 1)},
+		{"other secret", mustReplyAddress(t, otherSecret, "Ticket_123")},
+		{"other prefix", mustReplyAddress(t, otherPrefix, "Ticket_123")},
+	}
+
+	for _, tt := range failures {
+		t.Run("Failure - "+tt.name, func(t *testing.T) {
+			_, err := addresser.Verify(tt.address)
+			assert.ErrorIs(t, err, ErrInvalidReplyAddress)
+		})
+	}
+}
+
+// TestReplyAddresser_VerifyEmail tests the VerifyEmail method of ReplyAddresser
+func TestReplyAddresser_VerifyEmail(t *testing.T) {
+	addresser, _ := NewReplyAddresser(ReplyAddressOptions{Domain: "in.example.com", Secret: replyAddressSecret})
+	address := mustReplyAddress(t, addresser, "ticket123")
+
+	t.Run("Success - envelope recipient", func(t *testing.T) {
+		entityID, err := addresser.VerifyEmail(&InboundEmail{
+			Recipients: []string{"support@example.com", address},
+			To:         []Address{{Address: "support@example.com"}},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "ticket123", entityID)
+	})
+
+	t.Run("Success - Cc address", func(t *testing.T) {
+		entityID, err := addresser.VerifyEmail(&InboundEmail{
+			To: []Address{{Address: "support@example.com"}},
+			Cc: []Address{{Name: "Support", Address: address}},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "ticket123", entityID)
+	})
+
+	t.Run("Failure - no reply address", func(t *testing.T) {
+		_, err := addresser.VerifyEmail(&InboundEmail{To: []Address{{Address: "reply+ticket123.0000000000000000@in.example.com"}}})
+		assert.ErrorIs(t, err, ErrInvalidReplyAddress)
+	})
+}
+
+// TestEmailMessage_WithReplyAddress tests the WithReplyAddress method of EmailMessage
+func TestEmailMessage_WithReplyAddress(t *testing.T) {
+	addresser, _ := NewReplyAddresser(ReplyAddressOptions{Domain: "in.example.com", Secret: replyAddressSecret})
+
+	t.Run("Success", func(t *testing.T) {
+		msg, err := NewEmailMessage("test@example.com", "Subject", "Text", "").WithReplyAddress(addresser, "Support", "ticket123")
+		assert.NoError(t, err)
+		assert.Equal(t, &ReplyTo{Name: "Support", Address: mustReplyAddress(t, addresser, "ticket123")}, msg.ReplyTo)
+		assert.NoError(t, msg.Validate())
+	})
+
+	t.Run("Failure - invalid entity ID", func(t *testing.T) {
+		msg, err := NewEmailMessage("test@example.com", "Subject", "Text", "").WithReplyAddress(addresser, "Support", "ticket 123")
+		assert.EqualError(t, err, `invalid reply entity ID "ticket 123"`)
+		assert.Nil(t, msg.ReplyTo)
+	})
+}
+
+// mustReplyAddress returns the reply address of the entity, failing the test on error.
+func mustReplyAddress(t *testing.T, addresser *ReplyAddresser, entityID string) string {
+	address, err := addresser.Address(entityID)
+	assert.NoError(t, err)
+	return address
+}
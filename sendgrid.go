@@ -17,15 +17,19 @@ type SendgridClient interface {
 
 // SendgridService implements the SenderService interface using SendGrid
 type SendgridService struct {
-	client SendgridClient
-	from   *mail.Email
+	client  SendgridClient
+	from    *mail.Email
+	limits  SizeLimits
+	headers HeaderPolicy
 }
 
 // NewSendgridService returns a new instance of SendgridService
 func NewSendgridService(apiKey, senderName, senderEmail string) SenderService {
 	s := SendgridService{
-		client: sendgrid.NewSendClient(apiKey),
-		from:   mail.NewEmail(senderName, senderEmail),
+		client:  sendgrid.NewSendClient(apiKey),
+		from:    mail.NewEmail(senderName, senderEmail),
+		limits:  SendgridSizeLimits,
+		headers: SendgridHeaderPolicy,
 	}
 	var service SenderService = &s
 	return service
@@ -46,22 +50,47 @@ func (s *SendgridService) Send(message *EmailMessage) error {
 // SendGrid returns the message ID in the X-Message-Id response header rather
 // than the body; the returned SendResult.MessageID holds that value (empty if
 // the header is absent).
+//
+// Messages are validated (see EmailMessage.Validate) and those exceeding SendgridSizeLimits
+// are rejected with ErrMessageTooLarge before calling the API. Custom headers must be allowed
+// by SendgridHeaderPolicy; the threading fields are sent as headers and the tags as categories.
+// The From address of the message, when set, overrides the configured sender.
+// Messages with transformers are rejected with ErrTransformersUnsupported.
 func (s *SendgridService) SendWithResult(message *EmailMessage) (SendResult, error) {
+	if len(message.Transformers) > 0 {
+		return SendResult{}, ErrTransformersUnsupported
+	}
+	if err := message.Validate(); err != nil {
+		return SendResult{}, err
+	}
+	if err := checkMessageSize(message, s.limits); err != nil {
+		return SendResult{}, err
+	}
+
+	from := s.from
+	if message.From != nil {
+		from = mail.NewEmail(message.From.Name, message.From.Address)
+	}
 	to := mail.NewEmail(message.To, message.To)
-	msg := mail.NewSingleEmail(s.from, message.Subject, to, message.PlainTextContent, message.HTMLContent)
+	msg := mail.NewSingleEmail(from, message.Subject, to, message.PlainTextContent, message.HTMLContent)
 
 	if message.ReplyTo != nil && message.ReplyTo.Address != "" {
 		msg.SetReplyTo(mail.NewEmail(message.ReplyTo.Name, message.ReplyTo.Address))
 	}
 
-	for k, v := range message.Headers {
+	headers, err := s.headers.Sanitize(message.Headers)
+	if err != nil {
+		return SendResult{}, err
+	}
+	for k, v := range message.withThreadingHeaders(headers) {
 		msg.SetHeader(k, v)
 	}
+	msg.AddCategories(message.Tags...)
 
 	for _, a := range message.Attachments {
 		att := mail.NewAttachment()
 		att.SetContent(base64.StdEncoding.EncodeToString(a.Content))
-		att.SetType(a.ContentType)
+		att.SetType(a.mediaType())
 		att.SetFilename(a.Filename)
 		if a.ContentID != "" {
 			att.SetContentID(a.ContentID)
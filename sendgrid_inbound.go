@@ -0,0 +1,199 @@
+package goat
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/mail"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SendgridInboundOptions configures a SendgridInboundHandler.
+type SendgridInboundOptions struct {
+	Username string // Basic auth credentials expected when Username is set, as configured in the Inbound Parse URL
+	Password string
+}
+
+// SendgridInboundHandler is an http.Handler receiving emails from SendGrid Inbound Parse.
+type SendgridInboundHandler struct {
+	onEmail func(ctx context.Context, email *InboundEmail) error
+	opts    SendgridInboundOptions
+}
+
+// NewSendgridInboundHandler returns a handler for SendGrid Inbound Parse, calling onEmail with the email of
+// each multipart request, whether the parse setting posts parsed fields (the default) or the raw MIME message.
+// Inbound Parse cannot sign its requests: set opts.Username to require the basic auth credentials embedded
+// in the configured URL. SendGrid keeps retrying an email answered with a 500 status, as when onEmail fails,
+// for up to three days before dropping it.
+func NewSendgridInboundHandler(onEmail func(ctx context.Context, email *InboundEmail) error, opts SendgridInboundOptions) *SendgridInboundHandler {
+	return &SendgridInboundHandler{onEmail: onEmail, opts: opts}
+}
+
+// ServeHTTP implements the http.Handler interface.
+func (h *SendgridInboundHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !basicAuthorized(r, h.opts.Username, h.opts.Password) {
+		w.Header().Set("WWW-Authenticate", `Basic realm="inbound"`)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxInboundBodySize)
+	if err := r.ParseMultipartForm(maxInboundBodySize); err != nil {
+		http.Error(w, fmt.Sprintf("invalid SendGrid inbound request: %v", err), http.StatusBadRequest)
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	email, err := ParseSendgridInbound(r.MultipartForm)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := h.onEmail(r.Context(), email); err != nil {
+		http.Error(w, "email not processed", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// sendgridAttachmentInfo is an entry of the attachment-info field of a SendGrid Inbound Parse request.
+type sendgridAttachmentInfo struct {
+	Filename  string `json:"filename"`
+	Name      string `json:"name"`
+	Type      string `json:"type"`
+	ContentID string `json:"content-id"`
+}
+
+// ParseSendgridInbound reads the multipart form of a SendGrid Inbound Parse request into an InboundEmail.
+// In raw mode, the "email" field is parsed with ParseEmailMessage; otherwise the email is built from the
+// "headers", "text" and "html" fields, converted to UTF-8 according to "charsets", and the attachment files.
+func ParseSendgridInbound(form *multipart.Form) (*InboundEmail, error) {
+	value := func(key string) string {
+		if values := form.Value[key]; len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	}
+
+	var email *InboundEmail
+	var err error
+	if raw := value("email"); raw != "" {
+		if email, err = parseInboundRaw([]byte(raw)); err != nil {
+			return nil, fmt.Errorf("invalid SendGrid inbound email: %w", err)
+		}
+	} else {
+		if email, err = parseSendgridInboundFields(value); err != nil {
+			return nil, err
+		}
+		if email.Attachments, err = parseSendgridInboundAttachments(form, value("attachment-info")); err != nil {
+			return nil, err
+		}
+	}
+	email.Provider = ProviderSendgrid
+
+	if v := value("envelope"); v != "" {
+		var envelope struct {
+			To []string `json:"to"`
+		}
+		if err := json.Unmarshal([]byte(v), &envelope); err != nil {
+			return nil, fmt.Errorf("invalid SendGrid inbound envelope: %w", err)
+		}
+		email.Recipients = envelope.To
+	}
+	if v := value("spam_score"); v != "" {
+		email.SpamScore, _ = strconv.ParseFloat(v, 64)
+	}
+	return email, nil
+}
+
+// parseSendgridInboundFields builds an email from the header and body fields of a parsed mode request.
+func parseSendgridInboundFields(value func(key string) string) (*InboundEmail, error) {
+	charsets := map[string]string{}
+	if v := value("charsets"); v != "" {
+		if err := json.Unmarshal([]byte(v), &charsets); err != nil {
+			return nil, fmt.Errorf("invalid SendGrid inbound charsets: %w", err)
+		}
+	}
+
+	headers := strings.TrimRight(value("headers"), "\r\n") + "\r\n\r\n"
+	msg, err := mail.ReadMessage(strings.NewReader(headers))
+	if err != nil {
+		return nil, fmt.Errorf("invalid SendGrid inbound headers: %w", err)
+	}
+
+	email := &InboundEmail{}
+	email.setHeader(msg.Header)
+	if email.Subject == "" {
+		email.Subject = value("subject")
+	}
+	if email.Text, err = decodeText(charsets["text"], []byte(value("text"))); err != nil {
+		return nil, fmt.Errorf("invalid SendGrid inbound text: %w", err)
+	}
+	if email.HTML, err = decodeText(charsets["html"], []byte(value("html"))); err != nil {
+		return nil, fmt.Errorf("invalid SendGrid inbound html: %w", err)
+	}
+	return email, nil
+}
+
+// parseSendgridInboundAttachments reads the attachment files of a parsed mode request, in order.
+func parseSendgridInboundAttachments(form *multipart.Form, attachmentInfo string) ([]Attachment, error) {
+	infos := map[string]sendgridAttachmentInfo{}
+	if attachmentInfo != "" {
+		if err := json.Unmarshal([]byte(attachmentInfo), &infos); err != nil {
+			return nil, fmt.Errorf("invalid SendGrid inbound attachment-info: %w", err)
+		}
+	}
+
+	// attachment1, attachment2... sorted by number
+	keys := make([]string, 0, len(form.File))
+	for key := range form.File {
+		if strings.HasPrefix(key, "attachment") {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, _ := strconv.Atoi(strings.TrimPrefix(keys[i], "attachment"))
+		b, _ := strconv.Atoi(strings.TrimPrefix(keys[j], "attachment"))
+		return a < b
+	})
+
+	attachments := make([]Attachment, 0, len(keys))
+	for _, key := range keys {
+		file := form.File[key][0]
+		f, err := file.Open()
+		if err != nil {
+			return nil, fmt.Errorf("reading SendGrid inbound %s: %w", key, err)
+		}
+		content, err := io.ReadAll(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("reading SendGrid inbound %s: %w", key, err)
+		}
+
+		info := infos[key]
+		attachment := Attachment{
+			Filename:    info.Filename,
+			ContentType: info.Type,
+			Content:     content,
+			ContentID:   info.ContentID,
+		}
+		if attachment.Filename == "" {
+			attachment.Filename = file.Filename
+		}
+		if attachment.ContentType == "" {
+			attachment.ContentType = file.Header.Get("Content-Type")
+		}
+		attachments = append(attachments, attachment)
+	}
+	return attachments, nil
+}
@@ -0,0 +1,187 @@
+package goat
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// sendgridInboundFile is an attachment file of a SendGrid Inbound Parse test request.
+type sendgridInboundFile struct {
+	field, filename, contentType, content string
+}
+
+// newSendgridInboundRequest returns a SendGrid Inbound Parse request with the fields and files.
+func newSendgridInboundRequest(t *testing.T, fields map[string]string, files ...sendgridInboundFile) *http.Request {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	for key, value := range fields {
+		assert.NoError(t, w.WriteField(key, value))
+	}
+	for _, file := range files {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Disposition", `form-data; name="`+file.field+`"; filename="`+file.filename+`"`)
+		header.Set("Content-Type", file.contentType)
+		part, err := w.CreatePart(header)
+		assert.NoError(t, err)
+		_, err = part.Write([]byte(file.content))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, w.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/inbound/sendgrid", &body)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	return req
+}
+
+// sendgridParsedFields are the fields of a parsed mode SendGrid Inbound Parse request.
+var sendgridParsedFields = map[string]string{
+	"headers": "From: Renee <renee@example.com>\nTo: support@example.com\nSubject: Re: Your order\n" +
+		"Message-ID: <reply-1@mail.example.com>\nIn-Reply-To: <order-42@example.com>\nReferences: <order-42@example.com>\n",
+	"from":            "Renee <renee@example.com>",
+	"to":              "support@example.com",
+	"subject":         "Re: Your order",
+	"text":            "O\xf9 est mon colis ?\r\n",
+	"html":            "<p>Où est mon colis ?</p>",
+	"charsets":        `{"to":"UTF-8","html":"UTF-8","subject":"UTF-8","from":"UTF-8","text":"iso-8859-1"}`,
+	"envelope":        `{"to":["support@example.com"],"from":"renee@example.com"}`,
+	"spam_score":      "0.4",
+	"attachments":     "2",
+	"attachment-info": `{"attachment1":{"filename":"photo.png","name":"photo.png","type":"image/png","content-id":"ii_1"},"attachment2":{"filename":"invoice.pdf","type":"application/pdf"}}`,
+}
+
+// TestParseSendgridInbound tests the ParseSendgridInbound function
+func TestParseSendgridInbound(t *testing.T) {
+	parse := func(t *testing.T, req *http.Request) (*InboundEmail, error) {
+		assert.NoError(t, req.ParseMultipartForm(maxInboundBodySize))
+		return ParseSendgridInbound(req.MultipartForm)
+	}
+
+	t.Run("Success - parsed mode", func(t *testing.T) {
+		email, err := parse(t, newSendgridInboundRequest(t, sendgridParsedFields,
+			sendgridInboundFile{"attachment2", "invoice.pdf", "application/pdf", "%PDF-"},
+			sendgridInboundFile{"attachment1", "photo.png", "image/png", "PNG"},
+		))
+		assert.NoError(t, err)
+		assert.Equal(t, ProviderSendgrid, email.Provider)
+		assert.Equal(t, Address{Name: "Renee", Address: "renee@example.com"}, email.From)
+		assert.Equal(t, []Address{{Address: "support@example.com"}}, email.To)
+		assert.Equal(t, []string{"support@example.com"}, email.Recipients)
+		assert.Equal(t, "Re: Your order", email.Subject)
+		assert.Equal(t, "Où est mon colis ?\n", email.Text)
+		assert.Equal(t, "<p>Où est mon colis ?</p>", email.HTML)
+		assert.Equal(t, "<reply-1@mail.example.com>", email.MessageID)
+		assert.Equal(t, "<order-42@example.com>", email.InReplyTo)
+		assert.Equal(t, []string{"<order-42@example.com>"}, email.References)
+		assert.Equal(t, 0.4, email.SpamScore)
+		assert.Equal(t, []Attachment{
+			{Filename: "photo.png", ContentType: "image/png", Content: []byte("PNG"), ContentID: "ii_1"},
+			{Filename: "invoice.pdf", ContentType: "application/pdf", Content: []byte("%PDF-")},
+		}, email.Attachments)
+	})
+
+	t.Run("Success - parsed mode without attachment info", func(t *testing.T) {
+		email, err := parse(t, newSendgridInboundRequest(t, map[string]string{"subject": "Hello", "text": "Hi"},
+			sendgridInboundFile{"attachment1", "notes.txt", "text/plain", "notes"},
+		))
+		assert.NoError(t, err)
+		assert.Equal(t, "Hello", email.Subject)
+		assert.Equal(t, "Hi", email.Text)
+		assert.Equal(t, []Attachment{{Filename: "notes.txt", ContentType: "text/plain", Content: []byte("notes")}}, email.Attachments)
+	})
+
+	t.Run("Success - raw mode", func(t *testing.T) {
+		email, err := parse(t, newSendgridInboundRequest(t, map[string]string{
+			"email":      inboundRawEmail,
+			"envelope":   `{"to":["tickets@example.com"],"from":"renee@example.com"}`,
+			"spam_score": "1.2",
+		}))
+		assert.NoError(t, err)
+		assert.Equal(t, ProviderSendgrid, email.Provider)
+		assert.Equal(t, "Re: Your order n°42", email.Subject)
+		assert.Equal(t, "Where is my parcel?", email.Text)
+		assert.Equal(t, []string{"tickets@example.com"}, email.Recipients)
+		assert.Equal(t, 1.2, email.SpamScore)
+		assert.Len(t, email.Attachments, 1)
+	})
+
+	tests := []struct {
+		name     string
+		fields   map[string]string
+		expected string
+	}{
+		{"invalid raw email", map[string]string{"email": "not a message"}, "invalid SendGrid inbound email"},
+		{"invalid charsets", map[string]string{"charsets": "{"}, "invalid SendGrid inbound charsets"},
+		{"unsupported charset", map[string]string{"text": "Hi", "charsets": `{"text":"klingon"}`}, `invalid SendGrid inbound text: unsupported charset "klingon"`},
+		{"invalid attachment info", map[string]string{"attachment-info": "["}, "invalid SendGrid inbound attachment-info"},
+		{"invalid envelope", map[string]string{"envelope": "{"}, "invalid SendGrid inbound envelope"},
+	}
+
+	for _, tt := range tests {
+		t.Run("Failure - "+tt.name, func(t *testing.T) {
+			_, err := parse(t, newSendgridInboundRequest(t, tt.fields))
+			assert.ErrorContains(t, err, tt.expected)
+		})
+	}
+}
+
+// TestSendgridInboundHandler tests the ServeHTTP method of SendgridInboundHandler
+func TestSendgridInboundHandler(t *testing.T) {
+	var received []*InboundEmail
+	onEmail := func(ctx context.Context, email *InboundEmail) error {
+		received = append(received, email)
+		if email.Subject == "fail" {
+			return errors.New("ticketing unavailable")
+		}
+		return nil
+	}
+	handler := NewSendgridInboundHandler(onEmail, SendgridInboundOptions{Username: "sendgrid", Password: "s3cret"})
+
+	newRequest := func(fields map[string]string) *http.Request {
+		req := newSendgridInboundRequest(t, fields)
+		req.SetBasicAuth("sendgrid", "s3cret")
+		return req
+	}
+
+	tests := []struct {
+		name     string
+		request  func() *http.Request
+		status   int
+		received int
+	}{
+		{"Success", func() *http.Request { return newRequest(sendgridParsedFields) }, http.StatusOK, 1},
+		{"Failure - method not allowed", func() *http.Request {
+			return httptest.NewRequest(http.MethodGet, "/inbound/sendgrid", nil)
+		}, http.StatusMethodNotAllowed, 0},
+		{"Failure - wrong password", func() *http.Request {
+			req := newRequest(sendgridParsedFields)
+			req.SetBasicAuth("sendgrid", "guess")
+			return req
+		}, http.StatusUnauthorized, 0},
+		{"Failure - not multipart", func() *http.Request {
+			req := httptest.NewRequest(http.MethodPost, "/inbound/sendgrid", bytes.NewBufferString("{}"))
+			req.Header.Set("Content-Type", "application/json")
+			req.SetBasicAuth("sendgrid", "s3cret")
+			return req
+		}, http.StatusBadRequest, 0},
+		{"Failure - invalid email", func() *http.Request { return newRequest(map[string]string{"envelope": "{"}) }, http.StatusBadRequest, 0},
+		{"Failure - callback error", func() *http.Request { return newRequest(map[string]string{"subject": "fail"}) }, http.StatusInternalServerError, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			received = nil
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, tt.request())
+			assert.Equal(t, tt.status, rec.Code)
+			assert.Len(t, received, tt.received)
+		})
+	}
+}
@@ -46,6 +46,26 @@ func TestSendgridService_Send(t *testing.T) {
 		assert.NoError(t, err)
 	})
 
+	t.Run("Success - configured sender", func(t *testing.T) {
+		mock := &MockSendgridClient{SendResponse: &rest.Response{}, SendError: nil}
+		service.(*SendgridService).client = mock
+
+		assert.NoError(t, service.Send(NewEmailMessage("test@example.com", "Test Subject", "Test Plain Text", "")))
+		assert.Equal(t, "test_sender_name", mock.LastEmail.From.Name)
+		assert.Equal(t, "test_sender_email", mock.LastEmail.From.Address)
+	})
+
+	t.Run("Success - message from address", func(t *testing.T) {
+		mock := &MockSendgridClient{SendResponse: &rest.Response{}, SendError: nil}
+		service.(*SendgridService).client = mock
+
+		msg := NewEmailMessage("test@example.com", "Test Subject", "Test Plain Text", "").
+			WithFrom("Billing", "billing@example.com")
+		assert.NoError(t, service.Send(msg))
+		assert.Equal(t, "Billing", mock.LastEmail.From.Name)
+		assert.Equal(t, "billing@example.com", mock.LastEmail.From.Address)
+	})
+
 	t.Run("Success - with reply-to", func(t *testing.T) {
 		service.(*SendgridService).client = &MockSendgridClient{SendResponse: &rest.Response{}, SendError: nil}
 
@@ -74,6 +94,46 @@ func TestSendgridService_Send(t *testing.T) {
 		assert.NoError(t, err)
 	})
 
+	t.Run("Success - with threading", func(t *testing.T) {
+		mock := &MockSendgridClient{SendResponse: &rest.Response{}}
+		service.(*SendgridService).client = mock
+
+		msg := NewEmailMessage("test@example.com", "Re: Test Subject", "Test Plain Text", "").
+			WithMessageID("reply-1@example.com").
+			WithInReplyTo("<order-42@example.com>", "<welcome-1@example.com>")
+		err := service.Send(msg)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{
+			"Message-ID":  "<reply-1@example.com>",
+			"In-Reply-To": "<order-42@example.com>",
+			"References":  "<welcome-1@example.com> <order-42@example.com>",
+		}, mock.LastEmail.Headers)
+	})
+
+	t.Run("Success - with tags", func(t *testing.T) {
+		mock := &MockSendgridClient{SendResponse: &rest.Response{}}
+		service.(*SendgridService).client = mock
+
+		msg := NewEmailMessage("test@example.com", "Test Subject", "Test Plain Text", "").WithTags("newsletter", "march")
+		err := service.Send(msg)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"newsletter", "march"}, mock.LastEmail.Categories)
+	})
+
+	t.Run("Success - attachment content type detected from file name", func(t *testing.T) {
+		mock := &MockSendgridClient{SendResponse: &rest.Response{}, SendError: nil}
+		service.(*SendgridService).client = mock
+
+		msg := NewEmailMessage("test@example.com", "Test Subject", "Test Plain Text", "Test HTML Content").
+			WithAttachment("invoice.pdf", "", []byte("PDF bytes")).
+			WithAttachment("data.unknownext", "", []byte("bytes"))
+		assert.NoError(t, service.Send(msg))
+
+		assert.Len(t, mock.LastEmail.Attachments, 2)
+		assert.Equal(t, "application/pdf", mock.LastEmail.Attachments[0].Type)
+		assert.Equal(t, "application/octet-stream", mock.LastEmail.Attachments[1].Type)
+	})
+
 	t.Run("Success - with attachment", func(t *testing.T) {
 		mock := &MockSendgridClient{SendResponse: &rest.Response{}, SendError: nil}
 		service.(*SendgridService).client = mock
@@ -123,6 +183,53 @@ func TestSendgridService_Send(t *testing.T) {
 		assert.Len(t, mock.LastEmail.Attachments, 1)
 	})
 
+	t.Run("Failure - invalid message", func(t *testing.T) {
+		mock := &MockSendgridClient{SendResponse: &rest.Response{}}
+		service.(*SendgridService).client = mock
+
+		err := service.Send(NewEmailMessage("invalid", "", "Test Plain Text", "Test HTML Content"))
+		assert.Error(t, err)
+		assert.Nil(t, mock.LastEmail)
+	})
+
+	t.Run("Failure - reserved header", func(t *testing.T) {
+		mock := &MockSendgridClient{SendResponse: &rest.Response{}}
+		service.(*SendgridService).client = mock
+
+		msg := NewEmailMessage("test@example.com", "Test Subject", "Test Plain Text", "Test HTML Content").
+			WithHeader("X-Sg-Eid", "value")
+		err := service.Send(msg)
+
+		var headerErr *HeaderError
+		assert.ErrorAs(t, err, &headerErr)
+		assert.Equal(t, "X-Sg-Eid", headerErr.Header)
+		assert.Nil(t, mock.LastEmail)
+	})
+
+	t.Run("Failure - transformers", func(t *testing.T) {
+		mock := &MockSendgridClient{SendResponse: &rest.Response{}}
+		service.(*SendgridService).client = mock
+
+		msg := NewEmailMessage("test@example.com", "Test Subject", "Test Plain Text", "Test HTML Content").
+			WithTransformer(upperTransformer{})
+		err := service.Send(msg)
+		assert.ErrorIs(t, err, ErrTransformersUnsupported)
+		assert.Nil(t, mock.LastEmail)
+	})
+
+	t.Run("Failure - message too large", func(t *testing.T) {
+		mock := &MockSendgridClient{SendResponse: &rest.Response{}}
+		service.(*SendgridService).client = mock
+		service.(*SendgridService).limits = SizeLimits{MaxAttachments: 100}
+		defer func() { service.(*SendgridService).limits = SendgridSizeLimits }()
+
+		msg := NewEmailMessage("test@example.com", "Test Subject", "Test Plain Text", "Test HTML Content").
+			WithAttachment("large.bin", "application/octet-stream", make([]byte, 200))
+		err := service.Send(msg)
+		assert.ErrorIs(t, err, ErrMessageTooLarge)
+		assert.Nil(t, mock.LastEmail)
+	})
+
 	t.Run("Failure", func(t *testing.T) {
 		service.(*SendgridService).client = &MockSendgridClient{SendResponse: &rest.Response{}, SendError: fmt.Errorf("failed to send email")}
 
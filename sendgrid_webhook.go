@@ -0,0 +1,264 @@
+package goat
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// SendgridSignatureHeader holds the base64 ECDSA signature of a SendGrid event webhook request.
+	SendgridSignatureHeader = "X-Twilio-Email-Event-Webhook-Signature"
+	// SendgridTimestampHeader holds the timestamp signed along the payload.
+	SendgridTimestampHeader = "X-Twilio-Email-Event-Webhook-Timestamp"
+)
+
+// DefaultSendgridWebhookMaxAge is the default maximum age of a signed SendGrid webhook request.
+const DefaultSendgridWebhookMaxAge = 5 * time.Minute
+
+// ErrInvalidWebhookSignature is returned when a webhook request is not signed by the expected key, or too old.
+var ErrInvalidWebhookSignature = errors.New("invalid webhook signature")
+
+// SendgridEventType is the type of a SendGrid event webhook event.
+type SendgridEventType string
+
+const (
+	SendgridEventProcessed        SendgridEventType = "processed"
+	SendgridEventDelivered        SendgridEventType = "delivered"
+	SendgridEventDeferred         SendgridEventType = "deferred"
+	SendgridEventBounce           SendgridEventType = "bounce" // See SendgridEvent.BounceType: "bounce" or "blocked"
+	SendgridEventDropped          SendgridEventType = "dropped"
+	SendgridEventOpen             SendgridEventType = "open"
+	SendgridEventClick            SendgridEventType = "click"
+	SendgridEventSpamReport       SendgridEventType = "spamreport"
+	SendgridEventUnsubscribe      SendgridEventType = "unsubscribe"
+	SendgridEventGroupUnsubscribe SendgridEventType = "group_unsubscribe"
+	SendgridEventGroupResubscribe SendgridEventType = "group_resubscribe"
+)
+
+// SendgridEvent is an event of the SendGrid event webhook.
+type SendgridEvent struct {
+	Type SendgridEventType
+	// MessageID is the prefix of SGMessageID before the first dot, the X-Message-Id returned in SendResult.MessageID.
+	MessageID            string
+	SGMessageID          string
+	EventID              string // Unique event identifier, to deduplicate retried requests
+	Email                string // Recipient address
+	Time                 time.Time
+	Categories           []string
+	Reason               string // Bounce, drop or deferral reason
+	Status               string // SMTP status code of a bounce
+	BounceType           string // "bounce" or "blocked"
+	BounceClassification string
+	Response             string // Receiving server response of a delivery or deferral
+	Attempt              int    // Delivery attempt of a deferral
+	URL                  string // Clicked link
+	UserAgent            string
+	IP                   string
+	ASMGroupID           int64           // Unsubscribe group of the message, or of a group unsubscription or resubscription
+	Raw                  json.RawMessage // Original event, for custom arguments and fields not mapped above
+}
+
+// sendgridPayload is the JSON of a SendGrid event webhook event.
+type sendgridPayload struct {
+	Event                SendgridEventType `json:"event"`
+	Email                string            `json:"email"`
+	Timestamp            int64             `json:"timestamp"`
+	SGEventID            string            `json:"sg_event_id"`
+	SGMessageID          string            `json:"sg_message_id"`
+	Category             json.RawMessage   `json:"category"`
+	Reason               string            `json:"reason"`
+	Status               string            `json:"status"`
+	Type                 string            `json:"type"`
+	BounceClassification string            `json:"bounce_classification"`
+	Response             string            `json:"response"`
+	Attempt              json.RawMessage   `json:"attempt"`
+	URL                  string            `json:"url"`
+	UserAgent            string            `json:"useragent"`
+	IP                   string            `json:"ip"`
+	ASMGroupID           int64             `json:"asm_group_id"`
+}
+
+// SendgridWebhookOptions configures a SendgridWebhookHandler.
+type SendgridWebhookOptions struct {
+	// VerificationKey is the base64 public key shown in the SendGrid signed event webhook settings.
+	VerificationKey string
+	// MaxAge is the maximum age of a request timestamp, DefaultSendgridWebhookMaxAge when zero.
+	MaxAge time.Duration
+}
+
+// SendgridWebhookHandler is an http.Handler receiving signed SendGrid event webhook requests.
+type SendgridWebhookHandler struct {
+	onEvent func(ctx context.Context, event SendgridEvent) error
+	key     *ecdsa.PublicKey
+	maxAge  time.Duration
+}
+
+// NewSendgridWebhookHandler returns a handler for the SendGrid signed event webhook, calling onEvent with
+// each event of the batch a request carries. The ECDSA signature of the timestamp and payload is verified
+// against opts.VerificationKey, and requests with an invalid signature or signed more than MaxAge ago get
+// a 403 status, so captured requests cannot be replayed. SendGrid retries batches answered with a 500 status,
+// as when onEvent fails, for up to 24 hours: skip the events whose EventID was already processed.
+// It returns an error when the verification key does not parse.
+func NewSendgridWebhookHandler(onEvent func(ctx context.Context, event SendgridEvent) error, opts SendgridWebhookOptions) (*SendgridWebhookHandler, error) {
+	key, err := ParseSendgridVerificationKey(opts.VerificationKey)
+	if err != nil {
+		return nil, err
+	}
+
+	h := &SendgridWebhookHandler{onEvent: onEvent, key: key, maxAge: opts.MaxAge}
+	if h.maxAge <= 0 {
+		h.maxAge = DefaultSendgridWebhookMaxAge
+	}
+	return h, nil
+}
+
+// ParseSendgridVerificationKey parses the base64 public key of the SendGrid signed event webhook settings.
+func ParseSendgridVerificationKey(verificationKey string) (*ecdsa.PublicKey, error) {
+	der, err := base64.StdEncoding.DecodeString(strings.TrimSpace(verificationKey))
+	if err != nil {
+		return nil, fmt.Errorf("invalid SendGrid verification key: %w", err)
+	}
+	key, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SendGrid verification key: %w", err)
+	}
+	ecKey, ok := key.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("invalid SendGrid verification key: %T is not an ECDSA key", key)
+	}
+	return ecKey, nil
+}
+
+// VerifySendgridSignature verifies the base64 signature of a SendGrid event webhook request,
+// an ECDSA signature over the SHA-256 hash of the timestamp followed by the payload.
+func VerifySendgridSignature(key *ecdsa.PublicKey, payload []byte, timestamp, signature string) error {
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("%w: malformed signature", ErrInvalidWebhookSignature)
+	}
+
+	hash := sha256.New()
+	hash.Write([]byte(timestamp))
+	hash.Write(payload)
+	if !ecdsa.VerifyASN1(key, hash.Sum(nil), sig) {
+		return ErrInvalidWebhookSignature
+	}
+	return nil
+}
+
+// ServeHTTP implements the http.Handler interface.
+func (h *SendgridWebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	payload, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBodySize))
+	if err != nil {
+		http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	if err := h.verify(r, payload); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	events, err := ParseSendgridEvents(bytes.NewReader(payload))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	for _, event := range events {
+		if err := h.onEvent(r.Context(), event); err != nil {
+			http.Error(w, "event not processed", http.StatusInternalServerError)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// verify checks the signature and the timestamp of the request.
+func (h *SendgridWebhookHandler) verify(r *http.Request, payload []byte) error {
+	timestamp := r.Header.Get(SendgridTimestampHeader)
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: missing or malformed timestamp", ErrInvalidWebhookSignature)
+	}
+	if age := timeNow().Sub(time.Unix(seconds, 0)); age > h.maxAge || age < -h.maxAge {
+		return fmt.Errorf("%w: stale timestamp", ErrInvalidWebhookSignature)
+	}
+	return VerifySendgridSignature(h.key, payload, timestamp, r.Header.Get(SendgridSignatureHeader))
+}
+
+// ParseSendgridEvents parses a batch of SendGrid event webhook events.
+func ParseSendgridEvents(r io.Reader) ([]SendgridEvent, error) {
+	var items []json.RawMessage
+	if err := json.NewDecoder(r).Decode(&items); err != nil {
+		return nil, fmt.Errorf("invalid SendGrid webhook payload: %w", err)
+	}
+
+	events := make([]SendgridEvent, 0, len(items))
+	for _, item := range items {
+		var p sendgridPayload
+		if err := json.Unmarshal(item, &p); err != nil {
+			return nil, fmt.Errorf("invalid SendGrid webhook event: %w", err)
+		}
+		if p.Event == "" {
+			return nil, errors.New("invalid SendGrid webhook event: missing event type")
+		}
+
+		messageID, _, _ := strings.Cut(p.SGMessageID, ".")
+		event := SendgridEvent{
+			Type:                 p.Event,
+			MessageID:            messageID,
+			SGMessageID:          p.SGMessageID,
+			EventID:              p.SGEventID,
+			Email:                p.Email,
+			Reason:               p.Reason,
+			Status:               p.Status,
+			BounceType:           p.Type,
+			BounceClassification: p.BounceClassification,
+			Response:             p.Response,
+			URL:                  p.URL,
+			UserAgent:            p.UserAgent,
+			IP:                   p.IP,
+			ASMGroupID:           p.ASMGroupID,
+			Raw:                  item,
+		}
+		if p.Timestamp > 0 {
+			event.Time = time.Unix(p.Timestamp, 0)
+		}
+
+		// category is a string or an array of strings, and attempt a number or a string
+		var category string
+		if json.Unmarshal(p.Category, &category) == nil && category != "" {
+			event.Categories = []string{category}
+		} else {
+			_ = json.Unmarshal(p.Category, &event.Categories)
+		}
+		var attempt string
+		if json.Unmarshal(p.Attempt, &attempt) == nil {
+			event.Attempt, _ = strconv.Atoi(attempt)
+		} else {
+			_ = json.Unmarshal(p.Attempt, &event.Attempt)
+		}
+
+		events = append(events, event)
+	}
+	return events, nil
+}
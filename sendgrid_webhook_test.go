@@ -0,0 +1,282 @@
+package goat
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const sendgridEventsPayload = `[
+	{
+		"email": "user@example.com",
+		"timestamp": 1773500966,
+		"smtp-id": "<14c5d75ce93.dfd.64b469@ismtpd-555>",
+		"event": "delivered",
+		"category": "welcome",
+		"sg_event_id": "rWVYmVk90MjZJ9iohOBa3w==",
+		"sg_message_id": "14c5d75ce93.dfd.64b469.filter0001.16648.5515E0B88.0",
+		"response": "250 OK",
+		"user_id": "42"
+	},
+	{
+		"email": "gone@example.com",
+		"timestamp": 1773500970,
+		"event": "bounce",
+		"category": ["welcome", "onboarding"],
+		"sg_event_id": "6g4ZI7SA-xmRDv57GoPIPw==",
+		"sg_message_id": "14c5d75ce93.dfd.64b469.filter0001.16648.5515E0B88.1",
+		"reason": "500 unknown recipient",
+		"status": "5.0.0",
+		"type": "blocked",
+		"bounce_classification": "Invalid Address"
+	},
+	{
+		"email": "later@example.com",
+		"timestamp": 1773500980,
+		"event": "deferred",
+		"sg_message_id": "14c5d75ce93.dfd.64b469.filter0001.16648.5515E0B88.2",
+		"response": "400 try again later",
+		"attempt": "5"
+	}
+]`
+
+// signSendgridPayload returns the base64 signature of payload at timestamp, as sent by SendGrid.
+func signSendgridPayload(t *testing.T, key *ecdsa.PrivateKey, timestamp string, payload string) string {
+	hash := sha256.Sum256([]byte(timestamp + payload))
+	sig, err := ecdsa.SignASN1(rand.Reader, key, hash[:])
+	assert.NoError(t, err)
+	return base64.StdEncoding.EncodeToString(sig)
+}
+
+// newSendgridVerificationKey returns a new ECDSA key and its public key encoded as in the SendGrid settings.
+func newSendgridVerificationKey(t *testing.T) (*ecdsa.PrivateKey, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	assert.NoError(t, err)
+	return key, base64.StdEncoding.EncodeToString(der)
+}
+
+// TestParseSendgridEvents tests the ParseSendgridEvents function
+func TestParseSendgridEvents(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		events, err := ParseSendgridEvents(strings.NewReader(sendgridEventsPayload))
+		assert.NoError(t, err)
+		assert.Len(t, events, 3)
+
+		delivered := events[0]
+		assert.Equal(t, SendgridEventDelivered, delivered.Type)
+		assert.Equal(t, "14c5d75ce93", delivered.MessageID)
+		assert.Equal(t, "14c5d75ce93.dfd.64b469.filter0001.16648.5515E0B88.0", delivered.SGMessageID)
+		assert.Equal(t, "rWVYmVk90MjZJ9iohOBa3w==", delivered.EventID)
+		assert.Equal(t, "user@example.com", delivered.Email)
+		assert.Equal(t, time.Unix(1773500966, 0), delivered.Time)
+		assert.Equal(t, []string{"welcome"}, delivered.Categories)
+		assert.Equal(t, "250 OK", delivered.Response)
+		assert.Contains(t, string(delivered.Raw), `"user_id": "42"`)
+
+		bounce := events[1]
+		assert.Equal(t, SendgridEventBounce, bounce.Type)
+		assert.Equal(t, "14c5d75ce93", bounce.MessageID)
+		assert.Equal(t, []string{"welcome", "onboarding"}, bounce.Categories)
+		assert.Equal(t, "500 unknown recipient", bounce.Reason)
+		assert.Equal(t, "5.0.0", bounce.Status)
+		assert.Equal(t, "blocked", bounce.BounceType)
+		assert.Equal(t, "Invalid Address", bounce.BounceClassification)
+
+		deferred := events[2]
+		assert.Equal(t, SendgridEventDeferred, deferred.Type)
+		assert.Equal(t, 5, deferred.Attempt)
+		assert.Nil(t, deferred.Categories)
+	})
+
+	t.Run("Success - group unsubscribe", func(t *testing.T) {
+		events, err := ParseSendgridEvents(strings.NewReader(`[{"event": "group_unsubscribe", "email": "user@example.com", "asm_group_id": 42}]`))
+		assert.NoError(t, err)
+		assert.Len(t, events, 1)
+		assert.Equal(t, SendgridEventGroupUnsubscribe, events[0].Type)
+		assert.Equal(t, int64(42), events[0].ASMGroupID)
+	})
+
+	tests := []struct {
+		name     string
+		payload  string
+		expected string
+	}{
+		{"invalid JSON", `[{"event":`, "invalid SendGrid webhook payload"},
+		{"single event", `{"event": "delivered"}`, "invalid SendGrid webhook payload"},
+		{"invalid event", `[{"event": 1}]`, "invalid SendGrid webhook event"},
+		{"missing event type", `[{"email": "user@example.com"}]`, "invalid SendGrid webhook event: missing event type"},
+	}
+
+	for _, tt := range tests {
+		t.Run("Failure - "+tt.name, func(t *testing.T) {
+			_, err := ParseSendgridEvents(strings.NewReader(tt.payload))
+			assert.ErrorContains(t, err, tt.expected)
+		})
+	}
+}
+
+// TestParseSendgridVerificationKey tests the ParseSendgridVerificationKey function
+func TestParseSendgridVerificationKey(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		key, encoded := newSendgridVerificationKey(t)
+		parsed, err := ParseSendgridVerificationKey(" " + encoded + "\n")
+		assert.NoError(t, err)
+		assert.True(t, key.PublicKey.Equal(parsed))
+	})
+
+	t.Run("Failure - not base64", func(t *testing.T) {
+		_, err := ParseSendgridVerificationKey("not base64!")
+		assert.ErrorContains(t, err, "invalid SendGrid verification key")
+	})
+
+	t.Run("Failure - not a public key", func(t *testing.T) {
+		_, err := ParseSendgridVerificationKey(base64.StdEncoding.EncodeToString([]byte("key")))
+		assert.ErrorContains(t, err, "invalid SendGrid verification key")
+	})
+
+	t.Run("Failure - not an ECDSA key", func(t *testing.T) {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		assert.NoError(t, err)
+		der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+		assert.NoError(t, err)
+
+		_, err = ParseSendgridVerificationKey(base64.StdEncoding.EncodeToString(der))
+		assert.ErrorContains(t, err, "is not an ECDSA key")
+	})
+}
+
+// TestVerifySendgridSignature tests the VerifySendgridSignature function
+func TestVerifySendgridSignature(t *testing.T) {
+	key, _ := newSendgridVerificationKey(t)
+	signature := signSendgridPayload(t, key, "1773500966", sendgridEventsPayload)
+
+	t.Run("Success", func(t *testing.T) {
+		err := VerifySendgridSignature(&key.PublicKey, []byte(sendgridEventsPayload), "1773500966", signature)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Failure - tampered payload", func(t *testing.T) {
+		payload := strings.Replace(sendgridEventsPayload, "delivered", "open", 1)
+		err := VerifySendgridSignature(&key.PublicKey, []byte(payload), "1773500966", signature)
+		assert.ErrorIs(t, err, ErrInvalidWebhookSignature)
+	})
+
+	t.Run("Failure - other timestamp", func(t *testing.T) {
+		err := VerifySendgridSignature(&key.PublicKey, []byte(sendgridEventsPayload), "1773500967", signature)
+		assert.ErrorIs(t, err, ErrInvalidWebhookSignature)
+	})
+
+	t.Run("Failure - malformed signature", func(t *testing.T) {
+		err := VerifySendgridSignature(&key.PublicKey, []byte(sendgridEventsPayload), "1773500966", "not base64!")
+		assert.ErrorIs(t, err, ErrInvalidWebhookSignature)
+	})
+}
+
+// TestNewSendgridWebhookHandler tests the NewSendgridWebhookHandler function
+func TestNewSendgridWebhookHandler(t *testing.T) {
+	onEvent := func(ctx context.Context, event SendgridEvent) error { return nil }
+
+	t.Run("Success - default max age", func(t *testing.T) {
+		_, encoded := newSendgridVerificationKey(t)
+		handler, err := NewSendgridWebhookHandler(onEvent, SendgridWebhookOptions{VerificationKey: encoded})
+		assert.NoError(t, err)
+		assert.Equal(t, DefaultSendgridWebhookMaxAge, handler.maxAge)
+	})
+
+	t.Run("Failure - missing verification key", func(t *testing.T) {
+		handler, err := NewSendgridWebhookHandler(onEvent, SendgridWebhookOptions{})
+		assert.ErrorContains(t, err, "invalid SendGrid verification key")
+		assert.Nil(t, handler)
+	})
+}
+
+// TestSendgridWebhookHandler tests the ServeHTTP method of SendgridWebhookHandler
+func TestSendgridWebhookHandler(t *testing.T) {
+	now := time.Unix(1773501000, 0)
+	originalTimeNow := timeNow
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = originalTimeNow }()
+
+	var received []SendgridEvent
+	onEvent := func(ctx context.Context, event SendgridEvent) error {
+		received = append(received, event)
+		if event.Email == "fail@example.com" {
+			return errors.New("database unavailable")
+		}
+		return nil
+	}
+
+	key, encoded := newSendgridVerificationKey(t)
+	handler, err := NewSendgridWebhookHandler(onEvent, SendgridWebhookOptions{VerificationKey: encoded})
+	assert.NoError(t, err)
+
+	newRequest := func(method, body string, at time.Time) *http.Request {
+		timestamp := strconv.FormatInt(at.Unix(), 10)
+		req := httptest.NewRequest(method, "/webhooks/sendgrid", strings.NewReader(body))
+		req.Header.Set(SendgridTimestampHeader, timestamp)
+		req.Header.Set(SendgridSignatureHeader, signSendgridPayload(t, key, timestamp, body))
+		return req
+	}
+
+	tests := []struct {
+		name     string
+		request  func() *http.Request
+		status   int
+		received int
+	}{
+		{"Success", func() *http.Request { return newRequest(http.MethodPost, sendgridEventsPayload, now) }, http.StatusOK, 3},
+		{"Success - clock skew", func() *http.Request {
+			return newRequest(http.MethodPost, sendgridEventsPayload, now.Add(time.Minute))
+		}, http.StatusOK, 3},
+		{"Failure - method not allowed", func() *http.Request { return newRequest(http.MethodGet, "", now) }, http.StatusMethodNotAllowed, 0},
+		{"Failure - missing signature", func() *http.Request {
+			req := newRequest(http.MethodPost, sendgridEventsPayload, now)
+			req.Header.Del(SendgridSignatureHeader)
+			return req
+		}, http.StatusForbidden, 0},
+		{"Failure - missing timestamp", func() *http.Request {
+			req := newRequest(http.MethodPost, sendgridEventsPayload, now)
+			req.Header.Del(SendgridTimestampHeader)
+			return req
+		}, http.StatusForbidden, 0},
+		{"Failure - stale timestamp", func() *http.Request {
+			return newRequest(http.MethodPost, sendgridEventsPayload, now.Add(-10*time.Minute))
+		}, http.StatusForbidden, 0},
+		{"Failure - signed by another key", func() *http.Request {
+			other, _ := newSendgridVerificationKey(t)
+			req := newRequest(http.MethodPost, sendgridEventsPayload, now)
+			req.Header.Set(SendgridSignatureHeader, signSendgridPayload(t, other, req.Header.Get(SendgridTimestampHeader), sendgridEventsPayload))
+			return req
+		}, http.StatusForbidden, 0},
+		{"Failure - invalid payload", func() *http.Request { return newRequest(http.MethodPost, "not json", now) }, http.StatusBadRequest, 0},
+		{"Failure - callback error", func() *http.Request {
+			return newRequest(http.MethodPost, `[{"event": "delivered", "email": "fail@example.com"}, {"event": "open"}]`, now)
+		}, http.StatusInternalServerError, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			received = nil
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, tt.request())
+			assert.Equal(t, tt.status, rec.Code)
+			assert.Len(t, received, tt.received)
+		})
+	}
+}
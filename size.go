@@ -0,0 +1,128 @@
+package goat
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// GmailClipThreshold is the HTML body size above which Gmail clips messages behind a "View entire message" link.
+const GmailClipThreshold = 102 * 1024
+
+const (
+	// mimeLineLength is the maximum length of a base64 line in a MIME message, excluding CRLF.
+	mimeLineLength = 76
+	// mimePartOverhead estimates the headers and boundary of a MIME part.
+	mimePartOverhead = 200
+	// mimeHeaderOverhead estimates the top-level headers added by providers (From, Date, Message-ID, DKIM...).
+	mimeHeaderOverhead = 2048
+)
+
+// ErrMessageTooLarge is returned when a message exceeds a provider size limit.
+var ErrMessageTooLarge = errors.New("message too large")
+
+// MessageSize holds the size accounting of an EmailMessage, in bytes.
+type MessageSize struct {
+	HTMLBytes         int // Rendered HTML body
+	TextBytes         int // Plain text body
+	AttachmentBytes   int // Attachments once base64-encoded, as sent over the wire
+	LargestAttachment int // Largest attachment once base64-encoded
+	Total             int // Estimate of the whole MIME message
+}
+
+// SizeLimits holds the size limits enforced by a provider, in bytes. A zero limit is not enforced.
+type SizeLimits struct {
+	MaxTotal       int // Whole message, attachments included
+	MaxAttachments int // All attachments once base64-encoded
+}
+
+var (
+	// SendgridSizeLimits holds the limits of the SendGrid v3 Mail Send API (30MB per message).
+	SendgridSizeLimits = SizeLimits{MaxTotal: 30_000_000}
+	// BrevoSizeLimits holds the limits of the Brevo transactional API (20MB of attachments).
+	BrevoSizeLimits = SizeLimits{MaxAttachments: 20_000_000}
+	// SMTPSizeLimits holds the message size most SMTP servers accept (25MB, e.g. Gmail and Microsoft 365).
+	SMTPSizeLimits = SizeLimits{MaxTotal: 25_000_000}
+)
+
+var (
+	_clipWarningHookMu sync.RWMutex
+	_clipWarningHook   func(message *EmailMessage, size MessageSize)
+)
+
+// SetClipWarningHook sets a function called by the built-in providers before sending a message
+// whose HTML body exceeds GmailClipThreshold, e.g. to log a warning. The message is still sent.
+// It returns a function restoring the previous hook.
+func SetClipWarningHook(hook func(message *EmailMessage, size MessageSize)) func() {
+	_clipWarningHookMu.Lock()
+	defer _clipWarningHookMu.Unlock()
+
+	prev := _clipWarningHook
+	_clipWarningHook = hook
+	return func() { SetClipWarningHook(prev) }
+}
+
+// Size returns the size accounting of the message.
+func (m *EmailMessage) Size() MessageSize {
+	size := MessageSize{
+		HTMLBytes: len(m.HTMLContent),
+		TextBytes: len(m.PlainTextContent),
+	}
+
+	for _, a := range m.Attachments {
+		encoded := encodedSize(len(a.Content))
+		size.AttachmentBytes += encoded
+		size.LargestAttachment = max(size.LargestAttachment, encoded)
+	}
+
+	size.Total = mimeHeaderOverhead + len(m.Subject)
+	for k, v := range m.Headers {
+		size.Total += len(k) + len(v) + 4
+	}
+	for _, body := range []int{size.TextBytes, size.HTMLBytes} {
+		if body > 0 {
+			// quoted-printable adds soft line breaks and escapes, estimated to 5%
+			size.Total += mimePartOverhead + body + body/20
+		}
+	}
+	size.Total += size.AttachmentBytes + len(m.Attachments)*mimePartOverhead
+
+	return size
+}
+
+// encodedSize returns the size of n bytes once base64-encoded in lines of mimeLineLength characters.
+func encodedSize(n int) int {
+	encoded := base64.StdEncoding.EncodedLen(n)
+	lines := (encoded + mimeLineLength - 1) / mimeLineLength
+	return encoded + 2*lines
+}
+
+// Check returns an error wrapping ErrMessageTooLarge if size exceeds the limits.
+func (l SizeLimits) Check(size MessageSize) error {
+	if l.MaxAttachments > 0 && size.AttachmentBytes > l.MaxAttachments {
+		return fmt.Errorf("%w: attachments are %d bytes once encoded, the limit is %d", ErrMessageTooLarge, size.AttachmentBytes, l.MaxAttachments)
+	}
+	if l.MaxTotal > 0 && size.Total > l.MaxTotal {
+		return fmt.Errorf("%w: message is about %d bytes, the limit is %d", ErrMessageTooLarge, size.Total, l.MaxTotal)
+	}
+	return nil
+}
+
+// checkMessageSize checks the message against the provider limits and calls the clip warning hook if needed.
+func checkMessageSize(message *EmailMessage, limits SizeLimits) error {
+	size := message.Size()
+	if err := limits.Check(size); err != nil {
+		return err
+	}
+
+	if size.HTMLBytes > GmailClipThreshold {
+		_clipWarningHookMu.RLock()
+		hook := _clipWarningHook
+		_clipWarningHookMu.RUnlock()
+		if hook != nil {
+			hook(message, size)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package goat
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestEmailMessage_Size tests the Size method of EmailMessage
+func TestEmailMessage_Size(t *testing.T) {
+	t.Run("bodies only", func(t *testing.T) {
+		size := NewEmailMessage("test@example.com", "Subject", "plain", "<p>html</p>").Size()
+		assert.Equal(t, 11, size.HTMLBytes)
+		assert.Equal(t, 5, size.TextBytes)
+		assert.Equal(t, 0, size.AttachmentBytes)
+		assert.Greater(t, size.Total, size.HTMLBytes+size.TextBytes)
+	})
+
+	t.Run("attachments are counted base64-encoded", func(t *testing.T) {
+		msg := NewEmailMessage("test@example.com", "Subject", "", "").
+			WithAttachment("a.bin", "application/octet-stream", make([]byte, 57)).
+			WithAttachment("b.bin", "application/octet-stream", make([]byte, 300))
+
+		size := msg.Size()
+		// 57 bytes encode to a single 76 characters line, 300 bytes to 400 characters on 6 lines
+		assert.Equal(t, 78+412, size.AttachmentBytes)
+		assert.Equal(t, 412, size.LargestAttachment)
+		assert.Greater(t, size.Total, size.AttachmentBytes)
+	})
+}
+
+// TestSizeLimits_Check tests the Check method of SizeLimits
+func TestSizeLimits_Check(t *testing.T) {
+	assert.NoError(t, SizeLimits{}.Check(MessageSize{Total: 1 << 30, AttachmentBytes: 1 << 30}))
+	assert.NoError(t, SizeLimits{MaxTotal: 100, MaxAttachments: 50}.Check(MessageSize{Total: 100, AttachmentBytes: 50}))
+
+	err := SizeLimits{MaxTotal: 100}.Check(MessageSize{Total: 101})
+	assert.True(t, errors.Is(err, ErrMessageTooLarge))
+
+	err = SizeLimits{MaxAttachments: 50}.Check(MessageSize{Total: 10, AttachmentBytes: 51})
+	assert.True(t, errors.Is(err, ErrMessageTooLarge))
+	assert.Contains(t, err.Error(), "attachments")
+}
+
+// TestSetClipWarningHook tests that the clip warning hook is called for large HTML bodies
+func TestSetClipWarningHook(t *testing.T) {
+	var warned []MessageSize
+	restore := SetClipWarningHook(func(_ *EmailMessage, size MessageSize) {
+		warned = append(warned, size)
+	})
+	defer restore()
+
+	small := NewEmailMessage("test@example.com", "Subject", "", "<p>small</p>")
+	assert.NoError(t, checkMessageSize(small, SizeLimits{}))
+	assert.Empty(t, warned)
+
+	large := NewEmailMessage("test@example.com", "Subject", "", strings.Repeat("x", GmailClipThreshold+1))
+	assert.NoError(t, checkMessageSize(large, SizeLimits{}))
+	assert.Len(t, warned, 1)
+	assert.Equal(t, GmailClipThreshold+1, warned[0].HTMLBytes)
+
+	restore()
+	assert.NoError(t, checkMessageSize(large, SizeLimits{}))
+	assert.Len(t, warned, 1)
+}
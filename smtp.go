@@ -0,0 +1,113 @@
+package goat
+
+import (
+	"errors"
+	"fmt"
+	"net/smtp"
+	"net/textproto"
+)
+
+// SMTPClient is an interface for relaying serialized messages
+type SMTPClient interface {
+	SendMail(from string, to []string, msg []byte) error
+}
+
+// MIMETransformer transforms a serialized message before it is relayed, e.g. to sign or encrypt it.
+type MIMETransformer interface {
+	TransformMIME(raw []byte) ([]byte, error)
+}
+
+// ErrTransformersUnsupported is returned by providers that build the MIME message themselves,
+// and so cannot apply the transformers of a message.
+var ErrTransformersUnsupported = errors.New("provider does not send raw MIME, message transformers cannot be applied")
+
+// netSMTPClient relays messages with net/smtp.
+type netSMTPClient struct {
+	addr string
+	auth smtp.Auth
+}
+
+// SendMail implements the SMTPClient interface.
+func (c netSMTPClient) SendMail(from string, to []string, msg []byte) error {
+	return smtp.SendMail(c.addr, c.auth, from, to, msg)
+}
+
+// SMTPService implements the SenderService interface by relaying messages to an SMTP server
+type SMTPService struct {
+	client       SMTPClient
+	from         *Address
+	limits       SizeLimits
+	transformers []MIMETransformer
+}
+
+// NewSMTPService returns a new instance of SMTPService relaying messages to the server at addr (host:port),
+// authenticated with auth if not nil. Serialized messages go through the transformers in order before being
+// relayed, e.g. a DKIMSigner.
+func NewSMTPService(addr string, auth smtp.Auth, senderName, senderEmail string, transformers ...MIMETransformer) SenderService {
+	s := SMTPService{
+		client:       netSMTPClient{addr: addr, auth: auth},
+		from:         &Address{Name: senderName, Address: senderEmail},
+		limits:       SMTPSizeLimits,
+		transformers: transformers,
+	}
+	var service SenderService = &s
+	return service
+}
+
+// Send sends an email through the SMTP server.
+func (s *SMTPService) Send(message *EmailMessage) error {
+	_, err := s.SendWithResult(message)
+	return err
+}
+
+// SendWithResult sends an email through the SMTP server and returns its Message-ID.
+//
+// Messages are validated (see EmailMessage.Validate) and those exceeding SMTPSizeLimits are rejected
+// with ErrMessageTooLarge before connecting to the server.
+//
+// The message is sent from its From address, or else the configured sender, and serialized with
+// EmailMessage.WriteTo, its own transformers being applied before the service ones; its Message-ID
+// is generated unless set in MessageID or as a custom header. The envelope sender stays the configured
+// sender, so bounces are returned to it.
+func (s *SMTPService) SendWithResult(message *EmailMessage) (SendResult, error) {
+	if err := message.Validate(); err != nil {
+		return SendResult{}, err
+	}
+	if err := checkMessageSize(message, s.limits); err != nil {
+		return SendResult{}, err
+	}
+
+	msg := *message
+	if msg.From == nil {
+		msg.From = s.from
+	}
+	messageID := message.withThreadingHeaders(message.Headers)["Message-ID"]
+	for k, v := range message.Headers {
+		if messageID == "" && textproto.CanonicalMIMEHeaderKey(k) == "Message-Id" {
+			messageID = v
+		}
+	}
+	if messageID == "" {
+		id, err := newMessageID(msg.From.Address)
+		if err != nil {
+			return SendResult{}, err
+		}
+		messageID = id
+		msg.MessageID = id
+	}
+
+	raw, err := msg.MarshalMIME()
+	if err != nil {
+		return SendResult{}, err
+	}
+	for _, t := range s.transformers {
+		if raw, err = t.TransformMIME(raw); err != nil {
+			return SendResult{}, fmt.Errorf("transforming message: %w", err)
+		}
+	}
+
+	if err := s.client.SendMail(s.from.Address, []string{message.To}, raw); err != nil {
+		return SendResult{}, err
+	}
+	return SendResult{MessageID: messageID}, nil
+}
@@ -0,0 +1,149 @@
+package goat
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"net/mail"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type MockSMTPClient struct {
+	SendError error
+	LastFrom  string
+	LastTo    []string
+	LastMsg   []byte
+}
+
+func (m *MockSMTPClient) SendMail(from string, to []string, msg []byte) error {
+	m.LastFrom, m.LastTo, m.LastMsg = from, to, msg
+	return m.SendError
+}
+
+type failingTransformer struct{}
+
+func (failingTransformer) TransformMIME(raw []byte) ([]byte, error) {
+	return nil, errors.New("no key")
+}
+
+// TestNewSMTPService tests the NewSMTPService function
+func TestNewSMTPService(t *testing.T) {
+	service := NewSMTPService("smtp.example.com:587", nil, "test_sender_name", "sender@example.com")
+
+	assert.NotNil(t, service)
+	assert.IsType(t, &SMTPService{}, service)
+}
+
+// TestSMTPService_Send tests the Send method of SMTPService
+func TestSMTPService_Send(t *testing.T) {
+	public, key, _ := ed25519.GenerateKey(rand.Reader)
+	signer, _ := NewDKIMSigner(DKIMOptions{Domain: "example.com", Selector: "mail", Key: key})
+	service := NewSMTPService("smtp.example.com:587", nil, "Sender", "sender@example.com", signer)
+
+	t.Run("Success", func(t *testing.T) {
+		mock := &MockSMTPClient{}
+		service.(*SMTPService).client = mock
+
+		msg := NewEmailMessage("test@example.com", "Test Subject", "Test Plain Text", "Test HTML Content").
+			WithHeader("X-Campaign", "spring")
+		result, err := service.SendWithResult(msg)
+		assert.NoError(t, err)
+		assert.Equal(t, "sender@example.com", mock.LastFrom)
+		assert.Equal(t, []string{"test@example.com"}, mock.LastTo)
+		assert.NoError(t, VerifyDKIM(mock.LastMsg, public))
+		assert.Nil(t, msg.From)
+		assert.Equal(t, map[string]string{"X-Campaign": "spring"}, msg.Headers)
+
+		parsed, err := mail.ReadMessage(bytes.NewReader(mock.LastMsg))
+		assert.NoError(t, err)
+		assert.Equal(t, result.MessageID, parsed.Header.Get("Message-Id"))
+		assert.Equal(t, `"Sender" <sender@example.com>`, parsed.Header.Get("From"))
+	})
+
+	t.Run("Success - message from address", func(t *testing.T) {
+		mock := &MockSMTPClient{}
+		service.(*SMTPService).client = mock
+
+		msg := NewEmailMessage("test@example.com", "Test Subject", "Test Plain Text", "").
+			WithFrom("Billing", "billing@billing.example.com")
+		result, err := service.SendWithResult(msg)
+		assert.NoError(t, err)
+		assert.Equal(t, "sender@example.com", mock.LastFrom)
+		assert.True(t, strings.HasSuffix(result.MessageID, "@billing.example.com>"))
+
+		parsed, err := mail.ReadMessage(bytes.NewReader(mock.LastMsg))
+		assert.NoError(t, err)
+		assert.Equal(t, `"Billing" <billing@billing.example.com>`, parsed.Header.Get("From"))
+	})
+
+	t.Run("Success - custom Message-ID", func(t *testing.T) {
+		mock := &MockSMTPClient{}
+		service.(*SMTPService).client = mock
+
+		msg := NewEmailMessage("test@example.com", "Test Subject", "Test Plain Text", "").
+			WithHeader("Message-Id", "<custom@example.com>")
+		result, err := service.SendWithResult(msg)
+		assert.NoError(t, err)
+		assert.Equal(t, "<custom@example.com>", result.MessageID)
+	})
+
+	t.Run("Success - with threading", func(t *testing.T) {
+		mock := &MockSMTPClient{}
+		service.(*SMTPService).client = mock
+
+		msg := NewEmailMessage("test@example.com", "Re: Test Subject", "Test Plain Text", "").
+			WithHeader("Message-Id", "<custom@example.com>").
+			WithMessageID("reply-1@example.com").
+			WithInReplyTo("<order-42@example.com>", "<welcome-1@example.com>")
+		result, err := service.SendWithResult(msg)
+		assert.NoError(t, err)
+		assert.Equal(t, "<reply-1@example.com>", result.MessageID)
+
+		parsed, err := mail.ReadMessage(bytes.NewReader(mock.LastMsg))
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"<reply-1@example.com>"}, parsed.Header["Message-Id"])
+		assert.Equal(t, "<order-42@example.com>", parsed.Header.Get("In-Reply-To"))
+		assert.Equal(t, "<welcome-1@example.com> <order-42@example.com>", parsed.Header.Get("References"))
+	})
+
+	t.Run("Failure - invalid message", func(t *testing.T) {
+		mock := &MockSMTPClient{}
+		service.(*SMTPService).client = mock
+
+		err := service.Send(NewEmailMessage("invalid", "", "Test Plain Text", "Test HTML Content"))
+		assert.Error(t, err)
+		assert.Nil(t, mock.LastMsg)
+	})
+
+	t.Run("Failure - message too large", func(t *testing.T) {
+		mock := &MockSMTPClient{}
+		service.(*SMTPService).client = mock
+
+		msg := NewEmailMessage("test@example.com", "Test Subject", "Test Plain Text", "").
+			WithAttachment("large.bin", "application/octet-stream", make([]byte, SMTPSizeLimits.MaxTotal))
+		err := service.Send(msg)
+		assert.ErrorIs(t, err, ErrMessageTooLarge)
+		assert.Nil(t, mock.LastMsg)
+	})
+
+	t.Run("Failure - transformer", func(t *testing.T) {
+		mock := &MockSMTPClient{}
+		failing := NewSMTPService("smtp.example.com:587", nil, "Sender", "sender@example.com", failingTransformer{})
+		failing.(*SMTPService).client = mock
+
+		err := failing.Send(NewEmailMessage("test@example.com", "Test Subject", "Test Plain Text", ""))
+		assert.EqualError(t, err, "transforming message: no key")
+		assert.Nil(t, mock.LastMsg)
+	})
+
+	t.Run("Failure", func(t *testing.T) {
+		service.(*SMTPService).client = &MockSMTPClient{SendError: errors.New("connection refused")}
+
+		err := service.Send(NewEmailMessage("test@example.com", "Test Subject", "Test Plain Text", ""))
+		assert.EqualError(t, err, "connection refused")
+	})
+}
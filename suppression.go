@@ -0,0 +1,252 @@
+package goat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultSoftBounceTTL is the default time a soft-bounced address stays suppressed.
+const DefaultSoftBounceTTL = 72 * time.Hour
+
+// ErrRecipientSuppressed is returned by a SuppressingSender when the recipient is suppressed.
+var ErrRecipientSuppressed = errors.New("recipient is suppressed")
+
+// SuppressionReason is the reason an address is suppressed.
+type SuppressionReason string
+
+const (
+	SuppressionBounce      SuppressionReason = "bounce"      // Permanent delivery failure
+	SuppressionSoftBounce  SuppressionReason = "soft_bounce" // Temporary delivery failure, suppressed until it expires
+	SuppressionComplaint   SuppressionReason = "complaint"   // Reported as spam
+	SuppressionUnsubscribe SuppressionReason = "unsubscribe"
+	SuppressionInvalid     SuppressionReason = "invalid" // Invalid address
+	SuppressionManual      SuppressionReason = "manual"
+)
+
+// Suppression is a suppressed address.
+type Suppression struct {
+	Email     string // Lower-cased address
+	Reason    SuppressionReason
+	Detail    string // e.g. the bounce message
+	Source    string // ProviderSendgrid, ProviderBrevo, SourceListUnsubscribe, or empty when added manually
+	CreatedAt time.Time
+	ExpiresAt time.Time // Zero when the suppression does not expire
+}
+
+// Expired reports whether the suppression has expired at t.
+func (s Suppression) Expired(t time.Time) bool {
+	return !s.ExpiresAt.IsZero() && !t.Before(s.ExpiresAt)
+}
+
+// SuppressionStore stores suppressions by email address.
+type SuppressionStore interface {
+	// Get returns the suppression of an address, or nil when it is not suppressed.
+	Get(ctx context.Context, email string) (*Suppression, error)
+	Put(ctx context.Context, suppression Suppression) error
+	Delete(ctx context.Context, email string) error
+}
+
+// MemorySuppressionStore is an in-memory SuppressionStore, for tests and single-instance services.
+type MemorySuppressionStore struct {
+	mu           sync.RWMutex
+	suppressions map[string]Suppression
+}
+
+// NewMemorySuppressionStore returns an empty MemorySuppressionStore.
+func NewMemorySuppressionStore() *MemorySuppressionStore {
+	return &MemorySuppressionStore{suppressions: make(map[string]Suppression)}
+}
+
+// Get implements the SuppressionStore interface.
+func (s *MemorySuppressionStore) Get(ctx context.Context, email string) (*Suppression, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	suppression, ok := s.suppressions[email]
+	if !ok {
+		return nil, nil
+	}
+	return &suppression, nil
+}
+
+// Put implements the SuppressionStore interface.
+func (s *MemorySuppressionStore) Put(ctx context.Context, suppression Suppression) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.suppressions[suppression.Email] = suppression
+	return nil
+}
+
+// Delete implements the SuppressionStore interface.
+func (s *MemorySuppressionStore) Delete(ctx context.Context, email string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.suppressions, email)
+	return nil
+}
+
+// SuppressionOptions configures a SuppressionList.
+type SuppressionOptions struct {
+	// SoftBounceTTL is the time a soft-bounced address stays suppressed, DefaultSoftBounceTTL when zero.
+	SoftBounceTTL time.Duration
+}
+
+// SuppressionList tells which addresses must not be mailed, e.g. because they bounced or complained.
+type SuppressionList struct {
+	store         SuppressionStore
+	softBounceTTL time.Duration
+}
+
+// NewSuppressionList returns a list stored in store, a MemorySuppressionStore when nil.
+func NewSuppressionList(store SuppressionStore, opts SuppressionOptions) *SuppressionList {
+	if store == nil {
+		store = NewMemorySuppressionStore()
+	}
+	l := &SuppressionList{store: store, softBounceTTL: opts.SoftBounceTTL}
+	if l.softBounceTTL <= 0 {
+		l.softBounceTTL = DefaultSoftBounceTTL
+	}
+	return l
+}
+
+// normalizeEmail returns the address in the form suppressions are keyed by.
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// Add suppresses an address. The address is lower-cased and CreatedAt defaults to now;
+// soft bounces expire after the SoftBounceTTL unless ExpiresAt is set.
+// A permanent suppression is not replaced by one that expires.
+func (l *SuppressionList) Add(ctx context.Context, suppression Suppression) error {
+	suppression.Email = normalizeEmail(suppression.Email)
+	if suppression.Email == "" {
+		return errors.New("suppression without email address")
+	}
+	now := timeNow()
+	if suppression.CreatedAt.IsZero() {
+		suppression.CreatedAt = now
+	}
+	if suppression.Reason == SuppressionSoftBounce && suppression.ExpiresAt.IsZero() {
+		suppression.ExpiresAt = suppression.CreatedAt.Add(l.softBounceTTL)
+	}
+
+	if !suppression.ExpiresAt.IsZero() {
+		existing, err := l.store.Get(ctx, suppression.Email)
+		if err != nil {
+			return err
+		}
+		if existing != nil && !existing.Expired(now) && (existing.ExpiresAt.IsZero() || existing.ExpiresAt.After(suppression.ExpiresAt)) {
+			return nil
+		}
+	}
+	return l.store.Put(ctx, suppression)
+}
+
+// Remove removes the suppression of an address, if any.
+func (l *SuppressionList) Remove(ctx context.Context, email string) error {
+	return l.store.Delete(ctx, normalizeEmail(email))
+}
+
+// Check returns the suppression of an address, or nil when it is not suppressed or its suppression expired.
+func (l *SuppressionList) Check(ctx context.Context, email string) (*Suppression, error) {
+	suppression, err := l.store.Get(ctx, normalizeEmail(email))
+	if err != nil || suppression == nil || suppression.Expired(timeNow()) {
+		return nil, err
+	}
+	return suppression, nil
+}
+
+// suppressionReasons maps the delivery statuses suppressing the recipient to their reason.
+var suppressionReasons = map[DeliveryStatus]SuppressionReason{
+	DeliveryBounced:      SuppressionBounce,
+	DeliverySoftBounced:  SuppressionSoftBounce,
+	DeliveryComplained:   SuppressionComplaint,
+	DeliveryUnsubscribed: SuppressionUnsubscribe,
+}
+
+// HandleDeliveryEvent suppresses the recipient of bounced, soft-bounced, complained and unsubscribed
+// events. Other events are ignored, group unsubscriptions and resubscriptions included since they only
+// concern the messages of their group, which the provider already filters.
+func (l *SuppressionList) HandleDeliveryEvent(ctx context.Context, event DeliveryEvent) error {
+	reason, ok := suppressionReasons[event.Status]
+	if !ok || event.Recipient == "" {
+		return nil
+	}
+	return l.Add(ctx, Suppression{
+		Email:     event.Recipient,
+		Reason:    reason,
+		Detail:    event.Reason,
+		Source:    event.Provider,
+		CreatedAt: event.Time,
+	})
+}
+
+// HandleBrevoEvent feeds a Brevo event to HandleDeliveryEvent.
+// It can be used as the callback of NewBrevoWebhookHandler.
+func (l *SuppressionList) HandleBrevoEvent(ctx context.Context, event BrevoEvent) error {
+	if e, ok := event.DeliveryEvent(); ok {
+		return l.HandleDeliveryEvent(ctx, e)
+	}
+	return nil
+}
+
+// HandleSendgridEvent feeds a SendGrid event to HandleDeliveryEvent.
+// It can be used as the callback of NewSendgridWebhookHandler.
+func (l *SuppressionList) HandleSendgridEvent(ctx context.Context, event SendgridEvent) error {
+	if e, ok := event.DeliveryEvent(); ok {
+		return l.HandleDeliveryEvent(ctx, e)
+	}
+	return nil
+}
+
+// SuppressionMode is the behavior of a SuppressingSender for suppressed recipients.
+type SuppressionMode int
+
+const (
+	// SuppressionReject fails sending with an error wrapping ErrRecipientSuppressed.
+	SuppressionReject SuppressionMode = iota
+	// SuppressionDrop silently skips the message, returning an empty SendResult.
+	SuppressionDrop
+)
+
+// SuppressingSender implements the SenderService interface by sending through another service
+// only the messages whose recipient is not suppressed.
+type SuppressingSender struct {
+	next SenderService
+	list *SuppressionList
+	mode SuppressionMode
+}
+
+// NewSuppressingSender returns a service checking recipients against list before sending through next.
+func NewSuppressingSender(next SenderService, list *SuppressionList, mode SuppressionMode) SenderService {
+	s := SuppressingSender{next: next, list: list, mode: mode}
+	var service SenderService = &s
+	return service
+}
+
+// Send sends an email unless its recipient is suppressed.
+func (s *SuppressingSender) Send(message *EmailMessage) error {
+	_, err := s.SendWithResult(message)
+	return err
+}
+
+// SendWithResult sends an email unless its recipient is suppressed.
+func (s *SuppressingSender) SendWithResult(message *EmailMessage) (SendResult, error) {
+	suppression, err := s.list.Check(context.Background(), message.To)
+	if err != nil {
+		return SendResult{}, fmt.Errorf("checking suppression list: %w", err)
+	}
+	if suppression != nil {
+		if s.mode == SuppressionDrop {
+			return SendResult{}, nil
+		}
+		return SendResult{}, fmt.Errorf("%w: %s (%s)", ErrRecipientSuppressed, suppression.Email, suppression.Reason)
+	}
+	return s.next.SendWithResult(message)
+}
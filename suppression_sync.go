@@ -0,0 +1,184 @@
+package goat
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/antihax/optional"
+	brevo "github.com/getbrevo/brevo-go/lib"
+	"github.com/sendgrid/rest"
+	"github.com/sendgrid/sendgrid-go"
+)
+
+// Number of suppressions requested per page, the maximum allowed by the provider APIs.
+const (
+	sendgridSuppressionPageSize  = 500
+	brevoBlockedContactsPageSize = 100
+)
+
+// SuppressionSource lists the addresses suppressed by a provider, to import them in a SuppressionList.
+type SuppressionSource interface {
+	Suppressions(ctx context.Context) ([]Suppression, error)
+}
+
+// Sync adds the suppressions listed by source to the list and returns how many were listed.
+func (l *SuppressionList) Sync(ctx context.Context, source SuppressionSource) (int, error) {
+	suppressions, err := source.Suppressions(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, suppression := range suppressions {
+		if err := l.Add(ctx, suppression); err != nil {
+			return 0, err
+		}
+	}
+	return len(suppressions), nil
+}
+
+// sendgridSuppressionEndpoints maps the SendGrid suppression endpoints to the reason of their addresses.
+// Blocks, usually temporary, are not imported.
+var sendgridSuppressionEndpoints = []struct {
+	path   string
+	reason SuppressionReason
+}{
+	{"/v3/suppression/bounces", SuppressionBounce},
+	{"/v3/suppression/spam_reports", SuppressionComplaint},
+	{"/v3/suppression/invalid_emails", SuppressionInvalid},
+	{"/v3/suppression/unsubscribes", SuppressionUnsubscribe},
+}
+
+// SendgridSuppressionSource lists the bounces, spam reports, invalid emails and global unsubscribes of SendGrid.
+type SendgridSuppressionSource struct {
+	apiKey  string
+	request func(ctx context.Context, request rest.Request) (*rest.Response, error)
+}
+
+// NewSendgridSuppressionSource returns a source listing the SendGrid suppressions with an API key
+// allowed to read suppressions.
+func NewSendgridSuppressionSource(apiKey string) SuppressionSource {
+	s := SendgridSuppressionSource{apiKey: apiKey, request: sendgrid.MakeRequestWithContext}
+	var source SuppressionSource = &s
+	return source
+}
+
+// Suppressions implements the SuppressionSource interface.
+func (s *SendgridSuppressionSource) Suppressions(ctx context.Context) ([]Suppression, error) {
+	var suppressions []Suppression
+	for _, endpoint := range sendgridSuppressionEndpoints {
+		for offset := 0; ; offset += sendgridSuppressionPageSize {
+			request := sendgrid.GetRequest(s.apiKey, endpoint.path, "")
+			request.Method = rest.Get
+			request.QueryParams = map[string]string{
+				"limit":  strconv.Itoa(sendgridSuppressionPageSize),
+				"offset": strconv.Itoa(offset),
+			}
+
+			res, err := s.request(ctx, request)
+			if err != nil {
+				return nil, fmt.Errorf("listing SendGrid %s: %w", endpoint.path, err)
+			}
+			if res.StatusCode >= http.StatusMultipleChoices {
+				return nil, fmt.Errorf("listing SendGrid %s: status %d: %s", endpoint.path, res.StatusCode, res.Body)
+			}
+
+			var page []struct {
+				Email   string `json:"email"`
+				Created int64  `json:"created"`
+				Reason  string `json:"reason"`
+			}
+			if err := json.Unmarshal([]byte(res.Body), &page); err != nil {
+				return nil, fmt.Errorf("listing SendGrid %s: %w", endpoint.path, err)
+			}
+			for _, item := range page {
+				suppression := Suppression{
+					Email:  item.Email,
+					Reason: endpoint.reason,
+					Detail: item.Reason,
+					Source: ProviderSendgrid,
+				}
+				if item.Created > 0 {
+					suppression.CreatedAt = time.Unix(item.Created, 0)
+				}
+				suppressions = append(suppressions, suppression)
+			}
+			if len(page) < sendgridSuppressionPageSize {
+				break
+			}
+		}
+	}
+	return suppressions, nil
+}
+
+// BrevoBlockedContactsClient is an interface for listing the contacts blocked by Brevo
+type BrevoBlockedContactsClient interface {
+	GetTransacBlockedContacts(ctx context.Context, opts *brevo.GetTransacBlockedContactsOpts) (brevo.GetTransacBlockedContacts, *http.Response, error)
+}
+
+// brevoBlockReasons maps Brevo blocked contact reason codes to suppression reasons.
+// Unknown codes are imported as manual suppressions.
+var brevoBlockReasons = map[string]SuppressionReason{
+	"hardBounce":           SuppressionBounce,
+	"contactFlaggedAsSpam": SuppressionComplaint,
+	"unsubscribedViaMA":    SuppressionUnsubscribe,
+	"unsubscribedViaEmail": SuppressionUnsubscribe,
+	"unsubscribedViaApi":   SuppressionUnsubscribe,
+	"adminBlocked":         SuppressionManual,
+}
+
+// BrevoSuppressionSource lists the transactional contacts blocked by Brevo.
+type BrevoSuppressionSource struct {
+	client BrevoBlockedContactsClient
+}
+
+// NewBrevoSuppressionSource returns a source listing the Brevo blocked transactional contacts.
+func NewBrevoSuppressionSource(apiKey string) SuppressionSource {
+	cfg := brevo.NewConfiguration()
+	cfg.AddDefaultHeader("api-key", apiKey)
+
+	s := BrevoSuppressionSource{client: brevo.NewAPIClient(cfg).TransactionalEmailsApi}
+	var source SuppressionSource = &s
+	return source
+}
+
+// Suppressions implements the SuppressionSource interface.
+func (s *BrevoSuppressionSource) Suppressions(ctx context.Context) ([]Suppression, error) {
+	var suppressions []Suppression
+	for offset := int64(0); ; offset += brevoBlockedContactsPageSize {
+		page, _, err := s.client.GetTransacBlockedContacts(ctx, &brevo.GetTransacBlockedContactsOpts{
+			Limit:  optional.NewInt64(brevoBlockedContactsPageSize),
+			Offset: optional.NewInt64(offset),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("listing Brevo blocked contacts: %w", err)
+		}
+
+		for _, contact := range page.Contacts {
+			suppression := Suppression{
+				Email:  contact.Email,
+				Reason: SuppressionManual,
+				Source: ProviderBrevo,
+			}
+			if contact.Reason != nil {
+				if reason, ok := brevoBlockReasons[contact.Reason.Code]; ok {
+					suppression.Reason = reason
+				}
+				suppression.Detail = contact.Reason.Message
+			}
+			if t, err := time.Parse(time.RFC3339, contact.BlockedAt); err == nil {
+				suppression.CreatedAt = t
+			} else if t, err := time.Parse(time.DateOnly, strings.TrimSpace(contact.BlockedAt)); err == nil {
+				suppression.CreatedAt = t
+			}
+			suppressions = append(suppressions, suppression)
+		}
+		if len(page.Contacts) < brevoBlockedContactsPageSize || offset+int64(len(page.Contacts)) >= page.Count {
+			break
+		}
+	}
+	return suppressions, nil
+}
@@ -0,0 +1,197 @@
+package goat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	brevo "github.com/getbrevo/brevo-go/lib"
+	"github.com/sendgrid/rest"
+	"github.com/stretchr/testify/assert"
+)
+
+// MockSuppressionSource is a mock implementation of the SuppressionSource interface
+type MockSuppressionSource struct {
+	suppressions []Suppression
+	err          error
+}
+
+func (m *MockSuppressionSource) Suppressions(ctx context.Context) ([]Suppression, error) {
+	return m.suppressions, m.err
+}
+
+// MockBrevoBlockedContactsClient is a mock implementation of the BrevoBlockedContactsClient interface
+type MockBrevoBlockedContactsClient struct {
+	pages   []brevo.GetTransacBlockedContacts
+	offsets []int64
+	err     error
+}
+
+func (m *MockBrevoBlockedContactsClient) GetTransacBlockedContacts(ctx context.Context, opts *brevo.GetTransacBlockedContactsOpts) (brevo.GetTransacBlockedContacts, *http.Response, error) {
+	m.offsets = append(m.offsets, opts.Offset.Value())
+	if m.err != nil {
+		return brevo.GetTransacBlockedContacts{}, nil, m.err
+	}
+	page := m.pages[0]
+	m.pages = m.pages[1:]
+	return page, nil, nil
+}
+
+// TestSuppressionListSync tests the Sync method of SuppressionList
+func TestSuppressionListSync(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Success", func(t *testing.T) {
+		list := NewSuppressionList(nil, SuppressionOptions{})
+		count, err := list.Sync(ctx, &MockSuppressionSource{suppressions: []Suppression{
+			{Email: "gone@example.com", Reason: SuppressionBounce, Source: ProviderSendgrid},
+			{Email: "angry@example.com", Reason: SuppressionComplaint, Source: ProviderSendgrid},
+		}})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, count)
+
+		suppression, err := list.Check(ctx, "angry@example.com")
+		assert.NoError(t, err)
+		assert.Equal(t, SuppressionComplaint, suppression.Reason)
+	})
+
+	t.Run("Failure - source error", func(t *testing.T) {
+		list := NewSuppressionList(nil, SuppressionOptions{})
+		_, err := list.Sync(ctx, &MockSuppressionSource{err: errors.New("unauthorized")})
+		assert.EqualError(t, err, "unauthorized")
+	})
+
+	t.Run("Failure - invalid suppression", func(t *testing.T) {
+		list := NewSuppressionList(nil, SuppressionOptions{})
+		_, err := list.Sync(ctx, &MockSuppressionSource{suppressions: []Suppression{{Reason: SuppressionBounce}}})
+		assert.EqualError(t, err, "suppression without email address")
+	})
+}
+
+// TestSendgridSuppressionSource tests the Suppressions method of SendgridSuppressionSource
+func TestSendgridSuppressionSource(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Success", func(t *testing.T) {
+		bounces := make([]string, sendgridSuppressionPageSize)
+		for i := range bounces {
+			bounces[i] = fmt.Sprintf(`{"email": "gone%d@example.com", "created": 1773500966, "reason": "550 unknown user"}`, i)
+		}
+		responses := map[string][]string{
+			"/v3/suppression/bounces":        {"[" + strings.Join(bounces, ",") + "]", `[{"email": "last@example.com", "created": 1773500966}]`},
+			"/v3/suppression/spam_reports":   {`[{"email": "angry@example.com", "created": 1773500966, "ip": "10.63.202.100"}]`},
+			"/v3/suppression/invalid_emails": {`[{"email": "invalid@example", "created": 1773500966, "reason": "Mail domain mentioned in email address is unknown"}]`},
+			"/v3/suppression/unsubscribes":   {`[]`},
+		}
+
+		var requested []string
+		service := NewSendgridSuppressionSource("api-key")
+		service.(*SendgridSuppressionSource).request = func(ctx context.Context, request rest.Request) (*rest.Response, error) {
+			path := strings.TrimPrefix(request.BaseURL, "https://api.sendgrid.com")
+			requested = append(requested, path+"?offset="+request.QueryParams["offset"])
+			assert.Equal(t, rest.Get, request.Method)
+			assert.Equal(t, "Bearer api-key", request.Headers["Authorization"])
+
+			body := responses[path][0]
+			responses[path] = responses[path][1:]
+			return &rest.Response{StatusCode: http.StatusOK, Body: body}, nil
+		}
+
+		suppressions, err := service.Suppressions(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{
+			"/v3/suppression/bounces?offset=0",
+			"/v3/suppression/bounces?offset=500",
+			"/v3/suppression/spam_reports?offset=0",
+			"/v3/suppression/invalid_emails?offset=0",
+			"/v3/suppression/unsubscribes?offset=0",
+		}, requested)
+		assert.Len(t, suppressions, sendgridSuppressionPageSize+3)
+		assert.Equal(t, Suppression{
+			Email:     "gone0@example.com",
+			Reason:    SuppressionBounce,
+			Detail:    "550 unknown user",
+			Source:    ProviderSendgrid,
+			CreatedAt: time.Unix(1773500966, 0),
+		}, suppressions[0])
+		assert.Equal(t, SuppressionComplaint, suppressions[sendgridSuppressionPageSize+1].Reason)
+		assert.Equal(t, SuppressionInvalid, suppressions[sendgridSuppressionPageSize+2].Reason)
+	})
+
+	tests := []struct {
+		name     string
+		response *rest.Response
+		err      error
+		expected string
+	}{
+		{"request error", nil, errors.New("connection refused"), "listing SendGrid /v3/suppression/bounces: connection refused"},
+		{"API error", &rest.Response{StatusCode: http.StatusForbidden, Body: `{"errors":[{"message":"access forbidden"}]}`}, nil,
+			`listing SendGrid /v3/suppression/bounces: status 403: {"errors":[{"message":"access forbidden"}]}`},
+		{"invalid response", &rest.Response{StatusCode: http.StatusOK, Body: `{}`}, nil, "listing SendGrid /v3/suppression/bounces: json"},
+	}
+
+	for _, tt := range tests {
+		t.Run("Failure - "+tt.name, func(t *testing.T) {
+			service := NewSendgridSuppressionSource("api-key")
+			service.(*SendgridSuppressionSource).request = func(ctx context.Context, request rest.Request) (*rest.Response, error) {
+				return tt.response, tt.err
+			}
+
+			_, err := service.Suppressions(ctx)
+			assert.ErrorContains(t, err, tt.expected)
+		})
+	}
+}
+
+// TestBrevoSuppressionSource tests the Suppressions method of BrevoSuppressionSource
+func TestBrevoSuppressionSource(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Success", func(t *testing.T) {
+		first := make([]brevo.GetTransacBlockedContactsContacts, brevoBlockedContactsPageSize)
+		for i := range first {
+			first[i] = brevo.GetTransacBlockedContactsContacts{
+				Email:     fmt.Sprintf("gone%d@example.com", i),
+				Reason:    &brevo.GetTransacBlockedContactsReason{Code: "hardBounce", Message: "Hard bounce"},
+				BlockedAt: "2026-03-14T15:09:26Z",
+			}
+		}
+		client := &MockBrevoBlockedContactsClient{pages: []brevo.GetTransacBlockedContacts{
+			{Count: brevoBlockedContactsPageSize + 2, Contacts: first},
+			{Count: brevoBlockedContactsPageSize + 2, Contacts: []brevo.GetTransacBlockedContactsContacts{
+				{Email: "left@example.com", Reason: &brevo.GetTransacBlockedContactsReason{Code: "unsubscribedViaEmail"}, BlockedAt: "2026-03-14"},
+				{Email: "other@example.com", Reason: &brevo.GetTransacBlockedContactsReason{Code: "newCode"}},
+			}},
+		}}
+		service := NewBrevoSuppressionSource("api-key")
+		service.(*BrevoSuppressionSource).client = client
+
+		suppressions, err := service.Suppressions(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{0, brevoBlockedContactsPageSize}, client.offsets)
+		assert.Len(t, suppressions, brevoBlockedContactsPageSize+2)
+		assert.Equal(t, Suppression{
+			Email:     "gone0@example.com",
+			Reason:    SuppressionBounce,
+			Detail:    "Hard bounce",
+			Source:    ProviderBrevo,
+			CreatedAt: time.Date(2026, 3, 14, 15, 9, 26, 0, time.UTC),
+		}, suppressions[0])
+		assert.Equal(t, SuppressionUnsubscribe, suppressions[brevoBlockedContactsPageSize].Reason)
+		assert.Equal(t, time.Date(2026, 3, 14, 0, 0, 0, 0, time.UTC), suppressions[brevoBlockedContactsPageSize].CreatedAt)
+		assert.Equal(t, SuppressionManual, suppressions[brevoBlockedContactsPageSize+1].Reason)
+		assert.True(t, suppressions[brevoBlockedContactsPageSize+1].CreatedAt.IsZero())
+	})
+
+	t.Run("Failure - API error", func(t *testing.T) {
+		service := NewBrevoSuppressionSource("api-key")
+		service.(*BrevoSuppressionSource).client = &MockBrevoBlockedContactsClient{err: errors.New("401 Unauthorized")}
+
+		_, err := service.Suppressions(ctx)
+		assert.EqualError(t, err, "listing Brevo blocked contacts: 401 Unauthorized")
+	})
+}
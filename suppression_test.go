@@ -0,0 +1,257 @@
+package goat
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// MockSuppressionStore is a mock implementation of the SuppressionStore interface
+type MockSuppressionStore struct {
+	err error
+}
+
+func (m *MockSuppressionStore) Get(ctx context.Context, email string) (*Suppression, error) {
+	return nil, m.err
+}
+
+func (m *MockSuppressionStore) Put(ctx context.Context, suppression Suppression) error {
+	return m.err
+}
+
+func (m *MockSuppressionStore) Delete(ctx context.Context, email string) error {
+	return m.err
+}
+
+// withTimeNow sets the time returned by timeNow until the test ends.
+func withTimeNow(t *testing.T, now time.Time) {
+	original := timeNow
+	timeNow = func() time.Time { return now }
+	t.Cleanup(func() { timeNow = original })
+}
+
+// TestSuppressionExpired tests the Expired method of Suppression
+func TestSuppressionExpired(t *testing.T) {
+	now := time.Unix(1773500966, 0)
+	assert.False(t, Suppression{}.Expired(now))
+	assert.False(t, Suppression{ExpiresAt: now.Add(time.Second)}.Expired(now))
+	assert.True(t, Suppression{ExpiresAt: now}.Expired(now))
+}
+
+// TestSuppressionList tests the Add, Remove and Check methods of SuppressionList
+func TestSuppressionList(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1773500966, 0)
+	withTimeNow(t, now)
+
+	t.Run("Success - manual", func(t *testing.T) {
+		list := NewSuppressionList(nil, SuppressionOptions{})
+		assert.NoError(t, list.Add(ctx, Suppression{Email: " User@Example.com ", Reason: SuppressionManual, Detail: "asked by support"}))
+
+		suppression, err := list.Check(ctx, "user@EXAMPLE.com")
+		assert.NoError(t, err)
+		assert.Equal(t, &Suppression{
+			Email:     "user@example.com",
+			Reason:    SuppressionManual,
+			Detail:    "asked by support",
+			CreatedAt: now,
+		}, suppression)
+
+		assert.NoError(t, list.Remove(ctx, "USER@example.com"))
+		suppression, err = list.Check(ctx, "user@example.com")
+		assert.NoError(t, err)
+		assert.Nil(t, suppression)
+	})
+
+	t.Run("Success - soft bounce expires", func(t *testing.T) {
+		list := NewSuppressionList(nil, SuppressionOptions{SoftBounceTTL: time.Hour})
+		assert.NoError(t, list.Add(ctx, Suppression{Email: "full@example.com", Reason: SuppressionSoftBounce, CreatedAt: now.Add(-30 * time.Minute)}))
+
+		suppression, err := list.Check(ctx, "full@example.com")
+		assert.NoError(t, err)
+		assert.Equal(t, now.Add(30*time.Minute), suppression.ExpiresAt)
+
+		withTimeNow(t, now.Add(time.Hour))
+		suppression, err = list.Check(ctx, "full@example.com")
+		assert.NoError(t, err)
+		assert.Nil(t, suppression)
+	})
+
+	t.Run("Success - permanent suppression kept", func(t *testing.T) {
+		list := NewSuppressionList(nil, SuppressionOptions{})
+		assert.NoError(t, list.Add(ctx, Suppression{Email: "gone@example.com", Reason: SuppressionBounce}))
+		assert.NoError(t, list.Add(ctx, Suppression{Email: "gone@example.com", Reason: SuppressionSoftBounce}))
+
+		suppression, err := list.Check(ctx, "gone@example.com")
+		assert.NoError(t, err)
+		assert.Equal(t, SuppressionBounce, suppression.Reason)
+		assert.True(t, suppression.ExpiresAt.IsZero())
+	})
+
+	t.Run("Success - default soft bounce TTL", func(t *testing.T) {
+		list := NewSuppressionList(nil, SuppressionOptions{})
+		assert.Equal(t, DefaultSoftBounceTTL, list.softBounceTTL)
+	})
+
+	t.Run("Failure - missing email", func(t *testing.T) {
+		list := NewSuppressionList(nil, SuppressionOptions{})
+		assert.EqualError(t, list.Add(ctx, Suppression{Email: " "}), "suppression without email address")
+	})
+
+	t.Run("Failure - store error", func(t *testing.T) {
+		list := NewSuppressionList(&MockSuppressionStore{err: errors.New("database unavailable")}, SuppressionOptions{})
+		assert.EqualError(t, list.Add(ctx, Suppression{Email: "user@example.com", Reason: SuppressionSoftBounce}), "database unavailable")
+		_, err := list.Check(ctx, "user@example.com")
+		assert.EqualError(t, err, "database unavailable")
+	})
+}
+
+// TestSuppressionListHandleDeliveryEvent tests the HandleDeliveryEvent method of SuppressionList
+func TestSuppressionListHandleDeliveryEvent(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1773500966, 0)
+	withTimeNow(t, now)
+
+	tests := []struct {
+		name     string
+		event    DeliveryEvent
+		expected *Suppression
+	}{
+		{
+			name:  "bounce",
+			event: DeliveryEvent{Recipient: "gone@example.com", Status: DeliveryBounced, Reason: "550 unknown user", Provider: ProviderSendgrid, Time: now},
+			expected: &Suppression{
+				Email: "gone@example.com", Reason: SuppressionBounce, Detail: "550 unknown user", Source: ProviderSendgrid, CreatedAt: now,
+			},
+		},
+		{
+			name:  "soft bounce",
+			event: DeliveryEvent{Recipient: "full@example.com", Status: DeliverySoftBounced, Provider: ProviderBrevo, Time: now},
+			expected: &Suppression{
+				Email: "full@example.com", Reason: SuppressionSoftBounce, Source: ProviderBrevo, CreatedAt: now, ExpiresAt: now.Add(DefaultSoftBounceTTL),
+			},
+		},
+		{
+			name:     "complaint",
+			event:    DeliveryEvent{Recipient: "angry@example.com", Status: DeliveryComplained, Provider: ProviderBrevo, Time: now},
+			expected: &Suppression{Email: "angry@example.com", Reason: SuppressionComplaint, Source: ProviderBrevo, CreatedAt: now},
+		},
+		{
+			name:     "delivered",
+			event:    DeliveryEvent{Recipient: "user@example.com", Status: DeliveryDelivered, Time: now},
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run("Success - "+tt.name, func(t *testing.T) {
+			list := NewSuppressionList(nil, SuppressionOptions{})
+			assert.NoError(t, list.HandleDeliveryEvent(ctx, tt.event))
+
+			suppression, err := list.Check(ctx, tt.event.Recipient)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, suppression)
+		})
+	}
+
+	t.Run("Success - group unsubscribe ignored", func(t *testing.T) {
+		list := NewSuppressionList(nil, SuppressionOptions{})
+		assert.NoError(t, list.HandleSendgridEvent(ctx, SendgridEvent{Type: SendgridEventGroupUnsubscribe, Email: "user@example.com", ASMGroupID: 42}))
+
+		suppression, err := list.Check(ctx, "user@example.com")
+		assert.NoError(t, err)
+		assert.Nil(t, suppression)
+	})
+
+	t.Run("Success - group resubscribe keeps global unsubscriptions", func(t *testing.T) {
+		list := NewSuppressionList(nil, SuppressionOptions{})
+		assert.NoError(t, list.Unsubscribe(ctx, "listed@example.com"))
+		assert.NoError(t, list.HandleSendgridEvent(ctx, SendgridEvent{Type: SendgridEventUnsubscribe, Email: "user@example.com"}))
+
+		assert.NoError(t, list.HandleSendgridEvent(ctx, SendgridEvent{Type: SendgridEventGroupResubscribe, Email: "listed@example.com", ASMGroupID: 42}))
+		assert.NoError(t, list.HandleSendgridEvent(ctx, SendgridEvent{Type: SendgridEventGroupResubscribe, Email: "user@example.com", ASMGroupID: 42}))
+
+		for _, email := range []string{"listed@example.com", "user@example.com"} {
+			suppression, err := list.Check(ctx, email)
+			assert.NoError(t, err)
+			assert.Equal(t, SuppressionUnsubscribe, suppression.Reason)
+		}
+	})
+
+	t.Run("Success - Brevo event", func(t *testing.T) {
+		list := NewSuppressionList(nil, SuppressionOptions{})
+		assert.NoError(t, list.HandleBrevoEvent(ctx, BrevoEvent{Type: BrevoEventSpam, Email: "angry@example.com"}))
+		assert.NoError(t, list.HandleBrevoEvent(ctx, BrevoEvent{Type: "list_addition", Email: "user@example.com"}))
+
+		suppression, err := list.Check(ctx, "angry@example.com")
+		assert.NoError(t, err)
+		assert.Equal(t, SuppressionComplaint, suppression.Reason)
+		suppression, err = list.Check(ctx, "user@example.com")
+		assert.NoError(t, err)
+		assert.Nil(t, suppression)
+	})
+}
+
+// TestSuppressingSender tests the SuppressingSender methods
+func TestSuppressingSender(t *testing.T) {
+	ctx := context.Background()
+	list := NewSuppressionList(nil, SuppressionOptions{})
+	assert.NoError(t, list.Add(ctx, Suppression{Email: "gone@example.com", Reason: SuppressionBounce}))
+
+	t.Run("Success", func(t *testing.T) {
+		next := NewMockSenderService()
+		next.SendWithResultFunc = func(message *EmailMessage) (SendResult, error) {
+			return SendResult{MessageID: "id-1"}, nil
+		}
+		service := NewSuppressingSender(next, list, SuppressionReject)
+
+		result, err := service.SendWithResult(NewEmailMessage("user@example.com", "Hello", "Hi", ""))
+		assert.NoError(t, err)
+		assert.Equal(t, "id-1", result.MessageID)
+		assert.Len(t, next.GetSendCalls(), 1)
+	})
+
+	t.Run("Success - dropped", func(t *testing.T) {
+		next := NewMockSenderService()
+		service := NewSuppressingSender(next, list, SuppressionDrop)
+
+		result, err := service.SendWithResult(NewEmailMessage("Gone@example.com", "Hello", "Hi", ""))
+		assert.NoError(t, err)
+		assert.Equal(t, SendResult{}, result)
+		assert.Empty(t, next.GetSendCalls())
+	})
+
+	t.Run("Failure - rejected", func(t *testing.T) {
+		next := NewMockSenderService()
+		service := NewSuppressingSender(next, list, SuppressionReject)
+
+		err := service.Send(NewEmailMessage("gone@example.com", "Hello", "Hi", ""))
+		assert.ErrorIs(t, err, ErrRecipientSuppressed)
+		assert.EqualError(t, err, "recipient is suppressed: gone@example.com (bounce)")
+		assert.Empty(t, next.GetSendCalls())
+	})
+
+	t.Run("Failure - store error", func(t *testing.T) {
+		next := NewMockSenderService()
+		failing := NewSuppressionList(&MockSuppressionStore{err: errors.New("database unavailable")}, SuppressionOptions{})
+		service := NewSuppressingSender(next, failing, SuppressionDrop)
+
+		err := service.Send(NewEmailMessage("user@example.com", "Hello", "Hi", ""))
+		assert.EqualError(t, err, "checking suppression list: database unavailable")
+		assert.Empty(t, next.GetSendCalls())
+	})
+
+	t.Run("Failure - next service error", func(t *testing.T) {
+		next := NewMockSenderService()
+		next.SendWithResultFunc = func(message *EmailMessage) (SendResult, error) {
+			return SendResult{}, errors.New("provider unavailable")
+		}
+		service := NewSuppressingSender(next, list, SuppressionReject)
+
+		err := service.Send(NewEmailMessage("user@example.com", "Hello", "Hi", ""))
+		assert.EqualError(t, err, "provider unavailable")
+	})
+}
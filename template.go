@@ -9,23 +9,30 @@ import (
 
 // Template represents an email template
 type Template struct {
-	Name       string      // Name of the template
-	ContentRaw string      // Raw HTML content with potential template variables
-	Data       interface{} // Data containing the template variables values
+	Name       string           // Name of the template
+	ContentRaw string           // Raw HTML content with potential template variables
+	Data       interface{}      // Data containing the template variables values
+	Funcs      template.FuncMap // Optional functions available to this template only, see DefaultFuncs
 }
 
 // Render renders a template with the given data
 func (t Template) Render() (string, error) {
 
 	// Render the parent template
-	tmpl, err := template.New(t.Name).Parse(t.ContentRaw)
+	tmpl, err := template.New(t.Name).Funcs(templateFuncs(t.Funcs)).Parse(t.ContentRaw)
 	if err != nil {
 		return "", err
 	}
 
+	return execute(tmpl, t.Data)
+}
+
+// execute executes a parsed template with the given data
+func execute(tmpl *template.Template, data interface{}) (string, error) {
+
 	// Execute the template
 	var buf bytes.Buffer
-	err = tmpl.Execute(&buf, t.Data)
+	err := tmpl.Execute(&buf, data)
 	if err != nil {
 		return "", err
 	}
@@ -0,0 +1,275 @@
+package goat
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	_globalFuncsMu sync.RWMutex
+	_globalFuncs   = template.FuncMap{}
+)
+
+// currencySymbols maps ISO 4217 codes to the symbol printed by the money function.
+var currencySymbols = map[string]string{
+	"USD": "$",
+	"EUR": "€",
+	"GBP": "£",
+	"JPY": "¥",
+	"INR": "₹",
+}
+
+// zeroDecimalCurrencies lists currencies formatted without a fractional part.
+var zeroDecimalCurrencies = map[string]bool{
+	"JPY": true,
+	"KRW": true,
+}
+
+// DefaultFuncs returns a copy of the built-in template functions registered on every goat template.
+//
+// The functions are designed to be pipeline friendly, the piped value always being the last argument:
+//
+//	{{.Name | default "there"}}
+//	{{.Name | upper}}, {{.Name | lower}}, {{.Name | title}}
+//	{{.Description | truncate 80}}
+//	{{.CreatedAt | formatDate "Jan 2, 2006"}}
+//	{{.Total | money "EUR"}}
+//	{{buildURL "https://example.com/verify" "token" .Token "user" .ID}}
+//	{{.Count}} {{.Count | pluralize "item" "items"}}
+func DefaultFuncs() template.FuncMap {
+	return template.FuncMap{
+		"default":    defaultValue,
+		"upper":      strings.ToUpper,
+		"lower":      strings.ToLower,
+		"title":      title,
+		"trim":       strings.TrimSpace,
+		"truncate":   truncate,
+		"formatDate": formatDate,
+		"money":      money,
+		"urlQuery":   url.QueryEscape,
+		"buildURL":   buildURL,
+		"pluralize":  pluralize,
+	}
+}
+
+// RegisterTemplateFunc registers a function available to every template rendered afterwards.
+// A function registered under the name of a built-in one overrides it.
+// It returns a function restoring the previously registered functions.
+func RegisterTemplateFunc(name string, fn interface{}) func() {
+	return RegisterTemplateFuncs(template.FuncMap{name: fn})
+}
+
+// RegisterTemplateFuncs registers several functions available to every template rendered afterwards.
+// It returns a function restoring the previously registered functions.
+func RegisterTemplateFuncs(funcs template.FuncMap) func() {
+	_globalFuncsMu.Lock()
+	defer _globalFuncsMu.Unlock()
+
+	prev := make(template.FuncMap, len(_globalFuncs))
+	for name, fn := range _globalFuncs {
+		prev[name] = fn
+	}
+	for name, fn := range funcs {
+		_globalFuncs[name] = fn
+	}
+	return func() {
+		_globalFuncsMu.Lock()
+		defer _globalFuncsMu.Unlock()
+		_globalFuncs = prev
+	}
+}
+
+// templateFuncs merges the built-in, global and per template functions, in increasing priority.
+func templateFuncs(local template.FuncMap) template.FuncMap {
+	funcs := DefaultFuncs()
+
+	_globalFuncsMu.RLock()
+	for name, fn := range _globalFuncs {
+		funcs[name] = fn
+	}
+	_globalFuncsMu.RUnlock()
+
+	for name, fn := range local {
+		funcs[name] = fn
+	}
+	return funcs
+}
+
+// defaultValue returns value, or def when value is nil, empty or the zero value of its type.
+func defaultValue(def, value interface{}) interface{} {
+	if isEmptyValue(value) {
+		return def
+	}
+	return value
+}
+
+// isEmptyValue reports whether value is nil, an empty container or the zero value of its type.
+func isEmptyValue(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array, reflect.Chan:
+		return v.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	default:
+		return v.IsZero()
+	}
+}
+
+// title upper-cases the first letter of every word of s.
+func title(s string) string {
+	prev := ' '
+	return strings.Map(func(r rune) rune {
+		isStart := unicode.IsSpace(prev) || prev == '-'
+		prev = r
+		if isStart {
+			return unicode.ToTitle(r)
+		}
+		return r
+	}, s)
+}
+
+// truncate shortens s to at most length runes, ending it with an ellipsis when cut.
+func truncate(length int, s string) string {
+	if length < 0 || utf8.RuneCountInString(s) <= length {
+		return s
+	}
+	if length == 0 {
+		return ""
+	}
+	runes := []rune(s)
+	return strings.TrimRightFunc(string(runes[:length-1]), unicode.IsSpace) + "…"
+}
+
+// formatDate formats a time.Time (or *time.Time) with the given Go layout.
+// A nil or zero date renders as an empty string.
+func formatDate(layout string, date interface{}) (string, error) {
+	switch d := date.(type) {
+	case nil:
+		return "", nil
+	case time.Time:
+		if d.IsZero() {
+			return "", nil
+		}
+		return d.Format(layout), nil
+	case *time.Time:
+		if d == nil || d.IsZero() {
+			return "", nil
+		}
+		return d.Format(layout), nil
+	default:
+		return "", fmt.Errorf("formatDate: unsupported type %T", date)
+	}
+}
+
+// money formats a numeric amount in the given ISO 4217 currency, e.g. "$1,234.50" or "1,234.50 CHF".
+func money(currency string, amount interface{}) (string, error) {
+	value, err := toFloat(amount)
+	if err != nil {
+		return "", fmt.Errorf("money: %w", err)
+	}
+
+	currency = strings.ToUpper(currency)
+	decimals := 2
+	if zeroDecimalCurrencies[currency] {
+		decimals = 0
+	}
+
+	sign := ""
+	if value < 0 {
+		sign = "-"
+		value = math.Abs(value)
+	}
+
+	formatted := strconv.FormatFloat(value, 'f', decimals, 64)
+	integer, fraction, _ := strings.Cut(formatted, ".")
+	formatted = groupThousands(integer)
+	if fraction != "" {
+		formatted += "." + fraction
+	}
+
+	if symbol, ok := currencySymbols[currency]; ok {
+		return sign + symbol + formatted, nil
+	}
+	return sign + formatted + " " + currency, nil
+}
+
+// groupThousands inserts a comma between every group of three digits.
+func groupThousands(digits string) string {
+	if len(digits) <= 3 {
+		return digits
+	}
+	var b strings.Builder
+	head := len(digits) % 3
+	if head > 0 {
+		b.WriteString(digits[:head])
+	}
+	for i := head; i < len(digits); i += 3 {
+		if b.Len() > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(digits[i : i+3])
+	}
+	return b.String()
+}
+
+// buildURL appends the given key/value pairs to base as an escaped query string.
+func buildURL(base string, pairs ...interface{}) (string, error) {
+	if len(pairs)%2 != 0 {
+		return "", fmt.Errorf("buildURL: odd number of query arguments")
+	}
+
+	u, err := url.Parse(base)
+	if err != nil {
+		return "", fmt.Errorf("buildURL: %w", err)
+	}
+	if u.Scheme != "" && u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "mailto" {
+		return "", fmt.Errorf("buildURL: unsafe scheme %q", u.Scheme)
+	}
+
+	query := u.Query()
+	for i := 0; i < len(pairs); i += 2 {
+		query.Add(fmt.Sprint(pairs[i]), fmt.Sprint(pairs[i+1]))
+	}
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// pluralize returns singular when count is exactly one and plural otherwise.
+func pluralize(singular, plural string, count interface{}) (string, error) {
+	value, err := toFloat(count)
+	if err != nil {
+		return "", fmt.Errorf("pluralize: %w", err)
+	}
+	if value == 1 {
+		return singular, nil
+	}
+	return plural, nil
+}
+
+// toFloat converts any numeric value to a float64.
+func toFloat(value interface{}) (float64, error) {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), nil
+	default:
+		return 0, fmt.Errorf("unsupported numeric type %T", value)
+	}
+}
@@ -0,0 +1,115 @@
+package goat
+
+import (
+	"strings"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestDefaultFuncs tests the built-in template functions through Template.Render
+func TestDefaultFuncs(t *testing.T) {
+	date := time.Date(2025, time.March, 7, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		content  string
+		data     interface{}
+		expected string
+	}{
+		{"default with value", `{{.Name | default "there"}}`, map[string]string{"Name": "John"}, "John"},
+		{"default with empty value", `{{.Name | default "there"}}`, map[string]string{"Name": ""}, "there"},
+		{"default with missing key", `{{.Name | default "there"}}`, map[string]interface{}{}, "there"},
+		{"upper", `{{.Name | upper}}`, map[string]string{"Name": "john"}, "JOHN"},
+		{"lower", `{{.Name | lower}}`, map[string]string{"Name": "JOHN"}, "john"},
+		{"title", `{{.Name | title}}`, map[string]string{"Name": "john doe-smith"}, "John Doe-Smith"},
+		{"trim", `[{{.Name | trim}}]`, map[string]string{"Name": "  john "}, "[john]"},
+		{"truncate short", `{{.Text | truncate 10}}`, map[string]string{"Text": "short"}, "short"},
+		{"truncate long", `{{.Text | truncate 10}}`, map[string]string{"Text": "a rather long sentence"}, "a rather…"},
+		{"truncate runes", `{{.Text | truncate 3}}`, map[string]string{"Text": "héllo"}, "hé…"},
+		{"formatDate", `{{.Date | formatDate "Jan 2, 2006"}}`, map[string]interface{}{"Date": date}, "Mar 7, 2025"},
+		{"formatDate pointer", `{{.Date | formatDate "2006-01-02"}}`, map[string]interface{}{"Date": &date}, "2025-03-07"},
+		{"formatDate zero", `{{.Date | formatDate "2006-01-02"}}`, map[string]interface{}{"Date": time.Time{}}, ""},
+		{"money USD", `{{.Total | money "USD"}}`, map[string]interface{}{"Total": 1234.5}, "$1,234.50"},
+		{"money EUR negative", `{{.Total | money "eur"}}`, map[string]interface{}{"Total": -12}, "-€12.00"},
+		{"money JPY", `{{.Total | money "JPY"}}`, map[string]interface{}{"Total": 1234567}, "¥1,234,567"},
+		{"money unknown currency", `{{.Total | money "CHF"}}`, map[string]interface{}{"Total": 99.999}, "100.00 CHF"},
+		{"urlQuery", `{{.Q | urlQuery}}`, map[string]string{"Q": "a b&c"}, "a+b%26c"},
+		{"buildURL", `{{buildURL "https://example.com/verify" "token" .Token "id" 42}}`, map[string]string{"Token": "a&b"}, "https://example.com/verify?id=42&token=a%26b"},
+		{"pluralize singular", `{{.Count}} {{.Count | pluralize "item" "items"}}`, map[string]int{"Count": 1}, "1 item"},
+		{"pluralize plural", `{{.Count}} {{.Count | pluralize "item" "items"}}`, map[string]int{"Count": 3}, "3 items"},
+		{"pluralize zero", `{{.Count | pluralize "item" "items"}}`, map[string]float64{"Count": 0}, "items"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl := Template{Name: tt.name, ContentRaw: tt.content, Data: tt.data}
+
+			result, err := tmpl.Render()
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		for _, content := range []string{
+			`{{.Date | formatDate "2006"}}`,
+			`{{.Date | money "USD"}}`,
+			`{{.Date | pluralize "a" "b"}}`,
+			`{{buildURL "javascript:alert(1)"}}`,
+			`{{buildURL "https://example.com" "key"}}`,
+		} {
+			tmpl := Template{Name: "invalid", ContentRaw: content, Data: map[string]string{"Date": "today"}}
+
+			result, err := tmpl.Render()
+			assert.Error(t, err, content)
+			assert.Equal(t, "", result)
+		}
+	})
+}
+
+// TestRegisterTemplateFuncs tests global and per template function registration
+func TestRegisterTemplateFuncs(t *testing.T) {
+	t.Run("global function", func(t *testing.T) {
+		restore := RegisterTemplateFunc("shout", func(s string) string { return s + "!" })
+		defer restore()
+
+		result, err := Template{Name: "global", ContentRaw: `{{"hi" | shout}}`}.Render()
+		assert.NoError(t, err)
+		assert.Equal(t, "hi!", result)
+	})
+
+	t.Run("global function overrides built-in", func(t *testing.T) {
+		restore := RegisterTemplateFuncs(template.FuncMap{"upper": strings.ToLower})
+		defer restore()
+
+		result, err := Template{Name: "override", ContentRaw: `{{"HI" | upper}}`}.Render()
+		assert.NoError(t, err)
+		assert.Equal(t, "hi", result)
+	})
+
+	t.Run("restore removes function", func(t *testing.T) {
+		restore := RegisterTemplateFunc("shout", func(s string) string { return s + "!" })
+		restore()
+
+		_, err := Template{Name: "restored", ContentRaw: `{{"hi" | shout}}`}.Render()
+		assert.Error(t, err)
+	})
+
+	t.Run("per template function overrides global", func(t *testing.T) {
+		restore := RegisterTemplateFunc("greet", func() string { return "global" })
+		defer restore()
+
+		tmpl := Template{
+			Name:       "local",
+			ContentRaw: `{{greet}}`,
+			Funcs:      template.FuncMap{"greet": func() string { return "local" }},
+		}
+
+		result, err := tmpl.Render()
+		assert.NoError(t, err)
+		assert.Equal(t, "local", result)
+	})
+}
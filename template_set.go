@@ -0,0 +1,241 @@
+package goat
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Template source kinds, identified by file extension in a TemplateSet directory.
+const (
+	TemplateKindHTML     = ".html"
+	TemplateKindText     = ".txt"
+	TemplateKindMarkdown = ".md"
+)
+
+// MarkdownLayoutFile is the optional file of a TemplateSet directory holding the layout of its Markdown templates.
+const MarkdownLayoutFile = "_layout.html"
+
+// TemplateSet is a collection of email templates loaded from a directory.
+//
+// Files sharing the same path without extension form a single template named after it
+// (e.g. "auth/reset" for auth/reset.html and auth/reset.txt):
+//   - name.html holds the HTML body and name.txt the plain text body;
+//   - name.md holds a Markdown source producing both bodies (see MarkdownTemplate);
+//   - name.json, name.yaml or name.yml hold sample data, and name.<fixture>.json (or .yaml, .yml)
+//     additional named samples, used by previews and snapshot tests.
+//
+// Files and directories starting with an underscore or a dot are ignored, except _layout.html
+// which holds the layout of the Markdown templates.
+type TemplateSet struct {
+	entries map[string]*TemplateEntry
+}
+
+// TemplateEntry holds the sources of a single template of a TemplateSet.
+type TemplateEntry struct {
+	Name     string            // Name of the template, its path without extension
+	Sources  map[string]string // Raw sources indexed by kind (TemplateKindHTML, TemplateKindText, TemplateKindMarkdown)
+	Files    map[string]string // Paths of the sources indexed by kind
+	Fixtures map[string]string // Paths of the sample data files indexed by fixture name ("" for the default one)
+
+	fsys   fs.FS
+	layout string
+}
+
+// LoadTemplateSet loads every template found in fsys, e.g. os.DirFS("templates").
+// Every source is parsed so that syntax errors are reported at load time rather than at render time.
+func LoadTemplateSet(fsys fs.FS) (*TemplateSet, error) {
+	set, err := scanTemplateSet(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range set.entries {
+		if err := entry.parse(); err != nil {
+			return nil, err
+		}
+	}
+
+	return set, nil
+}
+
+// scanTemplateSet reads the templates and fixtures found in fsys without parsing them.
+func scanTemplateSet(fsys fs.FS) (*TemplateSet, error) {
+	set := &TemplateSet{entries: make(map[string]*TemplateEntry)}
+
+	layout, err := fs.ReadFile(fsys, MarkdownLayoutFile)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	var fixtures []string
+	err = fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p != "." && (strings.HasPrefix(d.Name(), "_") || strings.HasPrefix(d.Name(), ".")) {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		ext := path.Ext(p)
+		base := strings.TrimSuffix(p, ext)
+		switch ext {
+		case TemplateKindHTML, TemplateKindText, TemplateKindMarkdown:
+			content, err := fs.ReadFile(fsys, p)
+			if err != nil {
+				return err
+			}
+			entry := set.entry(base, fsys, string(layout))
+			entry.Sources[ext] = string(content)
+			entry.Files[ext] = p
+		case ".json", ".yaml", ".yml":
+			fixtures = append(fixtures, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Fixtures are matched once every template is known, "a.b.json" belonging to
+	// template "a.b" if it exists and being fixture "b" of template "a" otherwise.
+	for _, p := range fixtures {
+		base := strings.TrimSuffix(p, path.Ext(p))
+		if entry, ok := set.entries[base]; ok {
+			entry.Fixtures[""] = p
+			continue
+		}
+		dir, file := path.Split(base)
+		if dot := strings.LastIndex(file, "."); dot > 0 {
+			if entry, ok := set.entries[dir+file[:dot]]; ok {
+				entry.Fixtures[file[dot+1:]] = p
+			}
+		}
+	}
+
+	return set, nil
+}
+
+// entry returns the entry with the given name, creating it if needed.
+func (s *TemplateSet) entry(name string, fsys fs.FS, layout string) *TemplateEntry {
+	entry, ok := s.entries[name]
+	if !ok {
+		entry = &TemplateEntry{
+			Name:     name,
+			Sources:  make(map[string]string),
+			Files:    make(map[string]string),
+			Fixtures: make(map[string]string),
+			fsys:     fsys,
+			layout:   layout,
+		}
+		s.entries[name] = entry
+	}
+	return entry
+}
+
+// Names returns the sorted names of the templates of the set.
+func (s *TemplateSet) Names() []string {
+	names := make([]string, 0, len(s.entries))
+	for name := range s.entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Lookup returns the template with the given name.
+func (s *TemplateSet) Lookup(name string) (*TemplateEntry, bool) {
+	entry, ok := s.entries[name]
+	return entry, ok
+}
+
+// Render renders the template with the given name and returns the plain text and HTML bodies.
+func (s *TemplateSet) Render(name string, data interface{}) (plainText string, htmlContent string, err error) {
+	entry, ok := s.Lookup(name)
+	if !ok {
+		return "", "", fmt.Errorf("template %q not found", name)
+	}
+	return entry.Render(data)
+}
+
+// parse checks the syntax of every source of the entry.
+func (e *TemplateEntry) parse() error {
+	if _, ok := e.Sources[TemplateKindMarkdown]; ok && len(e.Sources) > 1 {
+		return fmt.Errorf("template %q: a Markdown source cannot be combined with HTML or text sources", e.Name)
+	}
+	for kind, source := range e.Sources {
+		if _, err := template.New(e.Files[kind]).Funcs(templateFuncs(nil)).Parse(source); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Render renders the template with the given data and returns the plain text and HTML bodies.
+// A body without source is returned empty.
+func (e *TemplateEntry) Render(data interface{}) (plainText string, htmlContent string, err error) {
+	if source, ok := e.Sources[TemplateKindMarkdown]; ok {
+		return MarkdownTemplate{Name: e.Name, ContentRaw: source, Data: data, Layout: e.layout}.Render()
+	}
+
+	if source, ok := e.Sources[TemplateKindText]; ok {
+		plainText, err = Template{Name: e.Files[TemplateKindText], ContentRaw: source, Data: data}.Render()
+		if err != nil {
+			return "", "", err
+		}
+	}
+	if source, ok := e.Sources[TemplateKindHTML]; ok {
+		htmlContent, err = Template{Name: e.Files[TemplateKindHTML], ContentRaw: source, Data: data}.Render()
+		if err != nil {
+			return "", "", err
+		}
+	}
+	return plainText, htmlContent, nil
+}
+
+// FixtureNames returns the sorted names of the sample data files of the template.
+func (e *TemplateEntry) FixtureNames() []string {
+	names := make([]string, 0, len(e.Fixtures))
+	for name := range e.Fixtures {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Fixture decodes the sample data file with the given name ("" for the default one).
+func (e *TemplateEntry) Fixture(name string) (interface{}, error) {
+	p, ok := e.Fixtures[name]
+	if !ok {
+		return nil, fmt.Errorf("template %q: fixture %q not found", e.Name, name)
+	}
+
+	content, err := fs.ReadFile(e.fsys, p)
+	if err != nil {
+		return nil, err
+	}
+
+	var data interface{}
+	if path.Ext(p) == ".json" {
+		err = json.Unmarshal(content, &data)
+	} else {
+		err = yaml.Unmarshal(content, &data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fixture %s: %w", p, err)
+	}
+	return data, nil
+}
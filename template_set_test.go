@@ -0,0 +1,117 @@
+package goat
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testTemplateFS returns a template directory used by the TemplateSet tests
+func testTemplateFS() fstest.MapFS {
+	return fstest.MapFS{
+		"welcome.html":          {Data: []byte("<p>Hello {{.Name}}</p>")},
+		"welcome.txt":           {Data: []byte("Hello {{.Name}}")},
+		"welcome.json":          {Data: []byte(`{"Name": "John"}`)},
+		"welcome.french.yaml":   {Data: []byte("Name: Jean\n")},
+		"auth/reset.md":         {Data: []byte("# Reset\n\n[Reset]({{.URL}})")},
+		"auth/reset.yml":        {Data: []byte("URL: https://example.com/reset\n")},
+		"v1.2.txt":              {Data: []byte("Version")},
+		"v1.2.json":             {Data: []byte(`{}`)},
+		"_layout.html":          {Data: []byte("<main>{{.Content}}</main>")},
+		"_partials/footer.html": {Data: []byte("{{.Broken")},
+		"orphan.json":           {Data: []byte(`{}`)},
+		"README":                {Data: []byte("not a template")},
+	}
+}
+
+// TestLoadTemplateSet tests the LoadTemplateSet function
+func TestLoadTemplateSet(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		set, err := LoadTemplateSet(testTemplateFS())
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"auth/reset", "v1.2", "welcome"}, set.Names())
+
+		entry, ok := set.Lookup("welcome")
+		assert.True(t, ok)
+		assert.Equal(t, "welcome.html", entry.Files[TemplateKindHTML])
+		assert.Equal(t, "welcome.txt", entry.Files[TemplateKindText])
+		assert.Equal(t, []string{"", "french"}, entry.FixtureNames())
+
+		entry, _ = set.Lookup("v1.2")
+		assert.Equal(t, []string{""}, entry.FixtureNames())
+
+		_, ok = set.Lookup("orphan")
+		assert.False(t, ok)
+	})
+
+	t.Run("Failure - syntax error", func(t *testing.T) {
+		fsys := testTemplateFS()
+		fsys["broken.html"] = &fstest.MapFile{Data: []byte("{{.Name")}
+
+		_, err := LoadTemplateSet(fsys)
+		assert.Error(t, err)
+	})
+
+	t.Run("Failure - Markdown combined with HTML", func(t *testing.T) {
+		fsys := testTemplateFS()
+		fsys["auth/reset.html"] = &fstest.MapFile{Data: []byte("<p>Reset</p>")}
+
+		_, err := LoadTemplateSet(fsys)
+		assert.Error(t, err)
+	})
+}
+
+// TestTemplateSet_Render tests the Render method of TemplateSet and TemplateEntry
+func TestTemplateSet_Render(t *testing.T) {
+	set, err := LoadTemplateSet(testTemplateFS())
+	assert.NoError(t, err)
+
+	t.Run("HTML and text sources with fixtures", func(t *testing.T) {
+		entry, _ := set.Lookup("welcome")
+
+		data, err := entry.Fixture("")
+		assert.NoError(t, err)
+		plainText, html, err := entry.Render(data)
+		assert.NoError(t, err)
+		assert.Equal(t, "Hello John", plainText)
+		assert.Equal(t, "<p>Hello John</p>", html)
+
+		data, err = entry.Fixture("french")
+		assert.NoError(t, err)
+		plainText, _, err = entry.Render(data)
+		assert.NoError(t, err)
+		assert.Equal(t, "Hello Jean", plainText)
+	})
+
+	t.Run("Markdown source with layout", func(t *testing.T) {
+		plainText, html, err := set.Render("auth/reset", map[string]string{"URL": "https://example.com/reset"})
+		assert.NoError(t, err)
+		assert.Equal(t, "Reset\n=====\n\nReset (https://example.com/reset)\n", plainText)
+		assert.Contains(t, html, "<main><h1")
+	})
+
+	t.Run("Text source only", func(t *testing.T) {
+		plainText, html, err := set.Render("v1.2", nil)
+		assert.NoError(t, err)
+		assert.Equal(t, "Version", plainText)
+		assert.Equal(t, "", html)
+	})
+
+	t.Run("Failure - missing data", func(t *testing.T) {
+		_, _, err := set.Render("welcome", nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("Failure - unknown template", func(t *testing.T) {
+		_, _, err := set.Render("unknown", nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("Failure - unknown fixture", func(t *testing.T) {
+		entry, _ := set.Lookup("welcome")
+
+		_, err := entry.Fixture("german")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,142 @@
+package goat
+
+import (
+	"fmt"
+	"io/fs"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Zapharaos/go-at/internal/fsutil"
+)
+
+// DefaultWatchInterval is the interval at which a TemplateWatcher checks its directory for changes.
+const DefaultWatchInterval = 2 * time.Second
+
+// TemplateReloadEvent reports a reload attempt of a TemplateWatcher.
+type TemplateReloadEvent struct {
+	Time      time.Time // Time of the reload attempt
+	Templates []string  // Names of the templates now served
+	Err       error     // Load error, in which case the last good version keeps being served
+}
+
+// TemplateWatcher serves a TemplateSet loaded from a directory and reloads it when its files change,
+// so templates can be fixed without redeploying.
+//
+// The directory is polled for changes. A changed directory is reloaded as a whole and swapped
+// atomically; if it fails to load (e.g. a syntax error), the last good version keeps being served
+// and the error is reported through the event callback.
+type TemplateWatcher struct {
+	fsys     fs.FS
+	interval time.Duration
+	onEvent  func(TemplateReloadEvent)
+
+	set     atomic.Pointer[TemplateSet]
+	mu      sync.Mutex // serializes reloads
+	version uint64
+
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// WatchTemplateSet loads the templates of fsys and starts watching it every interval
+// (DefaultWatchInterval when zero). onEvent, if not nil, is called after every reload attempt
+// triggered by a change. The initial load must succeed.
+func WatchTemplateSet(fsys fs.FS, interval time.Duration, onEvent func(TemplateReloadEvent)) (*TemplateWatcher, error) {
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+
+	w := &TemplateWatcher{
+		fsys:     fsys,
+		interval: interval,
+		onEvent:  onEvent,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	version, err := fsutil.Fingerprint(fsys)
+	if err != nil {
+		return nil, err
+	}
+	set, err := LoadTemplateSet(fsys)
+	if err != nil {
+		return nil, err
+	}
+	w.version = version
+	w.set.Store(set)
+
+	go w.run()
+	return w, nil
+}
+
+// Set returns the last successfully loaded TemplateSet.
+func (w *TemplateWatcher) Set() *TemplateSet {
+	return w.set.Load()
+}
+
+// Render renders a template of the last successfully loaded TemplateSet.
+func (w *TemplateWatcher) Render(name string, data interface{}) (plainText string, htmlContent string, err error) {
+	return w.Set().Render(name, data)
+}
+
+// Reload checks the directory immediately and reloads it if it changed since the last attempt.
+// It returns the load error, if any.
+func (w *TemplateWatcher) Reload() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	version, err := fsutil.Fingerprint(w.fsys)
+	if err != nil {
+		w.emit(TemplateReloadEvent{Time: time.Now(), Templates: w.Set().Names(), Err: err})
+		return err
+	}
+	if version == w.version {
+		return nil
+	}
+	// The version is recorded even on failure, so a broken file is reported once rather than on every poll.
+	w.version = version
+
+	set, err := LoadTemplateSet(w.fsys)
+	if err != nil {
+		err = fmt.Errorf("reloading templates: %w", err)
+		w.emit(TemplateReloadEvent{Time: time.Now(), Templates: w.Set().Names(), Err: err})
+		return err
+	}
+
+	w.set.Store(set)
+	w.emit(TemplateReloadEvent{Time: time.Now(), Templates: set.Names()})
+	return nil
+}
+
+// Close stops watching the directory. The last loaded templates remain available.
+func (w *TemplateWatcher) Close() error {
+	w.closeOnce.Do(func() { close(w.stop) })
+	<-w.done
+	return nil
+}
+
+// run polls the directory until the watcher is closed.
+func (w *TemplateWatcher) run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			_ = w.Reload()
+		}
+	}
+}
+
+// emit calls the event callback, if any.
+func (w *TemplateWatcher) emit(event TemplateReloadEvent) {
+	if w.onEvent != nil {
+		w.onEvent(event)
+	}
+}
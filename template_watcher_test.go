@@ -0,0 +1,126 @@
+package goat
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeTemplate writes a template file, moving its modification time forward so the change is detected
+func writeTemplate(t *testing.T, dir, name, content string) {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	next := time.Now().Add(time.Duration(len(content)) * time.Second)
+	assert.NoError(t, os.Chtimes(path, next, next))
+}
+
+// eventRecorder collects the events of a TemplateWatcher
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []TemplateReloadEvent
+}
+
+func (r *eventRecorder) record(event TemplateReloadEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *eventRecorder) all() []TemplateReloadEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]TemplateReloadEvent(nil), r.events...)
+}
+
+// TestWatchTemplateSet tests the WatchTemplateSet function
+func TestWatchTemplateSet(t *testing.T) {
+	t.Run("Failure - initial load", func(t *testing.T) {
+		dir := t.TempDir()
+		writeTemplate(t, dir, "welcome.txt", "{{.Name")
+
+		w, err := WatchTemplateSet(os.DirFS(dir), time.Hour, nil)
+		assert.Error(t, err)
+		assert.Nil(t, w)
+	})
+
+	t.Run("Success - reloads changed files on poll", func(t *testing.T) {
+		dir := t.TempDir()
+		writeTemplate(t, dir, "welcome.txt", "Hello {{.Name}}")
+
+		recorder := &eventRecorder{}
+		w, err := WatchTemplateSet(os.DirFS(dir), 10*time.Millisecond, recorder.record)
+		assert.NoError(t, err)
+		defer w.Close()
+
+		writeTemplate(t, dir, "welcome.txt", "Hi {{.Name}}")
+
+		assert.Eventually(t, func() bool {
+			plainText, _, err := w.Render("welcome", map[string]string{"Name": "John"})
+			return err == nil && plainText == "Hi John"
+		}, 5*time.Second, 10*time.Millisecond)
+
+		events := recorder.all()
+		assert.NotEmpty(t, events)
+		assert.NoError(t, events[0].Err)
+		assert.Equal(t, []string{"welcome"}, events[0].Templates)
+	})
+}
+
+// TestTemplateWatcher_Reload tests the Reload method of TemplateWatcher
+func TestTemplateWatcher_Reload(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, dir, "welcome.txt", "Hello {{.Name}}")
+
+	recorder := &eventRecorder{}
+	w, err := WatchTemplateSet(os.DirFS(dir), time.Hour, recorder.record)
+	assert.NoError(t, err)
+	defer w.Close()
+
+	t.Run("unchanged directory", func(t *testing.T) {
+		assert.NoError(t, w.Reload())
+		assert.Empty(t, recorder.all())
+	})
+
+	t.Run("broken template keeps the last good version", func(t *testing.T) {
+		writeTemplate(t, dir, "welcome.txt", "Hello {{.Name")
+
+		assert.Error(t, w.Reload())
+		events := recorder.all()
+		assert.Len(t, events, 1)
+		assert.Error(t, events[0].Err)
+
+		plainText, _, err := w.Render("welcome", map[string]string{"Name": "John"})
+		assert.NoError(t, err)
+		assert.Equal(t, "Hello John", plainText)
+
+		// the same broken version is not reported twice
+		assert.NoError(t, w.Reload())
+		assert.Len(t, recorder.all(), 1)
+	})
+
+	t.Run("fixed template is served", func(t *testing.T) {
+		writeTemplate(t, dir, "welcome.txt", "Welcome {{.Name}}")
+		writeTemplate(t, dir, "bye.txt", "Bye")
+
+		assert.NoError(t, w.Reload())
+		events := recorder.all()
+		assert.Len(t, events, 2)
+		assert.NoError(t, events[1].Err)
+		assert.Equal(t, []string{"bye", "welcome"}, events[1].Templates)
+
+		plainText, _, err := w.Render("welcome", map[string]string{"Name": "John"})
+		assert.NoError(t, err)
+		assert.Equal(t, "Welcome John", plainText)
+	})
+
+	t.Run("close is idempotent", func(t *testing.T) {
+		assert.NoError(t, w.Close())
+		assert.NoError(t, w.Close())
+		assert.NotNil(t, w.Set())
+	})
+}
@@ -0,0 +1,151 @@
+package goat
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/textproto"
+	"regexp"
+	"strings"
+)
+
+// messageIDPattern matches a Message-ID with its chevrons.
+var messageIDPattern = regexp.MustCompile(`^<[^<>\s@]+@[^<>\s@]+>$`)
+
+// threadingHeaderNames are the canonical names of the headers set from the threading fields of a message.
+var threadingHeaderNames = map[string]bool{
+	"Message-Id":  true,
+	"In-Reply-To": true,
+	"References":  true,
+}
+
+// NewMessageID returns a unique Message-ID in domain, "localhost" when empty. Set it as EmailMessage.MessageID
+// and store it before sending to thread later messages after this one, whatever the provider.
+func NewMessageID(domain string) (string, error) {
+	if domain == "" {
+		domain = "localhost"
+	}
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", fmt.Errorf("generating Message-ID: %w", err)
+	}
+	return fmt.Sprintf("<%d.%s@%s>", timeNow().UnixNano(), hex.EncodeToString(random), domain), nil
+}
+
+// normalizeMessageID returns the Message-ID with its chevrons, or an empty string.
+func normalizeMessageID(id string) string {
+	id = strings.TrimSpace(id)
+	if id == "" || strings.HasPrefix(id, "<") {
+		return id
+	}
+	return "<" + id + ">"
+}
+
+// WithMessageID sets the Message-ID of the message, with or without chevrons, and returns the message for chaining.
+func (m *EmailMessage) WithMessageID(id string) *EmailMessage {
+	m.MessageID = normalizeMessageID(id)
+	return m
+}
+
+// WithInReplyTo makes the message a reply to the message parentID, given the References of the parent,
+// and returns the message for chaining. The references of the message become the parent ones followed by parentID.
+func (m *EmailMessage) WithInReplyTo(parentID string, parentReferences ...string) *EmailMessage {
+	m.InReplyTo = normalizeMessageID(parentID)
+
+	seen := make(map[string]bool, len(parentReferences)+1)
+	m.References = make([]string, 0, len(parentReferences)+1)
+	for _, id := range append(append([]string{}, parentReferences...), m.InReplyTo) {
+		if id = normalizeMessageID(id); id != "" && !seen[id] {
+			seen[id] = true
+			m.References = append(m.References, id)
+		}
+	}
+	return m
+}
+
+// FollowUp returns a message to the same recipient, from the same sender, threaded after m once sent with result.
+//
+// The parent Message-ID is m.MessageID when set, else result.MessageID for providers returning the Message-ID
+// (SMTP and Brevo). SendGrid returns its own identifier instead, so set MessageID before sending to thread follow-ups.
+func (m *EmailMessage) FollowUp(result SendResult, plainTextContent, htmlContent string) *EmailMessage {
+	followUp := NewEmailMessage(m.To, replySubject(m.Subject), plainTextContent, htmlContent)
+	followUp.From = m.From
+	followUp.ReplyTo = m.ReplyTo
+
+	parentID := m.MessageID
+	if parentID == "" && messageIDPattern.MatchString(normalizeMessageID(result.MessageID)) {
+		parentID = result.MessageID
+	}
+	if parentID != "" {
+		followUp.WithInReplyTo(parentID, m.References...)
+	}
+	return followUp
+}
+
+// Reply returns a reply to the email, sent to its Reply-To address or else its From address,
+// and threaded with In-Reply-To and References.
+func (e *InboundEmail) Reply(plainTextContent, htmlContent string) *EmailMessage {
+	to := e.From
+	if e.ReplyTo != nil && e.ReplyTo.Address != "" {
+		to = *e.ReplyTo
+	}
+
+	reply := NewEmailMessage(to.Address, replySubject(e.Subject), plainTextContent, htmlContent)
+	if e.MessageID != "" {
+		reply.WithInReplyTo(e.MessageID, e.References...)
+	}
+	return reply
+}
+
+// replySubject returns the subject prefixed with "Re: " unless it already is.
+func replySubject(subject string) string {
+	if strings.HasPrefix(strings.ToLower(subject), "re:") {
+		return subject
+	}
+	return "Re: " + subject
+}
+
+// withThreadingHeaders returns the headers with the Message-ID, In-Reply-To and References headers
+// set from the threading fields of the message, overriding the same custom headers.
+func (m *EmailMessage) withThreadingHeaders(headers map[string]string) map[string]string {
+	fields := map[string]string{
+		"Message-ID":  normalizeMessageID(m.MessageID),
+		"In-Reply-To": normalizeMessageID(m.InReplyTo),
+	}
+	references := make([]string, 0, len(m.References))
+	for _, id := range m.References {
+		if id = normalizeMessageID(id); id != "" {
+			references = append(references, id)
+		}
+	}
+	fields["References"] = strings.Join(references, " ")
+
+	merged := make(map[string]string, len(headers)+len(fields))
+	for k, v := range headers {
+		merged[k] = v
+	}
+	for name, value := range fields {
+		if value == "" {
+			continue
+		}
+		for k := range merged {
+			if textproto.CanonicalMIMEHeaderKey(k) == textproto.CanonicalMIMEHeaderKey(name) {
+				delete(merged, k)
+			}
+		}
+		merged[name] = value
+	}
+	return merged
+}
+
+// validateThreading checks the Message-IDs of the threading fields.
+func (m *EmailMessage) validateThreading() []error {
+	var errs []error
+	ids := append([]string{m.MessageID, m.InReplyTo}, m.References...)
+	for _, id := range ids {
+		if id != "" && !messageIDPattern.MatchString(normalizeMessageID(id)) {
+			errs = append(errs, fmt.Errorf("invalid message ID %q", id))
+		}
+	}
+	return errs
+}
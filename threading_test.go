@@ -0,0 +1,184 @@
+package goat
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNewMessageID tests the NewMessageID function
+func TestNewMessageID(t *testing.T) {
+	withTimeNow(t, time.Unix(1700000000, 0))
+
+	t.Run("Success", func(t *testing.T) {
+		id, err := NewMessageID("example.com")
+		assert.NoError(t, err)
+		assert.Regexp(t, regexp.MustCompile(`^<1700000000000000000\.[0-9a-f]{32}@example\.com>$`), id)
+
+		other, err := NewMessageID("example.com")
+		assert.NoError(t, err)
+		assert.NotEqual(t, id, other)
+	})
+
+	t.Run("Success - default domain", func(t *testing.T) {
+		id, err := NewMessageID("")
+		assert.NoError(t, err)
+		assert.Regexp(t, `@localhost>$`, id)
+	})
+}
+
+// TestEmailMessage_WithMessageID tests the WithMessageID method of EmailMessage
+func TestEmailMessage_WithMessageID(t *testing.T) {
+	tests := []struct {
+		name     string
+		id       string
+		expected string
+	}{
+		{"with chevrons", "<id@example.com>", "<id@example.com>"},
+		{"without chevrons", " id@example.com ", "<id@example.com>"},
+		{"empty", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run("Success - "+tt.name, func(t *testing.T) {
+			msg := NewEmailMessage("test@example.com", "Subject", "Text", "").WithMessageID(tt.id)
+			assert.Equal(t, tt.expected, msg.MessageID)
+		})
+	}
+}
+
+// TestEmailMessage_WithInReplyTo tests the WithInReplyTo method of EmailMessage
+func TestEmailMessage_WithInReplyTo(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		parentReferences := []string{"welcome-1@example.com", "<order-41@example.com>"}
+		msg := NewEmailMessage("test@example.com", "Subject", "Text", "").
+			WithInReplyTo("order-42@example.com", parentReferences...)
+		assert.Equal(t, "<order-42@example.com>", msg.InReplyTo)
+		assert.Equal(t, []string{"<welcome-1@example.com>", "<order-41@example.com>", "<order-42@example.com>"}, msg.References)
+		assert.Equal(t, []string{"welcome-1@example.com", "<order-41@example.com>"}, parentReferences)
+	})
+
+	t.Run("Success - duplicated references", func(t *testing.T) {
+		msg := NewEmailMessage("test@example.com", "Subject", "Text", "").
+			WithInReplyTo("<order-42@example.com>", "<order-42@example.com>", "", "<welcome-1@example.com>")
+		assert.Equal(t, []string{"<order-42@example.com>", "<welcome-1@example.com>"}, msg.References)
+	})
+}
+
+// TestEmailMessage_FollowUp tests the FollowUp method of EmailMessage
+func TestEmailMessage_FollowUp(t *testing.T) {
+	t.Run("Success - message ID set before sending", func(t *testing.T) {
+		msg := NewEmailMessage("test@example.com", "Your order", "Text", "").
+			WithFrom("Shop", "shop@example.com").
+			WithReplyTo("Support", "support@example.com").
+			WithMessageID("<order-42@example.com>").
+			WithInReplyTo("<welcome-1@example.com>")
+
+		followUp := msg.FollowUp(SendResult{MessageID: "sendgrid-id"}, "Shipped", "<p>Shipped</p>")
+		assert.Equal(t, "test@example.com", followUp.To)
+		assert.Equal(t, "Re: Your order", followUp.Subject)
+		assert.Equal(t, "Shipped", followUp.PlainTextContent)
+		assert.Equal(t, "<p>Shipped</p>", followUp.HTMLContent)
+		assert.Equal(t, msg.From, followUp.From)
+		assert.Equal(t, msg.ReplyTo, followUp.ReplyTo)
+		assert.Empty(t, followUp.MessageID)
+		assert.Equal(t, "<order-42@example.com>", followUp.InReplyTo)
+		assert.Equal(t, []string{"<welcome-1@example.com>", "<order-42@example.com>"}, followUp.References)
+	})
+
+	t.Run("Success - message ID from the result", func(t *testing.T) {
+		msg := NewEmailMessage("test@example.com", "Your order", "Text", "")
+
+		followUp := msg.FollowUp(SendResult{MessageID: "<202603141509.1@smtp-relay.brevo.com>"}, "Shipped", "")
+		assert.Equal(t, "<202603141509.1@smtp-relay.brevo.com>", followUp.InReplyTo)
+		assert.Equal(t, []string{"<202603141509.1@smtp-relay.brevo.com>"}, followUp.References)
+	})
+
+	t.Run("Success - provider identifier ignored", func(t *testing.T) {
+		msg := NewEmailMessage("test@example.com", "Re: Your order", "Text", "")
+
+		followUp := msg.FollowUp(SendResult{MessageID: "14c5d75ce93.dfd.64b469"}, "Shipped", "")
+		assert.Equal(t, "Re: Your order", followUp.Subject)
+		assert.Empty(t, followUp.InReplyTo)
+		assert.Nil(t, followUp.References)
+	})
+}
+
+// TestInboundEmail_Reply tests the Reply method of InboundEmail
+func TestInboundEmail_Reply(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		email := &InboundEmail{
+			From:       Address{Name: "Renee", Address: "renee@example.com"},
+			ReplyTo:    &Address{Address: "renee.perso@example.com"},
+			Subject:    "Your order",
+			MessageID:  "<reply-1@mail.example.com>",
+			References: []string{"<order-42@example.com>"},
+		}
+
+		reply := email.Reply("On its way", "<p>On its way</p>")
+		assert.Equal(t, "renee.perso@example.com", reply.To)
+		assert.Equal(t, "Re: Your order", reply.Subject)
+		assert.Equal(t, "On its way", reply.PlainTextContent)
+		assert.Equal(t, "<reply-1@mail.example.com>", reply.InReplyTo)
+		assert.Equal(t, []string{"<order-42@example.com>", "<reply-1@mail.example.com>"}, reply.References)
+	})
+
+	t.Run("Success - without Message-ID", func(t *testing.T) {
+		email := &InboundEmail{From: Address{Address: "renee@example.com"}, Subject: "RE: Your order"}
+
+		reply := email.Reply("On its way", "")
+		assert.Equal(t, "renee@example.com", reply.To)
+		assert.Equal(t, "RE: Your order", reply.Subject)
+		assert.Empty(t, reply.InReplyTo)
+		assert.Nil(t, reply.References)
+	})
+}
+
+// TestEmailMessage_withThreadingHeaders tests the withThreadingHeaders method of EmailMessage
+func TestEmailMessage_withThreadingHeaders(t *testing.T) {
+	t.Run("Success - fields override headers", func(t *testing.T) {
+		headers := map[string]string{"message-id": "<custom@example.com>", "X-Campaign": "spring"}
+		msg := NewEmailMessage("test@example.com", "Subject", "Text", "").
+			WithMessageID("reply-1@example.com").
+			WithInReplyTo("order-42@example.com")
+
+		assert.Equal(t, map[string]string{
+			"Message-ID":  "<reply-1@example.com>",
+			"In-Reply-To": "<order-42@example.com>",
+			"References":  "<order-42@example.com>",
+			"X-Campaign":  "spring",
+		}, msg.withThreadingHeaders(headers))
+		assert.Equal(t, "<custom@example.com>", headers["message-id"])
+	})
+
+	t.Run("Success - headers kept without fields", func(t *testing.T) {
+		headers := map[string]string{"Message-Id": "<custom@example.com>"}
+		msg := NewEmailMessage("test@example.com", "Subject", "Text", "")
+
+		assert.Equal(t, headers, msg.withThreadingHeaders(headers))
+	})
+}
+
+// TestEmailMessage_validateThreading tests the validateThreading method of EmailMessage
+func TestEmailMessage_validateThreading(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		msg := NewEmailMessage("test@example.com", "Subject", "Text", "").
+			WithMessageID("reply-1@example.com").
+			WithInReplyTo("<order-42@example.com>")
+		assert.Empty(t, msg.validateThreading())
+	})
+
+	t.Run("Failure - invalid message IDs", func(t *testing.T) {
+		msg := NewEmailMessage("test@example.com", "Subject", "Text", "")
+		msg.MessageID = "no-domain"
+		msg.InReplyTo = "<a@b> <c@d>"
+		msg.References = []string{"<ok@example.com>", "bad id@example.com"}
+
+		errs := msg.validateThreading()
+		assert.Len(t, errs, 3)
+		assert.EqualError(t, errs[0], `invalid message ID "no-domain"`)
+		assert.EqualError(t, msg.Validate(), errs[0].Error()+"\n"+errs[1].Error()+"\n"+errs[2].Error())
+	})
+}
@@ -0,0 +1,49 @@
+package goat
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/url"
+	"strings"
+)
+
+// signedToken returns a token carrying the payload and its HMAC-SHA256 for the given purpose,
+// so that a token issued for one purpose is not valid for another.
+func signedToken(secret []byte, purpose, payload string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." +
+		base64.RawURLEncoding.EncodeToString(signPayload(secret, purpose, payload))
+}
+
+// verifySignedToken returns the payload of a token issued by signedToken, and whether its signature verifies.
+func verifySignedToken(secret []byte, purpose, token string) (string, bool) {
+	encodedPayload, encodedSignature, found := strings.Cut(token, ".")
+	if !found {
+		return "", false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return "", false
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil || !hmac.Equal(signature, signPayload(secret, purpose, string(payload))) {
+		return "", false
+	}
+	return string(payload), true
+}
+
+// signPayload returns the truncated HMAC-SHA256 of the payload for the given purpose.
+func signPayload(secret []byte, purpose, payload string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(purpose + "\n" + payload))
+	return mac.Sum(nil)[:16]
+}
+
+// tokenURL returns the URL with the token query parameter added.
+func tokenURL(base *url.URL, token string) string {
+	u := *base
+	query := u.Query()
+	query.Set("token", token)
+	u.RawQuery = query.Encode()
+	return u.String()
+}
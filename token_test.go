@@ -0,0 +1,49 @@
+package goat
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSignedToken tests the signedToken and verifySignedToken functions
+func TestSignedToken(t *testing.T) {
+	secret := []byte("0123456789abcdef")
+	token := signedToken(secret, "unsubscribe", "user@example.com")
+
+	t.Run("Success", func(t *testing.T) {
+		payload, ok := verifySignedToken(secret, "unsubscribe", token)
+		assert.True(t, ok)
+		assert.Equal(t, "user@example.com", payload)
+	})
+
+	tests := []struct {
+		name    string
+		secret  []byte
+		purpose string
+		token   string
+	}{
+		{"other purpose", secret, "preferences", token},
+		{"other secret", []byte("fedcba9876543210"), "unsubscribe", token},
+		{"other payload", secret, "unsubscribe", signedToken(secret, "unsubscribe", "other@example.com")[:10] + token[10:]},
+		{"no signature", secret, "unsubscribe", "dXNlckBleGFtcGxlLmNvbQ"},
+		{"malformed", secret, "unsubscribe", "not base64!.sig"},
+	}
+
+	for _, tt := range tests {
+		t.Run("Failure - "+tt.name, func(t *testing.T) {
+			_, ok := verifySignedToken(tt.secret, tt.purpose, tt.token)
+			assert.False(t, ok)
+		})
+	}
+}
+
+// TestTokenURL tests the tokenURL function
+func TestTokenURL(t *testing.T) {
+	base, err := url.Parse("https://example.com/unsubscribe?list=news")
+	assert.NoError(t, err)
+
+	assert.Equal(t, "https://example.com/unsubscribe?list=news&token=a.b", tokenURL(base, "a.b"))
+	assert.Equal(t, "https://example.com/unsubscribe?list=news", base.String())
+}
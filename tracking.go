@@ -0,0 +1,257 @@
+package goat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	nethtml "golang.org/x/net/html"
+)
+
+// NoTrackAttribute excludes a link from click tracking when set on its <a> element; it is removed from the output.
+const NoTrackAttribute = "data-notrack"
+
+// trackingPixel is a transparent 1x1 GIF.
+var trackingPixel = []byte("GIF89a\x01\x00\x01\x00\x80\x00\x00\x00\x00\x00\x00\x00\x00!\xf9\x04\x01\x00\x00\x00\x00,\x00\x00\x00\x00\x01\x00\x01\x00\x00\x02\x02D\x01\x00;")
+
+// closingBody matches the closing body tag of an HTML document, before which the tracking pixel is injected.
+var closingBody = regexp.MustCompile(`(?i)</body\s*>`)
+
+// TrackingOptions configures an EngagementTracker.
+type TrackingOptions struct {
+	URL          string           // https base URL of the handlers, serving opens at URL+"/open" and clicks at URL+"/click"
+	Secret       []byte           // HMAC key signing the tracking URLs, at least 16 bytes
+	ExcludeLinks []*regexp.Regexp // Links matching any pattern are not tracked, e.g. unsubscribe links
+}
+
+// EngagementTracker records the opens and clicks of messages, whatever the provider sending them.
+//
+// Track rewrites the links of the HTML body of a message to signed redirect URLs and injects a tracking pixel;
+// OpenHandler and ClickHandler serve them and pass the opens and clicks to a callback as DeliveryEvents
+// with the Message-ID of the message, the recipient, and the clicked link.
+type EngagementTracker struct {
+	onEvent func(ctx context.Context, event DeliveryEvent) error
+	url     *url.URL
+	secret  []byte
+	exclude []*regexp.Regexp
+}
+
+// NewEngagementTracker returns a tracker calling onEvent with the opens and clicks, e.g. DeliveryTracker.Record.
+func NewEngagementTracker(onEvent func(ctx context.Context, event DeliveryEvent) error, opts TrackingOptions) (*EngagementTracker, error) {
+	u, err := url.Parse(strings.TrimSuffix(opts.URL, "/"))
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return nil, fmt.Errorf("invalid tracking URL %q: https URL required", opts.URL)
+	}
+	if len(opts.Secret) < 16 {
+		return nil, errors.New("tracking secret must be at least 16 bytes")
+	}
+	return &EngagementTracker{onEvent: onEvent, url: u, secret: opts.Secret, exclude: opts.ExcludeLinks}, nil
+}
+
+// Track rewrites the http and https links of the HTML body of the message to click tracking URLs and injects
+// an open tracking pixel, before the closing body tag if any. Messages without HTML body or with NoTracking set
+// are left unchanged. A Message-ID is generated when the message has none, in the domain of its from address
+// or else of the tracking URL.
+//
+// Links are not tracked when they match one of the ExcludeLinks patterns or carry the NoTrackAttribute.
+func (t *EngagementTracker) Track(m *EmailMessage) error {
+	if m.NoTracking || m.HTMLContent == "" {
+		return nil
+	}
+	if m.MessageID == "" {
+		domain := t.url.Hostname()
+		if m.From != nil {
+			if at := strings.LastIndex(m.From.Address, "@"); at >= 0 {
+				domain = m.From.Address[at+1:]
+			}
+		}
+		id, err := NewMessageID(domain)
+		if err != nil {
+			return err
+		}
+		m.MessageID = id
+	}
+	messageID, recipient := normalizeMessageID(m.MessageID), normalizeEmail(m.To)
+
+	var b strings.Builder
+	z := nethtml.NewTokenizer(strings.NewReader(m.HTMLContent))
+	for {
+		tt := z.Next()
+		if tt == nethtml.ErrorToken {
+			break
+		}
+		raw := string(z.Raw())
+		if tt != nethtml.StartTagToken {
+			b.WriteString(raw)
+			continue
+		}
+		token := z.Token()
+		if token.Data != "a" {
+			b.WriteString(raw)
+			continue
+		}
+		b.WriteString(t.trackLink(token, messageID, recipient).String())
+	}
+
+	pixel := fmt.Sprintf(`<img src="%s" width="1" height="1" alt="" style="display:block;width:1px;height:1px;border:0">`,
+		html.EscapeString(t.endpoint("/open", "open", messageID, recipient, "")))
+	body := b.String()
+	if loc := closingBody.FindAllStringIndex(body, -1); len(loc) > 0 {
+		last := loc[len(loc)-1][0]
+		body = body[:last] + pixel + body[last:]
+	} else {
+		body += pixel
+	}
+	m.HTMLContent = body
+	return nil
+}
+
+// trackLink returns the <a> start tag with its href rewritten to a click tracking URL, unless excluded.
+func (t *EngagementTracker) trackLink(token nethtml.Token, messageID, recipient string) nethtml.Token {
+	attrs := make([]nethtml.Attribute, 0, len(token.Attr))
+	href, noTrack := -1, false
+	for _, a := range token.Attr {
+		switch {
+		case a.Key == NoTrackAttribute:
+			noTrack = true
+			continue
+		case a.Key == "href" && href < 0:
+			href = len(attrs)
+		}
+		attrs = append(attrs, a)
+	}
+	token.Attr = attrs
+	if noTrack || href < 0 {
+		return token
+	}
+
+	link := strings.TrimSpace(attrs[href].Val)
+	u, err := url.Parse(link)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return token
+	}
+	for _, pattern := range t.exclude {
+		if pattern.MatchString(link) {
+			return token
+		}
+	}
+	attrs[href].Val = t.endpoint("/click", "click", messageID, recipient, link)
+	return token
+}
+
+// endpoint returns the URL of a handler with the token signing the message ID, the recipient and the link.
+func (t *EngagementTracker) endpoint(path, purpose, messageID, recipient, link string) string {
+	u := *t.url
+	u.Path += path
+	return tokenURL(&u, signedToken(t.secret, purpose, messageID+"\n"+recipient+"\n"+link))
+}
+
+// verify returns the event of a tracking token, or false when its signature does not verify.
+func (t *EngagementTracker) verify(r *http.Request, purpose string, status DeliveryStatus) (DeliveryEvent, bool) {
+	payload, ok := verifySignedToken(t.secret, purpose, r.URL.Query().Get("token"))
+	if !ok {
+		return DeliveryEvent{}, false
+	}
+	fields := strings.SplitN(payload, "\n", 3)
+	if len(fields) != 3 {
+		return DeliveryEvent{}, false
+	}
+	return DeliveryEvent{
+		MessageID: fields[0],
+		Recipient: fields[1],
+		Status:    status,
+		URL:       fields[2],
+		Provider:  ProviderTracking,
+		Time:      timeNow(),
+	}, true
+}
+
+// OpenHandler returns the handler of the tracking pixels, recording a DeliveryOpened event on each request.
+// Requests with an invalid token get a 403 status; errors of the callback do not change the response,
+// so it should report them itself.
+func (t *EngagementTracker) OpenHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		event, ok := t.verify(r, "open", DeliveryOpened)
+		if !ok {
+			http.Error(w, "invalid tracking token", http.StatusForbidden)
+			return
+		}
+
+		_ = t.onEvent(r.Context(), event)
+		w.Header().Set("Content-Type", "image/gif")
+		w.Header().Set("Cache-Control", "no-store")
+		_, _ = w.Write(trackingPixel)
+	})
+}
+
+// ClickHandler returns the handler of the tracked links, recording a DeliveryClicked event and redirecting
+// to the original link. Only signed links are redirected to, so the handler cannot be used as an open redirect:
+// requests with an invalid token get a 403 status. Errors of the callback do not prevent the redirect,
+// so it should report them itself.
+func (t *EngagementTracker) ClickHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		event, ok := t.verify(r, "click", DeliveryClicked)
+		if !ok || event.URL == "" {
+			http.Error(w, "invalid tracking token", http.StatusForbidden)
+			return
+		}
+
+		_ = t.onEvent(r.Context(), event)
+		w.Header().Set("Cache-Control", "no-store")
+		http.Redirect(w, r, event.URL, http.StatusFound)
+	})
+}
+
+// TrackingSender implements the SenderService interface by tracking the opens and clicks of the messages
+// sent through another service.
+type TrackingSender struct {
+	next    SenderService
+	tracker *EngagementTracker
+}
+
+// NewTrackingSender returns a service applying tracker.Track to a copy of the messages before sending them
+// through next. The opens and clicks of a message carry its MessageID, which is written back to the message
+// when generated. SMTP and Brevo also return it in SendResult.MessageID, but SendGrid returns its own
+// X-Message-Id, the message ID of its webhook events: record both to join the two timelines.
+func NewTrackingSender(next SenderService, tracker *EngagementTracker) SenderService {
+	s := TrackingSender{next: next, tracker: tracker}
+	var service SenderService = &s
+	return service
+}
+
+// Send sends an email with open and click tracking.
+func (s *TrackingSender) Send(message *EmailMessage) error {
+	_, err := s.SendWithResult(message)
+	return err
+}
+
+// SendWithResult sends an email with open and click tracking.
+func (s *TrackingSender) SendWithResult(message *EmailMessage) (SendResult, error) {
+	tracked := *message
+	if err := s.tracker.Track(&tracked); err != nil {
+		return SendResult{}, fmt.Errorf("tracking message: %w", err)
+	}
+	message.MessageID = tracked.MessageID
+	return s.next.SendWithResult(&tracked)
+}
+
+// WithoutTracking disables the open and click tracking of the message and returns the message for chaining.
+func (m *EmailMessage) WithoutTracking() *EmailMessage {
+	m.NoTracking = true
+	return m
+}
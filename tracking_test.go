@@ -0,0 +1,296 @@
+package goat
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	nethtml "golang.org/x/net/html"
+)
+
+// newTestEngagementTracker returns an EngagementTracker recording the events into events.
+func newTestEngagementTracker(t *testing.T, events *[]DeliveryEvent, exclude ...*regexp.Regexp) *EngagementTracker {
+	tracker, err := NewEngagementTracker(func(ctx context.Context, event DeliveryEvent) error {
+		*events = append(*events, event)
+		if event.Recipient == "fail@example.com" {
+			return errors.New("database unavailable")
+		}
+		return nil
+	}, TrackingOptions{URL: "https://t.example.com/", Secret: []byte("0123456789abcdef"), ExcludeLinks: exclude})
+	assert.NoError(t, err)
+	return tracker
+}
+
+// trackedLinks returns the href of the links and the src of the images of an HTML body.
+func trackedLinks(t *testing.T, body string) (links []string, images []string) {
+	z := nethtml.NewTokenizer(strings.NewReader(body))
+	for tt := z.Next(); tt != nethtml.ErrorToken; tt = z.Next() {
+		token := z.Token()
+		for _, a := range token.Attr {
+			switch {
+			case token.Data == "a" && a.Key == "href":
+				links = append(links, a.Val)
+			case token.Data == "img" && a.Key == "src":
+				images = append(images, a.Val)
+			}
+		}
+	}
+	return links, images
+}
+
+// TestNewEngagementTracker tests the NewEngagementTracker function
+func TestNewEngagementTracker(t *testing.T) {
+	onEvent := func(ctx context.Context, event DeliveryEvent) error { return nil }
+
+	t.Run("Success", func(t *testing.T) {
+		tracker, err := NewEngagementTracker(onEvent, TrackingOptions{URL: "https://t.example.com/", Secret: []byte("0123456789abcdef")})
+		assert.NoError(t, err)
+		assert.Equal(t, "https://t.example.com", tracker.url.String())
+	})
+
+	tests := []struct {
+		name     string
+		opts     TrackingOptions
+		expected string
+	}{
+		{"http URL", TrackingOptions{URL: "http://t.example.com", Secret: []byte("0123456789abcdef")}, `invalid tracking URL "http://t.example.com": https URL required`},
+		{"short secret", TrackingOptions{URL: "https://t.example.com", Secret: []byte("secret")}, "tracking secret must be at least 16 bytes"},
+	}
+
+	for _, tt := range tests {
+		t.Run("Failure - "+tt.name, func(t *testing.T) {
+			_, err := NewEngagementTracker(onEvent, tt.opts)
+			assert.EqualError(t, err, tt.expected)
+		})
+	}
+}
+
+// TestEngagementTracker_Track tests the Track method of EngagementTracker
+func TestEngagementTracker_Track(t *testing.T) {
+	var events []DeliveryEvent
+	tracker := newTestEngagementTracker(t, &events, regexp.MustCompile(`^https://example\.com/unsubscribe`))
+
+	t.Run("Success", func(t *testing.T) {
+		msg := NewEmailMessage("user@example.com", "Subject", "Text", `<html><body>`+
+			`<p><a href="https://example.com/offers?a=1&amp;b=2" class="button">Offers</a></p>`+
+			`<p><a href="mailto:support@example.com">Mail</a> <a href="#top">Top</a> <a name="anchor">Anchor</a></p>`+
+			`<p><a href="https://example.com/private" data-notrack>Private</a></p>`+
+			`<p><a href="https://example.com/unsubscribe?token=abc">Unsubscribe</a></p>`+
+			`</body></html>`).WithFrom("Shop", "shop@example.org")
+
+		assert.NoError(t, tracker.Track(msg))
+		assert.Regexp(t, `@example\.org>$`, msg.MessageID)
+		assert.Contains(t, msg.HTMLContent, `class="button">Offers</a>`)
+		assert.Contains(t, msg.HTMLContent, `<a href="https://example.com/private">Private</a>`)
+		assert.True(t, strings.HasSuffix(msg.HTMLContent, `border:0"></body></html>`))
+
+		links, images := trackedLinks(t, msg.HTMLContent)
+		assert.Equal(t, []string{"mailto:support@example.com", "#top", "https://example.com/private", "https://example.com/unsubscribe?token=abc"}, links[1:])
+		assert.True(t, strings.HasPrefix(links[0], "https://t.example.com/click?token="))
+		assert.Len(t, images, 1)
+		assert.True(t, strings.HasPrefix(images[0], "https://t.example.com/open?token="))
+
+		click, _ := url.Parse(links[0])
+		event, ok := tracker.verify(httptest.NewRequest(http.MethodGet, click.RequestURI(), nil), "click", DeliveryClicked)
+		assert.True(t, ok)
+		assert.Equal(t, msg.MessageID, event.MessageID)
+		assert.Equal(t, "user@example.com", event.Recipient)
+		assert.Equal(t, "https://example.com/offers?a=1&b=2", event.URL)
+	})
+
+	t.Run("Success - fragment without body tag", func(t *testing.T) {
+		msg := NewEmailMessage("user@example.com", "Subject", "Text", `<p>Hello</p>`).WithMessageID("id@example.com")
+
+		assert.NoError(t, tracker.Track(msg))
+		assert.Equal(t, "<id@example.com>", msg.MessageID)
+		assert.True(t, strings.HasPrefix(msg.HTMLContent, `<p>Hello</p><img src="https://t.example.com/open?token=`))
+	})
+
+	t.Run("Success - not tracked", func(t *testing.T) {
+		optedOut := NewEmailMessage("user@example.com", "Subject", "Text", `<a href="https://example.com">Link</a>`).WithoutTracking()
+		assert.NoError(t, tracker.Track(optedOut))
+		assert.Equal(t, `<a href="https://example.com">Link</a>`, optedOut.HTMLContent)
+		assert.Empty(t, optedOut.MessageID)
+
+		plain := NewEmailMessage("user@example.com", "Subject", "Text", "")
+		assert.NoError(t, tracker.Track(plain))
+		assert.Empty(t, plain.HTMLContent)
+		assert.Empty(t, plain.MessageID)
+	})
+}
+
+// TestEngagementTracker_OpenHandler tests the OpenHandler method of EngagementTracker
+func TestEngagementTracker_OpenHandler(t *testing.T) {
+	now := time.Date(2026, 3, 14, 15, 9, 26, 0, time.UTC)
+	withTimeNow(t, now)
+	var events []DeliveryEvent
+	tracker := newTestEngagementTracker(t, &events)
+	handler := tracker.OpenHandler()
+
+	pixelURL := func(to string) string {
+		msg := NewEmailMessage(to, "Subject", "Text", "<p>Hello</p>").WithMessageID("<id@example.com>")
+		assert.NoError(t, tracker.Track(msg))
+		_, images := trackedLinks(t, msg.HTMLContent)
+		u, _ := url.Parse(images[0])
+		return u.RequestURI()
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		events = nil
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, pixelURL("user@example.com"), nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "image/gif", rec.Header().Get("Content-Type"))
+		assert.Equal(t, "no-store", rec.Header().Get("Cache-Control"))
+		assert.Equal(t, trackingPixel, rec.Body.Bytes())
+		assert.Equal(t, []DeliveryEvent{{
+			MessageID: "<id@example.com>",
+			Recipient: "user@example.com",
+			Status:    DeliveryOpened,
+			Provider:  ProviderTracking,
+			Time:      now,
+		}}, events)
+	})
+
+	t.Run("Success - callback error", func(t *testing.T) {
+		events = nil
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, pixelURL("fail@example.com"), nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Len(t, events, 1)
+	})
+
+	tests := []struct {
+		name    string
+		request func() *http.Request
+		status  int
+	}{
+		{"method not allowed", func() *http.Request {
+			return httptest.NewRequest(http.MethodPost, pixelURL("user@example.com"), nil)
+		}, http.StatusMethodNotAllowed},
+		{"invalid token", func() *http.Request { return httptest.NewRequest(http.MethodGet, "/open?token=forged", nil) }, http.StatusForbidden},
+		{"click token", func() *http.Request {
+			return httptest.NewRequest(http.MethodGet, "/open?token="+signedToken(tracker.secret, "click", "<id@example.com>\nuser@example.com\nhttps://example.com"), nil)
+		}, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run("Failure - "+tt.name, func(t *testing.T) {
+			events = nil
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, tt.request())
+			assert.Equal(t, tt.status, rec.Code)
+			assert.Empty(t, events)
+		})
+	}
+}
+
+// TestEngagementTracker_ClickHandler tests the ClickHandler method of EngagementTracker
+func TestEngagementTracker_ClickHandler(t *testing.T) {
+	var events []DeliveryEvent
+	tracker := newTestEngagementTracker(t, &events)
+	handler := tracker.ClickHandler()
+
+	clickURL := func(to, link string) string {
+		msg := NewEmailMessage(to, "Subject", "Text", `<a href="`+link+`">Link</a>`).WithMessageID("<id@example.com>")
+		assert.NoError(t, tracker.Track(msg))
+		links, _ := trackedLinks(t, msg.HTMLContent)
+		u, _ := url.Parse(links[0])
+		return u.RequestURI()
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		events = nil
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, clickURL("user@example.com", "https://example.com/offers?a=1&amp;b=2"), nil))
+		assert.Equal(t, http.StatusFound, rec.Code)
+		assert.Equal(t, "https://example.com/offers?a=1&b=2", rec.Header().Get("Location"))
+		assert.Len(t, events, 1)
+		assert.Equal(t, DeliveryClicked, events[0].Status)
+		assert.Equal(t, "<id@example.com>", events[0].MessageID)
+		assert.Equal(t, "https://example.com/offers?a=1&b=2", events[0].URL)
+	})
+
+	t.Run("Success - callback error", func(t *testing.T) {
+		events = nil
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, clickURL("fail@example.com", "https://example.com"), nil))
+		assert.Equal(t, http.StatusFound, rec.Code)
+		assert.Equal(t, "https://example.com", rec.Header().Get("Location"))
+		assert.Len(t, events, 1)
+	})
+
+	tests := []struct {
+		name    string
+		request func() *http.Request
+		status  int
+	}{
+		{"method not allowed", func() *http.Request {
+			return httptest.NewRequest(http.MethodDelete, clickURL("user@example.com", "https://example.com"), nil)
+		}, http.StatusMethodNotAllowed},
+		{"forged link", func() *http.Request {
+			token := signedToken([]byte("fedcba9876543210"), "click", "<id@example.com>\nuser@example.com\nhttps://evil.example.com")
+			return httptest.NewRequest(http.MethodGet, "/click?token="+token, nil)
+		}, http.StatusForbidden},
+		{"open token", func() *http.Request {
+			return httptest.NewRequest(http.MethodGet, "/click?token="+signedToken(tracker.secret, "open", "<id@example.com>\nuser@example.com\n"), nil)
+		}, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run("Failure - "+tt.name, func(t *testing.T) {
+			events = nil
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, tt.request())
+			assert.Equal(t, tt.status, rec.Code)
+			assert.Empty(t, rec.Header().Get("Location"))
+			assert.Empty(t, events)
+		})
+	}
+}
+
+// TestTrackingSender tests the SendWithResult method of TrackingSender
+func TestTrackingSender(t *testing.T) {
+	var events []DeliveryEvent
+	tracker := newTestEngagementTracker(t, &events)
+
+	t.Run("Success", func(t *testing.T) {
+		mock := NewMockSenderService()
+		sender := NewTrackingSender(mock, tracker)
+
+		msg := NewEmailMessage("user@example.com", "Subject", "Text", `<a href="https://example.com">Link</a>`).
+			WithMessageID("<id@example.com>")
+		assert.NoError(t, sender.Send(msg))
+		assert.Equal(t, `<a href="https://example.com">Link</a>`, msg.HTMLContent)
+
+		calls := mock.GetSendCalls()
+		assert.Len(t, calls, 1)
+		assert.Equal(t, "<id@example.com>", calls[0].MessageID)
+		assert.Contains(t, calls[0].HTMLContent, `href="https://t.example.com/click?token=`)
+		assert.Contains(t, calls[0].HTMLContent, `src="https://t.example.com/open?token=`)
+	})
+
+	t.Run("Success - generated Message-ID written back", func(t *testing.T) {
+		mock := NewMockSenderService()
+		mock.SendWithResultFunc = func(message *EmailMessage) (SendResult, error) {
+			return SendResult{MessageID: "14c5d75ce93"}, nil
+		}
+		sender := NewTrackingSender(mock, tracker)
+
+		msg := NewEmailMessage("user@example.com", "Subject", "Text", `<a href="https://example.com">Link</a>`).
+			WithFrom("Shop", "shop@example.com")
+		result, err := sender.SendWithResult(msg)
+		assert.NoError(t, err)
+		assert.Equal(t, "14c5d75ce93", result.MessageID)
+		assert.True(t, strings.HasSuffix(msg.MessageID, "@example.com>"))
+		assert.Equal(t, msg.MessageID, mock.GetSendCalls()[0].MessageID)
+		assert.Equal(t, `<a href="https://example.com">Link</a>`, msg.HTMLContent)
+	})
+}
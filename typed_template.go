@@ -0,0 +1,64 @@
+package goat
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"text/template"
+)
+
+// TemplateCheckError is returned when a template references fields or functions that do not exist.
+type TemplateCheckError struct {
+	Diagnostics []Diagnostic
+}
+
+// Error implements the error interface.
+func (e *TemplateCheckError) Error() string {
+	lines := make([]string, len(e.Diagnostics))
+	for i, d := range e.Diagnostics {
+		lines[i] = d.String()
+	}
+	return "template check failed:\n" + strings.Join(lines, "\n")
+}
+
+// TypedTemplate is a template bound to the type of its data at construction.
+//
+// Unlike Template, whose Data is an interface{}, a TypedTemplate is parsed once and checked against T:
+// every field it references must exist on T, so a mismatch is reported by NewTypedTemplate at
+// startup (or in tests) rather than when an email is rendered in production.
+type TypedTemplate[T any] struct {
+	tmpl *template.Template
+}
+
+// NewTypedTemplate parses the Name, ContentRaw and Funcs of tmpl (its Data is ignored) and checks that
+// every field and function it references exists, returning a *TemplateCheckError otherwise.
+// Functions registered globally after this call are not available to the template.
+func NewTypedTemplate[T any](tmpl Template) (*TypedTemplate[T], error) {
+	funcs := templateFuncs(tmpl.Funcs)
+
+	diags := checkTemplate(tmpl.Name, tmpl.ContentRaw, funcs, reflect.TypeOf((*T)(nil)).Elem())
+	if len(diags) > 0 {
+		return nil, &TemplateCheckError{Diagnostics: diags}
+	}
+
+	parsed, err := template.New(tmpl.Name).Funcs(funcs).Parse(tmpl.ContentRaw)
+	if err != nil {
+		return nil, err
+	}
+	return &TypedTemplate[T]{tmpl: parsed}, nil
+}
+
+// MustTypedTemplate is like NewTypedTemplate but panics on error.
+// It simplifies the initialization of package level templates.
+func MustTypedTemplate[T any](tmpl Template) *TypedTemplate[T] {
+	t, err := NewTypedTemplate[T](tmpl)
+	if err != nil {
+		panic(fmt.Sprintf("goat: %v", err))
+	}
+	return t
+}
+
+// Render renders the template with the given data
+func (t *TypedTemplate[T]) Render(data T) (string, error) {
+	return execute(t.tmpl, data)
+}
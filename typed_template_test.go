@@ -0,0 +1,79 @@
+package goat
+
+import (
+	"errors"
+	"testing"
+	"text/template"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type typedWelcome struct {
+	Name  string
+	Items []string
+}
+
+// TestNewTypedTemplate tests the NewTypedTemplate function
+func TestNewTypedTemplate(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		tmpl, err := NewTypedTemplate[typedWelcome](Template{
+			Name:       "welcome",
+			ContentRaw: "Hello {{.Name | shout}}, {{len .Items}} {{len .Items | pluralize \"item\" \"items\"}}",
+			Funcs:      template.FuncMap{"shout": func(s string) string { return s + "!" }},
+		})
+		assert.NoError(t, err)
+		assert.NotNil(t, tmpl)
+	})
+
+	t.Run("Failure - unknown field", func(t *testing.T) {
+		tmpl, err := NewTypedTemplate[*typedWelcome](Template{Name: "welcome", ContentRaw: "Hello\n{{.Nmae}}"})
+		assert.Nil(t, tmpl)
+
+		var checkErr *TemplateCheckError
+		assert.True(t, errors.As(err, &checkErr))
+		assert.Equal(t, []Diagnostic{{File: "welcome", Line: 2, Severity: SeverityError, Rule: RuleUnknownField, Message: `field "Nmae" does not exist on type goat.typedWelcome`}}, checkErr.Diagnostics)
+		assert.Equal(t, "template check failed:\nwelcome:2: error: field \"Nmae\" does not exist on type goat.typedWelcome (unknown-field)", err.Error())
+	})
+
+	t.Run("Failure - undefined function", func(t *testing.T) {
+		_, err := NewTypedTemplate[typedWelcome](Template{Name: "welcome", ContentRaw: "{{.Name | shout}}"})
+		assert.Error(t, err)
+	})
+
+	t.Run("Failure - syntax error", func(t *testing.T) {
+		_, err := NewTypedTemplate[typedWelcome](Template{Name: "welcome", ContentRaw: "{{.Name"})
+		assert.Error(t, err)
+	})
+}
+
+// TestMustTypedTemplate tests the MustTypedTemplate function
+func TestMustTypedTemplate(t *testing.T) {
+	assert.NotPanics(t, func() {
+		MustTypedTemplate[typedWelcome](Template{Name: "welcome", ContentRaw: "{{.Name}}"})
+	})
+	assert.Panics(t, func() {
+		MustTypedTemplate[typedWelcome](Template{Name: "welcome", ContentRaw: "{{.Nmae}}"})
+	})
+}
+
+// TestTypedTemplate_Render tests the Render method of TypedTemplate
+func TestTypedTemplate_Render(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		tmpl := MustTypedTemplate[typedWelcome](Template{
+			Name:       "welcome",
+			ContentRaw: "Hello {{.Name}}{{range .Items}} [{{.}}]{{end}}",
+		})
+
+		result, err := tmpl.Render(typedWelcome{Name: "John", Items: []string{"a", "b"}})
+		assert.NoError(t, err)
+		assert.Equal(t, "Hello John [a] [b]", result)
+	})
+
+	t.Run("Failure - execution error", func(t *testing.T) {
+		tmpl := MustTypedTemplate[map[string]interface{}](Template{Name: "welcome", ContentRaw: "{{.Name}}"})
+
+		result, err := tmpl.Render(map[string]interface{}{})
+		assert.Error(t, err)
+		assert.Equal(t, "", result)
+	})
+}
@@ -0,0 +1,187 @@
+package goat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strings"
+)
+
+// SourceListUnsubscribe is the Suppression.Source of the addresses unsubscribed through an UnsubscribeHandler.
+const SourceListUnsubscribe = "list-unsubscribe"
+
+// ErrInvalidUnsubscribeToken is returned when an unsubscribe token is malformed or its signature does not verify.
+var ErrInvalidUnsubscribeToken = errors.New("invalid unsubscribe token")
+
+// unsubscribePage is the confirmation page served on GET requests, whose form sends the one-click POST request.
+var unsubscribePage = template.Must(template.New("unsubscribe").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><meta name="viewport" content="width=device-width, initial-scale=1"><title>Unsubscribe</title></head>
+<body>
+<form method="post">
+<p>Unsubscribe {{.}} from these emails?</p>
+<input type="hidden" name="List-Unsubscribe" value="One-Click">
+<button type="submit">Unsubscribe</button>
+</form>
+</body>
+</html>
+`))
+
+// WithListUnsubscribe sets the List-Unsubscribe header (RFC 2369) to the mailto address or URI and the https URL,
+// either of which may be empty, and returns the message for chaining. With an https URL, the List-Unsubscribe-Post
+// header is set too, so mail clients unsubscribe with one click (RFC 8058) as Gmail and Yahoo require of bulk senders.
+func (m *EmailMessage) WithListUnsubscribe(mailto, unsubscribeURL string) *EmailMessage {
+	var uris []string
+	if mailto != "" {
+		if !strings.HasPrefix(strings.ToLower(mailto), "mailto:") {
+			mailto = "mailto:" + mailto
+		}
+		uris = append(uris, "<"+mailto+">")
+	}
+	if unsubscribeURL != "" {
+		uris = append(uris, "<"+unsubscribeURL+">")
+	}
+	if len(uris) == 0 {
+		return m
+	}
+
+	m.WithHeader("List-Unsubscribe", strings.Join(uris, ", "))
+	if strings.HasPrefix(strings.ToLower(unsubscribeURL), "https://") {
+		m.WithHeader("List-Unsubscribe-Post", "List-Unsubscribe=One-Click")
+	}
+	return m
+}
+
+// validateListUnsubscribe checks that a List-Unsubscribe-Post header comes with an https List-Unsubscribe URI.
+func (m *EmailMessage) validateListUnsubscribe() []error {
+	var listUnsubscribe, listUnsubscribePost string
+	for k, v := range m.Headers {
+		switch textproto.CanonicalMIMEHeaderKey(k) {
+		case "List-Unsubscribe":
+			listUnsubscribe = v
+		case "List-Unsubscribe-Post":
+			listUnsubscribePost = v
+		}
+	}
+	if listUnsubscribePost == "" {
+		return nil
+	}
+	if listUnsubscribePost != "List-Unsubscribe=One-Click" {
+		return []error{fmt.Errorf("invalid List-Unsubscribe-Post header %q", listUnsubscribePost)}
+	}
+	if !strings.Contains(strings.ToLower(listUnsubscribe), "<https://") {
+		return []error{errors.New("List-Unsubscribe-Post header without https List-Unsubscribe URI")}
+	}
+	return nil
+}
+
+// UnsubscribeStore records the addresses unsubscribed from the emails. *SuppressionList implements it,
+// so that a SuppressingSender stops sending to unsubscribed recipients.
+type UnsubscribeStore interface {
+	Unsubscribe(ctx context.Context, email string) error
+}
+
+// Unsubscribe suppresses an address with the SuppressionUnsubscribe reason.
+func (l *SuppressionList) Unsubscribe(ctx context.Context, email string) error {
+	return l.Add(ctx, Suppression{Email: email, Reason: SuppressionUnsubscribe, Source: SourceListUnsubscribe})
+}
+
+// UnsubscribeOptions configures an Unsubscriber.
+type UnsubscribeOptions struct {
+	URL    string // https URL of the UnsubscribeHandler, to which the token query parameter is added
+	Secret []byte // HMAC key signing the tokens, at least 16 bytes
+	Mailto string // Optional address or mailto URI also listed in the List-Unsubscribe header
+}
+
+// Unsubscriber generates per-recipient unsubscribe URLs, carrying a token signing the recipient address,
+// and verifies their tokens.
+type Unsubscriber struct {
+	url    *url.URL
+	secret []byte
+	mailto string
+}
+
+// NewUnsubscriber returns an Unsubscriber for the given options.
+func NewUnsubscriber(opts UnsubscribeOptions) (*Unsubscriber, error) {
+	u, err := url.Parse(opts.URL)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return nil, fmt.Errorf("invalid unsubscribe URL %q: https URL required", opts.URL)
+	}
+	if len(opts.Secret) < 16 {
+		return nil, errors.New("unsubscribe secret must be at least 16 bytes")
+	}
+	return &Unsubscriber{url: u, secret: opts.Secret, mailto: opts.Mailto}, nil
+}
+
+// Token returns the unsubscribe token of an address.
+func (u *Unsubscriber) Token(email string) string {
+	return signedToken(u.secret, "unsubscribe", normalizeEmail(email))
+}
+
+// VerifyToken returns the address of an unsubscribe token, or ErrInvalidUnsubscribeToken.
+func (u *Unsubscriber) VerifyToken(token string) (string, error) {
+	email, ok := verifySignedToken(u.secret, "unsubscribe", token)
+	if !ok {
+		return "", ErrInvalidUnsubscribeToken
+	}
+	return email, nil
+}
+
+// URL returns the unsubscribe URL of an address.
+func (u *Unsubscriber) URL(email string) string {
+	return tokenURL(u.url, u.Token(email))
+}
+
+// WithUnsubscribe sets the List-Unsubscribe headers of the message to the unsubscribe URL of its recipient,
+// and the mailto address of the unsubscriber if any, and returns the message for chaining.
+func (m *EmailMessage) WithUnsubscribe(unsubscriber *Unsubscriber) *EmailMessage {
+	return m.WithListUnsubscribe(unsubscriber.mailto, unsubscriber.URL(m.To))
+}
+
+// UnsubscribeHandler is an http.Handler unsubscribing the recipients of unsubscribe URLs.
+type UnsubscribeHandler struct {
+	unsubscriber *Unsubscriber
+	store        UnsubscribeStore
+}
+
+// NewUnsubscribeHandler returns a handler serving the unsubscribe URLs of unsubscriber.
+//
+// POST requests, sent by mail clients on one click (RFC 8058) or by the confirmation form, record the address
+// of the token in store. GET requests, sent when the URL is opened in a browser, only serve the confirmation form,
+// so that link scanners prefetching the URL do not unsubscribe recipients. When the token does not verify,
+// the handler responds with a 403 status, and with a 500 status when store returns an error.
+func NewUnsubscribeHandler(unsubscriber *Unsubscriber, store UnsubscribeStore) *UnsubscribeHandler {
+	return &UnsubscribeHandler{unsubscriber: unsubscriber, store: store}
+}
+
+// ServeHTTP implements the http.Handler interface.
+func (h *UnsubscribeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	email, err := h.unsubscriber.VerifyToken(r.URL.Query().Get("token"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_ = unsubscribePage.Execute(w, email)
+		return
+	}
+
+	if err := h.store.Unsubscribe(r.Context(), email); err != nil {
+		http.Error(w, "unsubscribe not recorded", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = fmt.Fprintln(w, "You have been unsubscribed.")
+}
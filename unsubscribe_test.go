@@ -0,0 +1,248 @@
+package goat
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// unsubscribeSecret is the secret of the test Unsubscribers.
+var unsubscribeSecret = []byte("0123456789abcdef")
+
+// MockUnsubscribeStore is a mock implementation of the UnsubscribeStore interface.
+type MockUnsubscribeStore struct {
+	emails []string
+	err    error
+}
+
+func (m *MockUnsubscribeStore) Unsubscribe(ctx context.Context, email string) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.emails = append(m.emails, email)
+	return nil
+}
+
+// TestEmailMessage_WithListUnsubscribe tests the WithListUnsubscribe method of EmailMessage
+func TestEmailMessage_WithListUnsubscribe(t *testing.T) {
+	tests := []struct {
+		name     string
+		mailto   string
+		url      string
+		expected map[string]string
+	}{
+		{"mailto and https", "unsubscribe@example.com", "https://example.com/unsubscribe?token=abc", map[string]string{
+			"List-Unsubscribe":      "<mailto:unsubscribe@example.com>, <https://example.com/unsubscribe?token=abc>",
+			"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+		}},
+		{"mailto URI only", "mailto:unsubscribe@example.com?subject=unsubscribe", "", map[string]string{
+			"List-Unsubscribe": "<mailto:unsubscribe@example.com?subject=unsubscribe>",
+		}},
+		{"http URL without one-click", "", "http://example.com/unsubscribe", map[string]string{
+			"List-Unsubscribe": "<http://example.com/unsubscribe>",
+		}},
+		{"nothing", "", "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run("Success - "+tt.name, func(t *testing.T) {
+			msg := NewEmailMessage("test@example.com", "Subject", "Text", "").WithListUnsubscribe(tt.mailto, tt.url)
+			assert.Equal(t, tt.expected, msg.Headers)
+			assert.NoError(t, msg.Validate())
+		})
+	}
+}
+
+// TestEmailMessage_validateListUnsubscribe tests the validateListUnsubscribe method of EmailMessage
+func TestEmailMessage_validateListUnsubscribe(t *testing.T) {
+	tests := []struct {
+		name     string
+		headers  map[string]string
+		expected string
+	}{
+		{"invalid value", map[string]string{
+			"List-Unsubscribe":      "<https://example.com/unsubscribe>",
+			"list-unsubscribe-post": "One-Click",
+		}, `invalid List-Unsubscribe-Post header "One-Click"`},
+		{"without https URI", map[string]string{
+			"List-Unsubscribe":      "<mailto:unsubscribe@example.com>",
+			"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+		}, "List-Unsubscribe-Post header without https List-Unsubscribe URI"},
+	}
+
+	for _, tt := range tests {
+		t.Run("Failure - "+tt.name, func(t *testing.T) {
+			msg := NewEmailMessage("test@example.com", "Subject", "Text", "").WithHeaders(tt.headers)
+			errs := msg.validateListUnsubscribe()
+			assert.Len(t, errs, 1)
+			assert.EqualError(t, errs[0], tt.expected)
+			assert.EqualError(t, msg.Validate(), tt.expected)
+		})
+	}
+}
+
+// TestNewUnsubscriber tests the NewUnsubscriber function
+func TestNewUnsubscriber(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		unsubscriber, err := NewUnsubscriber(UnsubscribeOptions{URL: "https://example.com/unsubscribe", Secret: unsubscribeSecret})
+		assert.NoError(t, err)
+		assert.NotNil(t, unsubscriber)
+	})
+
+	tests := []struct {
+		name     string
+		opts     UnsubscribeOptions
+		expected string
+	}{
+		{"http URL", UnsubscribeOptions{URL: "http://example.com/unsubscribe", Secret: unsubscribeSecret}, `invalid unsubscribe URL "http://example.com/unsubscribe": https URL required`},
+		{"relative URL", UnsubscribeOptions{URL: "/unsubscribe", Secret: unsubscribeSecret}, `invalid unsubscribe URL "/unsubscribe": https URL required`},
+		{"short secret", UnsubscribeOptions{URL: "https://example.com/unsubscribe", Secret: []byte("secret")}, "unsubscribe secret must be at least 16 bytes"},
+	}
+
+	for _, tt := range tests {
+		t.Run("Failure - "+tt.name, func(t *testing.T) {
+			_, err := NewUnsubscriber(tt.opts)
+			assert.EqualError(t, err, tt.expected)
+		})
+	}
+}
+
+// TestUnsubscriber_VerifyToken tests the VerifyToken method of Unsubscriber
+func TestUnsubscriber_VerifyToken(t *testing.T) {
+	unsubscriber, _ := NewUnsubscriber(UnsubscribeOptions{URL: "https://example.com/unsubscribe", Secret: unsubscribeSecret})
+	other, _ := NewUnsubscriber(UnsubscribeOptions{URL: "https://example.com/unsubscribe", Secret: []byte("fedcba9876543210")})
+	token := unsubscriber.Token(" User@Example.com")
+
+	t.Run("Success", func(t *testing.T) {
+		email, err := unsubscriber.VerifyToken(token)
+		assert.NoError(t, err)
+		assert.Equal(t, "user@example.com", email)
+	})
+
+	_, signature, _ := strings.Cut(token, ".")
+	otherEmail, _, _ := strings.Cut(unsubscriber.Token("other@example.com"), ".")
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"empty", ""},
+		{"no signature", strings.Split(token, ".")[0]},
+		{"invalid encoding", "!!!." + signature},
+		{"other address", otherEmail + "." + signature},
+		{"other secret", other.Token("user@example.com")},
+	}
+
+	for _, tt := range tests {
+		t.Run("Failure - "+tt.name, func(t *testing.T) {
+			_, err := unsubscriber.VerifyToken(tt.token)
+			assert.ErrorIs(t, err, ErrInvalidUnsubscribeToken)
+		})
+	}
+}
+
+// TestEmailMessage_WithUnsubscribe tests the WithUnsubscribe method of EmailMessage
+func TestEmailMessage_WithUnsubscribe(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		unsubscriber, _ := NewUnsubscriber(UnsubscribeOptions{
+			URL:    "https://example.com/unsubscribe?list=news",
+			Secret: unsubscribeSecret,
+			Mailto: "unsubscribe@example.com",
+		})
+
+		msg := NewEmailMessage("user@example.com", "Subject", "Text", "").WithUnsubscribe(unsubscriber)
+		assert.Equal(t, "<mailto:unsubscribe@example.com>, <"+unsubscriber.URL("user@example.com")+">", msg.Headers["List-Unsubscribe"])
+		assert.Equal(t, "List-Unsubscribe=One-Click", msg.Headers["List-Unsubscribe-Post"])
+		assert.NoError(t, msg.Validate())
+
+		u, err := url.Parse(unsubscriber.URL("user@example.com"))
+		assert.NoError(t, err)
+		assert.Equal(t, "news", u.Query().Get("list"))
+		assert.Equal(t, unsubscriber.Token("user@example.com"), u.Query().Get("token"))
+	})
+}
+
+// TestSuppressionList_Unsubscribe tests the Unsubscribe method of SuppressionList
+func TestSuppressionList_Unsubscribe(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		list := NewSuppressionList(nil, SuppressionOptions{})
+		assert.NoError(t, list.Unsubscribe(context.Background(), "User@Example.com"))
+
+		suppression, err := list.Check(context.Background(), "user@example.com")
+		assert.NoError(t, err)
+		assert.Equal(t, SuppressionUnsubscribe, suppression.Reason)
+		assert.Equal(t, SourceListUnsubscribe, suppression.Source)
+		assert.True(t, suppression.ExpiresAt.IsZero())
+	})
+}
+
+// TestUnsubscribeHandler tests the ServeHTTP method of UnsubscribeHandler
+func TestUnsubscribeHandler(t *testing.T) {
+	unsubscriber, _ := NewUnsubscriber(UnsubscribeOptions{URL: "https://example.com/unsubscribe", Secret: unsubscribeSecret})
+	target := "/unsubscribe?token=" + unsubscriber.Token("user@example.com")
+
+	newOneClickRequest := func(target string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, target, strings.NewReader("List-Unsubscribe=One-Click"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return req
+	}
+
+	t.Run("Success - one-click unsubscribe feeds the suppression list", func(t *testing.T) {
+		list := NewSuppressionList(nil, SuppressionOptions{})
+		handler := NewUnsubscribeHandler(unsubscriber, list)
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, newOneClickRequest(target))
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		mock := NewMockSenderService()
+		sender := NewSuppressingSender(mock, list, SuppressionReject)
+		err := sender.Send(NewEmailMessage("user@example.com", "Subject", "Text", ""))
+		assert.ErrorIs(t, err, ErrRecipientSuppressed)
+		assert.Empty(t, mock.GetSendCalls())
+	})
+
+	t.Run("Success - confirmation page", func(t *testing.T) {
+		store := &MockUnsubscribeStore{}
+		handler := NewUnsubscribeHandler(unsubscriber, store)
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "text/html; charset=utf-8", rec.Header().Get("Content-Type"))
+		assert.Contains(t, rec.Body.String(), `<form method="post">`)
+		assert.Contains(t, rec.Body.String(), "user@example.com")
+		assert.Empty(t, store.emails)
+	})
+
+	tests := []struct {
+		name    string
+		request func() *http.Request
+		err     error
+		status  int
+	}{
+		{"method not allowed", func() *http.Request {
+			return httptest.NewRequest(http.MethodDelete, target, nil)
+		}, nil, http.StatusMethodNotAllowed},
+		{"missing token", func() *http.Request { return newOneClickRequest("/unsubscribe") }, nil, http.StatusForbidden},
+		{"invalid token", func() *http.Request { return newOneClickRequest(target + "x") }, nil, http.StatusForbidden},
+		{"store error", func() *http.Request { return newOneClickRequest(target) }, errors.New("database unavailable"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run("Failure - "+tt.name, func(t *testing.T) {
+			store := &MockUnsubscribeStore{err: tt.err}
+			handler := NewUnsubscribeHandler(unsubscriber, store)
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, tt.request())
+			assert.Equal(t, tt.status, rec.Code)
+			assert.Empty(t, store.emails)
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package goat
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// reservedHeaders lists the headers set from EmailMessage fields or by the MIME structure,
+// which cannot be overridden through EmailMessage.Headers.
+var reservedHeaders = map[string]bool{
+	"From":                      true,
+	"To":                        true,
+	"Cc":                        true,
+	"Bcc":                       true,
+	"Subject":                   true,
+	"Reply-To":                  true,
+	"Content-Type":              true,
+	"Content-Transfer-Encoding": true,
+	"Mime-Version":              true,
+}
+
+// cidReference matches the cid: references of an HTML body.
+var cidReference = regexp.MustCompile(`(?i)cid:([^"'\s)>]+)`)
+
+// Validate runs pre-flight checks on the message and returns every problem found as a joined error,
+// or nil when the message is valid. The built-in providers validate messages before calling their API.
+//
+// It checks that:
+//   - the recipient, from and reply-to addresses are valid (see IsEmailValid);
+//   - the subject is not empty and has no CR, LF or control character, reported as a *HeaderError;
+//   - the message has at least one body;
+//   - headers are allowed by DefaultHeaderPolicy: valid names, no CR, LF or control character in values,
+//     and no override of a reserved header (e.g. From, Content-Type), each rejection being a *HeaderError;
+//   - tags are not blank, at most 255 bytes long and without control character;
+//   - the MessageID, InReplyTo and References are Message-IDs (e.g. "<id@example.com>", chevrons optional);
+//   - a List-Unsubscribe-Post header comes with an https List-Unsubscribe URI (RFC 8058);
+//   - attachments have a plain file name and, when set, a valid content type;
+//   - inline attachments have unique Content-IDs, each referenced from the HTML body as cid:<ContentID>,
+//     and every cid: reference of the HTML body matches an inline attachment.
+func (m *EmailMessage) Validate() error {
+	var errs []error
+
+	if !IsEmailValid(m.To) {
+		errs = append(errs, fmt.Errorf("invalid recipient address %q", m.To))
+	}
+	if m.From != nil && !IsEmailValid(m.From.Address) {
+		errs = append(errs, fmt.Errorf("invalid from address %q", m.From.Address))
+	}
+	if m.ReplyTo != nil && m.ReplyTo.Address != "" && !IsEmailValid(m.ReplyTo.Address) {
+		errs = append(errs, fmt.Errorf("invalid reply-to address %q", m.ReplyTo.Address))
+	}
+	if strings.TrimSpace(m.Subject) == "" {
+		errs = append(errs, errors.New("empty subject"))
+	} else if err := checkHeaderValue("Subject", m.Subject); err != nil {
+		errs = append(errs, err)
+	}
+	if m.PlainTextContent == "" && m.HTMLContent == "" {
+		errs = append(errs, errors.New("no plain text nor HTML body"))
+	}
+
+	if _, err := DefaultHeaderPolicy.Sanitize(m.Headers); err != nil {
+		errs = append(errs, err)
+	}
+
+	for _, tag := range m.Tags {
+		if strings.TrimSpace(tag) == "" || len(tag) > 255 || strings.ContainsFunc(tag, unicode.IsControl) {
+			errs = append(errs, fmt.Errorf("invalid tag %q", tag))
+		}
+	}
+
+	errs = append(errs, m.validateThreading()...)
+	errs = append(errs, m.validateListUnsubscribe()...)
+	errs = append(errs, m.validateAttachments()...)
+
+	return errors.Join(errs...)
+}
+
+// isHeaderName reports whether key is a valid header field name: printable ASCII characters except colon.
+func isHeaderName(key string) bool {
+	if key == "" {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if c := key[i]; c < 33 || c > 126 || c == ':' {
+			return false
+		}
+	}
+	return true
+}
+
+// validateAttachments checks the attachments and the inline Content-IDs against the HTML body.
+func (m *EmailMessage) validateAttachments() []error {
+	var errs []error
+
+	contentIDs := make(map[string]bool)
+	for i, a := range m.Attachments {
+		if a.Filename == "" || strings.ContainsAny(a.Filename, "/\\\r\n\x00") {
+			errs = append(errs, fmt.Errorf("attachment %d: invalid file name %q", i, a.Filename))
+		}
+		if a.ContentType != "" {
+			if mediaType, _, err := mime.ParseMediaType(a.ContentType); err != nil || !strings.Contains(mediaType, "/") {
+				errs = append(errs, fmt.Errorf("attachment %q: invalid content type %q", a.Filename, a.ContentType))
+			}
+		}
+		if a.ContentID == "" {
+			continue
+		}
+		if contentIDs[a.ContentID] {
+			errs = append(errs, fmt.Errorf("attachment %q: duplicate Content-ID %q", a.Filename, a.ContentID))
+		}
+		contentIDs[a.ContentID] = true
+	}
+
+	referenced := make(map[string]bool)
+	for _, match := range cidReference.FindAllStringSubmatch(m.HTMLContent, -1) {
+		referenced[match[1]] = true
+		if !contentIDs[match[1]] {
+			errs = append(errs, fmt.Errorf("HTML body references cid:%s without matching inline attachment", match[1]))
+		}
+	}
+	for _, a := range m.Attachments {
+		if a.ContentID != "" && !referenced[a.ContentID] {
+			errs = append(errs, fmt.Errorf("attachment %q: inline Content-ID %q is not referenced from the HTML body", a.Filename, a.ContentID))
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,65 @@
+package goat
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestEmailMessage_Validate tests the Validate method of EmailMessage
+func TestEmailMessage_Validate(t *testing.T) {
+	valid := func() *EmailMessage {
+		return NewEmailMessage("test@example.com", "Test Subject", "Test Plain Text", `<p>Hi</p><img src="cid:logo">`).
+			WithReplyTo("Reply Name", "reply@example.com").
+			WithHeader("List-Unsubscribe", "<mailto:unsubscribe@example.com>").
+			WithAttachment("invoice.pdf", "application/pdf", []byte("PDF")).
+			WithInlineAttachment("logo.png", "image/png", []byte("PNG"), "logo")
+	}
+
+	t.Run("valid message", func(t *testing.T) {
+		assert.NoError(t, valid().Validate())
+		assert.NoError(t, NewEmailMessage("test@example.com", "Subject", "", "<p>HTML only</p>").Validate())
+		assert.NoError(t, NewEmailMessage("test@example.com", "Subject", "Text", "").WithAttachment("invoice.pdf", "", []byte("PDF")).Validate())
+	})
+
+	tests := []struct {
+		name     string
+		modify   func(m *EmailMessage)
+		expected string
+	}{
+		{"invalid recipient", func(m *EmailMessage) { m.To = "invalid" }, `invalid recipient address "invalid"`},
+		{"invalid from", func(m *EmailMessage) { m.WithFrom("Name", "invalid") }, `invalid from address "invalid"`},
+		{"invalid reply-to", func(m *EmailMessage) { m.WithReplyTo("Name", "invalid") }, `invalid reply-to address "invalid"`},
+		{"empty subject", func(m *EmailMessage) { m.Subject = "  " }, "empty subject"},
+		{"subject injection", func(m *EmailMessage) { m.Subject = "Hi\r\nBcc: evil@x.com" }, `header "Subject": value contains a line break`},
+		{"subject with control character", func(m *EmailMessage) { m.Subject = "Hi\x00" }, `header "Subject": value contains a control character`},
+		{"no body", func(m *EmailMessage) { m.PlainTextContent, m.HTMLContent, m.Attachments = "", "", nil }, "no plain text nor HTML body"},
+		{"invalid header name", func(m *EmailMessage) { m.WithHeader("X Bad:Name", "value") }, `header "X Bad:Name": invalid name`},
+		{"header injection", func(m *EmailMessage) { m.WithHeader("X-Custom", "value\r\nBcc: victim@example.com") }, `header "X-Custom": value contains a line break`},
+		{"reserved header", func(m *EmailMessage) { m.WithHeader("content-type", "text/plain") }, `header "content-type": reserved header cannot be set`},
+		{"blank tag", func(m *EmailMessage) { m.WithTags(" ") }, `invalid tag " "`},
+		{"tag with control character", func(m *EmailMessage) { m.WithTags("news\tletter") }, `invalid tag "news\tletter"`},
+		{"invalid file name", func(m *EmailMessage) { m.Attachments[0].Filename = "../etc/passwd" }, `attachment 0: invalid file name "../etc/passwd"`},
+		{"invalid content type", func(m *EmailMessage) { m.Attachments[0].ContentType = "pdf" }, `attachment "invoice.pdf": invalid content type "pdf"`},
+		{"duplicate content ID", func(m *EmailMessage) { m.WithInlineAttachment("logo2.png", "image/png", nil, "logo") }, `attachment "logo2.png": duplicate Content-ID "logo"`},
+		{"unreferenced content ID", func(m *EmailMessage) { m.WithInlineAttachment("banner.png", "image/png", nil, "banner") }, `attachment "banner.png": inline Content-ID "banner" is not referenced from the HTML body`},
+		{"dangling cid reference", func(m *EmailMessage) { m.HTMLContent += `<img src="cid:missing">` }, "HTML body references cid:missing without matching inline attachment"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := valid()
+			tt.modify(msg)
+
+			err := msg.Validate()
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expected)
+		})
+	}
+
+	t.Run("every problem is reported", func(t *testing.T) {
+		err := NewEmailMessage("invalid", "", "", "").Validate()
+		assert.Error(t, err)
+		assert.Equal(t, "invalid recipient address \"invalid\"\nempty subject\nno plain text nor HTML body", err.Error())
+	})
+}